@@ -19,14 +19,20 @@ package api
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/Peripli/service-manager/api/visibility"
 
 	"github.com/Peripli/service-manager/api/broker"
+	"github.com/Peripli/service-manager/api/catalog_override"
+	"github.com/Peripli/service-manager/api/operation"
 	"github.com/Peripli/service-manager/api/platform"
 
+	"github.com/Peripli/service-manager/api/service_binding"
+	"github.com/Peripli/service-manager/api/service_instance"
 	"github.com/Peripli/service-manager/api/service_offering"
 	"github.com/Peripli/service-manager/api/service_plan"
 
@@ -35,12 +41,14 @@ import (
 	"github.com/Peripli/service-manager/api/filters/authn/oauth"
 	"github.com/Peripli/service-manager/api/info"
 	"github.com/Peripli/service-manager/api/osb"
+	"github.com/Peripli/service-manager/pkg/brokerclient"
 	"github.com/Peripli/service-manager/pkg/health"
 	"github.com/Peripli/service-manager/pkg/security"
 	secfilters "github.com/Peripli/service-manager/pkg/security/filters"
+	"github.com/Peripli/service-manager/pkg/util"
 	"github.com/Peripli/service-manager/pkg/web"
 	"github.com/Peripli/service-manager/storage"
-	osbc "github.com/pmorie/go-open-service-broker-client/v2"
+	goidc "github.com/coreos/go-oidc"
 )
 
 // Settings type to be loaded from the environment
@@ -49,15 +57,43 @@ type Settings struct {
 	ClientID          string `mapstructure:"client_id"`
 	SkipSSLValidation bool   `mapstructure:"skip_ssl_validation"`
 	TokenBasicAuth    bool   `mapstructure:"token_basic_auth"`
+	// BrokerHealthInterval is the interval at which the catalogs of the brokers are probed; 0 disables the probes
+	BrokerHealthInterval time.Duration `mapstructure:"broker_health_interval"`
+	// OperationPollingInterval is the interval at which the asynchronous operations in progress are polled on behalf
+	// of the platforms; 0 disables the polling
+	OperationPollingInterval time.Duration `mapstructure:"operation_polling_interval"`
+	// MaximumPollingDuration is the time after which asynchronous operations on plans without maximum_polling_duration
+	// are considered failed; 0 means that such operations have no deadline
+	MaximumPollingDuration time.Duration `mapstructure:"maximum_polling_duration"`
+	// OrphanMitigationBackoff is the delay before the first retry of a failed orphan mitigation; it doubles on each retry
+	OrphanMitigationBackoff time.Duration `mapstructure:"orphan_mitigation_backoff"`
+	// OrphanMitigationMaxAttempts is the number of attempts after which an orphan mitigation is abandoned; 0 disables
+	// the orphan mitigation
+	OrphanMitigationMaxAttempts int `mapstructure:"orphan_mitigation_max_attempts"`
+	// EnforceVisibilities restricts the OSB catalogs of platforms to the plans visible to them and rejects their
+	// provision and update requests for other plans
+	EnforceVisibilities bool `mapstructure:"enforce_visibilities"`
+	// OriginatingIdentitySigningKey is the key with which the verified originating identities forwarded to the
+	// brokers are signed; empty disables the signing. Identities are only signed if a verification key is configured
+	OriginatingIdentitySigningKey string `mapstructure:"originating_identity_signing_key"`
+	// OriginatingIdentityVerificationKey is the key with which the signatures of the originating identities provided
+	// by the platforms are verified; empty disables the verification
+	OriginatingIdentityVerificationKey string `mapstructure:"originating_identity_verification_key"`
 }
 
 // DefaultSettings returns default values for API settings
 func DefaultSettings() *Settings {
 	return &Settings{
-		TokenIssuerURL:    "",
-		ClientID:          "",
-		SkipSSLValidation: false,
-		TokenBasicAuth:    true, // RFC 6749 section 2.3.1
+		TokenIssuerURL:       "",
+		ClientID:             "",
+		SkipSSLValidation:    false,
+		TokenBasicAuth:       true, // RFC 6749 section 2.3.1
+		BrokerHealthInterval: time.Minute,
+		// the default maximum polling duration of Cloud Foundry
+		MaximumPollingDuration:      7 * 24 * time.Hour,
+		OrphanMitigationBackoff:     10 * time.Second,
+		OrphanMitigationMaxAttempts: 10,
+		EnforceVisibilities:         true,
 	}
 }
 
@@ -66,22 +102,71 @@ func (s *Settings) Validate() error {
 	if (len(s.TokenIssuerURL)) == 0 {
 		return fmt.Errorf("validate Settings: APITokenIssuerURL missing")
 	}
+	if s.BrokerHealthInterval < 0 {
+		return fmt.Errorf("validate Settings: APIBrokerHealthInterval must not be negative")
+	}
+	if s.OperationPollingInterval < 0 {
+		return fmt.Errorf("validate Settings: APIOperationPollingInterval must not be negative")
+	}
+	if s.MaximumPollingDuration < 0 {
+		return fmt.Errorf("validate Settings: APIMaximumPollingDuration must not be negative")
+	}
+	if s.OrphanMitigationMaxAttempts < 0 {
+		return fmt.Errorf("validate Settings: APIOrphanMitigationMaxAttempts must not be negative")
+	}
+	if s.OrphanMitigationMaxAttempts > 0 && s.OrphanMitigationBackoff <= 0 {
+		return fmt.Errorf("validate Settings: APIOrphanMitigationBackoff must be positive")
+	}
 	return nil
 }
 
 // New returns the minimum set of REST APIs needed for the Service Manager
 func New(ctx context.Context, repository storage.Repository, settings *Settings, encrypter security.Encrypter) (*web.API, error) {
-	bearerAuthnFilter, err := oauth.NewFilter(ctx, settings.TokenIssuerURL, settings.ClientID)
+	// calls towards the token issuers honor the global skip SSL validation setting
+	httpClient := &http.Client{
+		Transport: util.NewTransport(&tls.Config{InsecureSkipVerify: settings.SkipSSLValidation}),
+	}
+	bearerAuthnFilter, err := oauth.NewFilter(goidc.ClientContext(ctx, httpClient), settings.TokenIssuerURL, settings.ClientID)
 	if err != nil {
 		return nil, err
 	}
-	return &web.API{
+	brokerClient := brokerclient.NewClient(
+		brokerclient.NewTransports(settings.SkipSSLValidation),
+		brokerclient.NewTokenSource(httpClient.Do),
+	)
+	brokerFetcher := &osb.StorageBrokerFetcher{
+		BrokerStorage: repository.Broker(),
+		Encrypter:     encrypter,
+	}
+	instanceTracker := &osb.StorageInstanceTracker{
+		Repository:             repository,
+		Encrypter:              encrypter,
+		MaximumPollingDuration: settings.MaximumPollingDuration,
+	}
+	var orphanMitigator osb.OrphanMitigator
+	if settings.OrphanMitigationMaxAttempts > 0 {
+		orphanMitigator = osb.NewStorageOrphanMitigator(ctx, repository, brokerFetcher, brokerClient, instanceTracker,
+			settings.OrphanMitigationBackoff, settings.OrphanMitigationMaxAttempts)
+	}
+	catalogFetcher := &osb.StorageCatalogFetcher{
+		CatalogStorage:         repository.ServiceOffering(),
+		CatalogOverrideStorage: repository.CatalogOverride(),
+	}
+	if settings.EnforceVisibilities {
+		catalogFetcher.VisibilityStorage = repository.Visibility()
+	}
+	var brokerHealthIndicator *broker.HealthIndicator
+	if settings.BrokerHealthInterval > 0 {
+		brokerHealthIndicator = broker.NewHealthIndicator(ctx, repository, encrypter, brokerClient, settings.BrokerHealthInterval)
+	}
+	api := &web.API{
 		// Default controllers - more filters can be registered using the relevant API methods
 		Controllers: []web.Controller{
 			&broker.Controller{
-				Repository:          repository,
-				OSBClientCreateFunc: newOSBClient(settings.SkipSSLValidation),
-				Encrypter:           encrypter,
+				Repository:      repository,
+				Encrypter:       encrypter,
+				BrokerClient:    brokerClient,
+				HealthIndicator: brokerHealthIndicator,
 			},
 			&platform.Controller{
 				PlatformStorage: repository.Platform(),
@@ -89,25 +174,33 @@ func New(ctx context.Context, repository storage.Repository, settings *Settings,
 			},
 			&service_offering.Controller{
 				ServiceOfferingStorage: repository.ServiceOffering(),
+				CatalogOverrideStorage: repository.CatalogOverride(),
 			},
 			&service_plan.Controller{
-				ServicePlanStorage: repository.ServicePlan(),
+				ServicePlanStorage:     repository.ServicePlan(),
+				CatalogOverrideStorage: repository.CatalogOverride(),
 			},
 			&visibility.Controller{
 				Repository: repository,
 			},
+			&catalog_override.Controller{
+				CatalogOverrideStorage: repository.CatalogOverride(),
+			},
+			&service_instance.Controller{
+				ServiceInstanceStorage: repository.ServiceInstance(),
+			},
+			&service_binding.Controller{
+				ServiceBindingStorage: repository.ServiceBinding(),
+			},
+			&operation.Controller{
+				OperationStorage: repository.Operation(),
+			},
 			&info.Controller{
 				TokenIssuer:    settings.TokenIssuerURL,
 				TokenBasicAuth: settings.TokenBasicAuth,
 			},
-			osb.NewController(&osb.StorageBrokerFetcher{
-				BrokerStorage: repository.Broker(),
-				Encrypter:     encrypter,
-			}, &osb.StorageCatalogFetcher{
-				CatalogStorage: repository.ServiceOffering(),
-			},
-				http.DefaultTransport,
-			),
+			osb.NewController(brokerFetcher, catalogFetcher, brokerClient, instanceTracker, orphanMitigator, repository),
+			osb.NewAggregatedController(brokerFetcher, catalogFetcher, brokerClient, instanceTracker, orphanMitigator, repository),
 		},
 		// Default filters - more filters can be registered using the relevant API methods
 		Filters: []web.Filter{
@@ -116,14 +209,24 @@ func New(ctx context.Context, repository storage.Repository, settings *Settings,
 			bearerAuthnFilter,
 			secfilters.NewRequiredAuthnFilter(),
 			&filters.SelectionCriteria{},
+			&osb.CatalogOverrideFilter{Repository: repository},
+			&osb.ParametersValidationFilter{Repository: repository},
+			&osb.OriginatingIdentityFilter{
+				SigningKey:      settings.OriginatingIdentitySigningKey,
+				VerificationKey: settings.OriginatingIdentityVerificationKey,
+			},
 		},
 		Registry: health.NewDefaultRegistry(),
-	}, nil
-}
-
-func newOSBClient(skipSsl bool) osbc.CreateFunc {
-	return func(configuration *osbc.ClientConfiguration) (osbc.Client, error) {
-		configuration.Insecure = skipSsl
-		return osbc.NewClient(configuration)
 	}
+	if settings.EnforceVisibilities {
+		api.RegisterFilters(&osb.VisibilityFilter{Repository: repository})
+	}
+	api.RegisterPlugins(&osb.PlatformContextPlugin{Repository: repository})
+	if settings.OperationPollingInterval > 0 {
+		osb.NewOperationPoller(ctx, repository, brokerFetcher, brokerClient, instanceTracker, settings.OperationPollingInterval)
+	}
+	if brokerHealthIndicator != nil {
+		api.AddHealthIndicator(brokerHealthIndicator)
+	}
+	return api, nil
 }
@@ -68,5 +68,19 @@ func (c *Controller) Routes() []web.Route {
 			},
 			Handler: c.patchBroker,
 		},
+		{
+			Endpoint: web.Endpoint{
+				Method: http.MethodPut,
+				Path:   web.BrokersURL + "/{broker_id}/credentials",
+			},
+			Handler: c.updateBrokerCredentials,
+		},
+		{
+			Endpoint: web.Endpoint{
+				Method: http.MethodGet,
+				Path:   web.BrokersURL + "/{broker_id}/health",
+			},
+			Handler: c.getBrokerHealth,
+		},
 	}
 }
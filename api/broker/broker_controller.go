@@ -18,18 +18,24 @@ package broker
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 
 	"github.com/Peripli/service-manager/pkg/query"
 
+	"github.com/Peripli/service-manager/pkg/brokerclient"
+	"github.com/Peripli/service-manager/pkg/catalog"
+	"github.com/Peripli/service-manager/pkg/health"
 	"github.com/Peripli/service-manager/pkg/log"
 	"github.com/Peripli/service-manager/pkg/security"
-	osbc "github.com/pmorie/go-open-service-broker-client/v2"
 
 	"github.com/Peripli/service-manager/pkg/types"
 	"github.com/Peripli/service-manager/pkg/util"
@@ -46,17 +52,18 @@ const (
 type Controller struct {
 	Repository storage.Repository
 
-	OSBClientCreateFunc osbc.CreateFunc
-	Encrypter           security.Encrypter
-}
+	Encrypter    security.Encrypter
+	BrokerClient *brokerclient.Client
 
-var _ web.Controller = &Controller{}
+	// CatalogTransformers are invoked in order on the broker catalog before it is stored
+	CatalogTransformers catalog.Transformers
 
-type catalogPlanWithServiceOfferingID struct {
-	*osbc.Plan
-	ServiceOffering *types.ServiceOffering
+	// HealthIndicator provides the results of the broker health probes; nil if the probes are disabled
+	HealthIndicator *HealthIndicator
 }
 
+var _ web.Controller = &Controller{}
+
 func (c *Controller) createBroker(r *web.Request) (*web.Response, error) {
 	ctx := r.Context()
 	log.C(ctx).Debug("Creating new broker")
@@ -77,11 +84,19 @@ func (c *Controller) createBroker(r *web.Request) (*web.Response, error) {
 	broker.CreatedAt = currentTime
 	broker.UpdatedAt = currentTime
 
-	catalog, err := c.getBrokerCatalog(ctx, broker)
+	catalog, _, err := c.fetchBrokerCatalog(ctx, broker)
 	if err != nil {
 		return nil, err
 	}
 
+	if catalog, err = c.transformBrokerCatalog(ctx, broker, catalog); err != nil {
+		return nil, err
+	}
+
+	if err := validateBrokerCatalog(ctx, broker, catalog); err != nil {
+		return nil, err
+	}
+
 	if err := transformBrokerCredentials(ctx, broker, c.Encrypter.Encrypt); err != nil {
 		return nil, err
 	}
@@ -93,7 +108,7 @@ func (c *Controller) createBroker(r *web.Request) (*web.Response, error) {
 		}
 		for _, service := range catalog.Services {
 			serviceOffering := &types.ServiceOffering{}
-			err := osbcCatalogServiceToServiceOffering(serviceOffering, &service)
+			err := catalogServiceToServiceOffering(serviceOffering, service)
 			if err != nil {
 				return err
 			}
@@ -119,10 +134,10 @@ func (c *Controller) createBroker(r *web.Request) (*web.Response, error) {
 				return util.HandleStorageError(err, "service_offering")
 			}
 			serviceOffering.ID = serviceID
-			for planIndex := range service.Plans {
+			for _, plan := range service.Plans {
 				servicePlan := &types.ServicePlan{}
-				err := osbcCatalogPlanToServicePlan(servicePlan, &catalogPlanWithServiceOfferingID{
-					Plan:            &service.Plans[planIndex],
+				err := catalogPlanToServicePlan(servicePlan, &catalogPlanWithServiceOfferingID{
+					catalogPlan:     plan,
 					ServiceOffering: serviceOffering,
 				})
 				if err != nil {
@@ -155,6 +170,7 @@ func (c *Controller) createBroker(r *web.Request) (*web.Response, error) {
 	}
 
 	broker.Credentials = nil
+	broker.BindingCredentials = nil
 	return util.NewJSONResponse(http.StatusCreated, broker)
 }
 
@@ -169,9 +185,32 @@ func (c *Controller) getBroker(r *web.Request) (*web.Response, error) {
 	}
 
 	broker.Credentials = nil
+	broker.BindingCredentials = nil
 	return util.NewJSONResponse(http.StatusOK, broker)
 }
 
+func (c *Controller) getBrokerHealth(r *web.Request) (*web.Response, error) {
+	brokerID := r.PathParams[reqBrokerID]
+	ctx := r.Context()
+	log.C(ctx).Debugf("Getting health of broker with id %s", brokerID)
+
+	if _, err := c.Repository.Broker().Get(ctx, brokerID); err != nil {
+		return nil, util.HandleStorageError(err, "broker")
+	}
+	var healthz *health.Health
+	if c.HealthIndicator != nil {
+		healthz = c.HealthIndicator.BrokerHealth(brokerID)
+	}
+	if healthz == nil {
+		return nil, &util.HTTPError{
+			ErrorType:   "NotFound",
+			Description: fmt.Sprintf("broker with id %s has not been probed", brokerID),
+			StatusCode:  http.StatusNotFound,
+		}
+	}
+	return util.NewJSONResponse(http.StatusOK, healthz)
+}
+
 func (c *Controller) listBrokers(r *web.Request) (*web.Response, error) {
 	var brokers []*types.Broker
 	var err error
@@ -185,6 +224,7 @@ func (c *Controller) listBrokers(r *web.Request) (*web.Response, error) {
 
 	for _, broker := range brokers {
 		broker.Credentials = nil
+		broker.BindingCredentials = nil
 	}
 
 	return util.NewJSONResponse(http.StatusOK, &types.Brokers{
@@ -211,6 +251,7 @@ func (c *Controller) deleteBroker(r *web.Request) (*web.Response, error) {
 	if err := c.Repository.Broker().Delete(ctx, byID); err != nil {
 		return nil, util.HandleStorageError(err, "broker")
 	}
+	c.BrokerClient.Evict(brokerID)
 	return util.NewJSONResponse(http.StatusOK, map[string]int{})
 }
 
@@ -228,6 +269,9 @@ func (c *Controller) patchBroker(r *web.Request) (*web.Response, error) {
 		return nil, err
 	}
 	createdAt := broker.CreatedAt
+	brokerType := broker.Type
+	apiVersion := broker.APIVersion
+	settings := catalogSettingsOf(broker)
 
 	changes, err := query.LabelChangesFromJSON(r.Body)
 	if err != nil {
@@ -240,15 +284,52 @@ func (c *Controller) patchBroker(r *web.Request) (*web.Response, error) {
 	if err := util.BytesToObject(r.Body, broker); err != nil {
 		return nil, err
 	}
+	if gjson.GetBytes(r.Body, "credentials").Exists() {
+		// the credentials replaced by a rotation must not remain usable once other credentials are set
+		broker.PreviousCredentials = nil
+		broker.PreviousCredentialsExpireAt = time.Time{}
+	}
 
 	broker.ID = brokerID
+	broker.Type = brokerType
+	broker.APIVersion = apiVersion
 	broker.CreatedAt = createdAt
 	broker.UpdatedAt = time.Now().UTC()
+	if len(changes) > 0 || catalogSettingsOf(broker) != settings {
+		// the stored catalog was transformed and validated with the previous settings of the broker and the validators
+		// returned by another broker url cannot be used for conditional catalog requests, so the catalog is fetched
+		// unconditionally and resynced even if the broker has not changed it
+		broker.CatalogETag = ""
+		broker.CatalogLastModified = ""
+		broker.CatalogHash = ""
+	}
 
-	catalog, err := c.getBrokerCatalog(ctx, broker)
+	if broker.State == types.BrokerStateDisabled {
+		// disabled brokers are often unreachable, so their catalog is resynced once they are enabled again
+		log.C(ctx).Infof("Broker with id %s is disabled. Skipping catalog resync", brokerID)
+		return c.updateBroker(ctx, broker, changes)
+	}
+	if broker.IsVirtual() && len(broker.Catalog) == 0 {
+		log.C(ctx).Infof("No catalog provided for virtual broker with id %s. Skipping catalog resync", brokerID)
+		return c.updateBroker(ctx, broker, changes)
+	}
+
+	catalog, catalogModified, err := c.fetchBrokerCatalog(ctx, broker)
 	if err != nil {
 		return nil, err
 	}
+	if !catalogModified {
+		log.C(ctx).Infof("Catalog of broker with id %s has not changed. Skipping catalog resync", brokerID)
+		return c.updateBroker(ctx, broker, changes)
+	}
+
+	if catalog, err = c.transformBrokerCatalog(ctx, broker, catalog); err != nil {
+		return nil, err
+	}
+
+	if err := validateBrokerCatalog(ctx, broker, catalog); err != nil {
+		return nil, err
+	}
 
 	if err := transformBrokerCredentials(ctx, broker, c.Encrypter.Encrypt); err != nil {
 		return nil, err
@@ -259,144 +340,257 @@ func (c *Controller) patchBroker(r *web.Request) (*web.Response, error) {
 	}
 
 	broker.Credentials = nil
+	broker.BindingCredentials = nil
 	return util.NewJSONResponse(http.StatusOK, broker)
 }
 
-func convertExistingServiceOfferringsToMaps(serviceOfferings []*types.ServiceOffering) (map[string]*types.ServiceOffering, map[string][]*types.ServicePlan) {
-	serviceOfferingsMap := make(map[string]*types.ServiceOffering)
-	servicePlansMap := make(map[string][]*types.ServicePlan)
+// catalogSettings are the broker settings which the catalog transformers and the catalog validation depend on
+type catalogSettings struct {
+	name              string
+	brokerURL         string
+	catalogValidation string
+}
 
-	for serviceOfferingIndex := range serviceOfferings {
-		serviceOfferingsMap[serviceOfferings[serviceOfferingIndex].CatalogID] = serviceOfferings[serviceOfferingIndex]
-		for servicePlanIndex := range serviceOfferings[serviceOfferingIndex].Plans {
-			servicePlansMap[serviceOfferings[serviceOfferingIndex].CatalogID] = append(servicePlansMap[serviceOfferings[serviceOfferingIndex].CatalogID], serviceOfferings[serviceOfferingIndex].Plans[servicePlanIndex])
-		}
+func catalogSettingsOf(broker *types.Broker) catalogSettings {
+	return catalogSettings{
+		name:              broker.Name,
+		brokerURL:         broker.BrokerURL,
+		catalogValidation: broker.CatalogValidation,
 	}
+}
 
-	return serviceOfferingsMap, servicePlansMap
+// updateBroker stores the broker without resyncing its catalog
+func (c *Controller) updateBroker(ctx context.Context, broker *types.Broker, changes []*query.LabelChange) (*web.Response, error) {
+	if err := transformBrokerCredentials(ctx, broker, c.Encrypter.Encrypt); err != nil {
+		return nil, err
+	}
+	if err := c.Repository.Broker().Update(ctx, broker, changes...); err != nil {
+		return nil, util.HandleStorageError(err, "broker")
+	}
+	broker.Credentials = nil
+	broker.BindingCredentials = nil
+	return util.NewJSONResponse(http.StatusOK, broker)
 }
 
-func (c *Controller) getBrokerCatalog(ctx context.Context, broker *types.Broker) (*osbc.CatalogResponse, error) {
-	osbClient, err := osbcClient(ctx, c.OSBClientCreateFunc, broker)
+// brokerCredentialsRotation is the request body of the broker credentials endpoint
+type brokerCredentialsRotation struct {
+	Credentials *types.Credentials `json:"credentials"`
+	GracePeriod string             `json:"grace_period,omitempty"`
+	Verify      bool               `json:"verify,omitempty"`
+
+	gracePeriod time.Duration
+}
+
+// Validate implements InputValidator and verifies the credentials and the grace period
+func (r *brokerCredentialsRotation) Validate() error {
+	if r.Credentials == nil {
+		return errors.New("missing credentials")
+	}
+	if err := r.Credentials.Validate(); err != nil {
+		return err
+	}
+	if r.GracePeriod == "" {
+		return nil
+	}
+	gracePeriod, err := time.ParseDuration(r.GracePeriod)
 	if err != nil {
+		return fmt.Errorf("invalid grace period %s: %s", r.GracePeriod, err)
+	}
+	if gracePeriod < 0 {
+		return fmt.Errorf("invalid grace period %s: must not be negative", r.GracePeriod)
+	}
+	r.gracePeriod = gracePeriod
+	return nil
+}
+
+// updateBrokerCredentials stores new broker credentials without resyncing the broker catalog. The replaced
+// credentials are still tried during the requested grace period.
+func (c *Controller) updateBrokerCredentials(r *web.Request) (*web.Response, error) {
+	brokerID := r.PathParams[reqBrokerID]
+	ctx := r.Context()
+	log.C(ctx).Debugf("Rotating credentials of broker with id %s", brokerID)
+
+	rotation := &brokerCredentialsRotation{}
+	if err := util.BytesToObject(r.Body, rotation); err != nil {
 		return nil, err
 	}
-	catalog, err := osbClient.GetCatalog()
+
+	broker, err := c.Repository.Broker().Get(ctx, brokerID)
 	if err != nil {
+		return nil, util.HandleStorageError(err, "broker")
+	}
+	if broker.IsVirtual() {
 		return nil, &util.HTTPError{
-			ErrorType:   "BrokerError",
-			Description: fmt.Sprintf("error fetching catalog from broker %s: %v", broker.Name, err),
+			ErrorType:   "BadRequest",
+			Description: fmt.Sprintf("virtual broker %s has no credentials", broker.Name),
 			StatusCode:  http.StatusBadRequest,
 		}
 	}
+	if err := transformBrokerCredentials(ctx, broker, c.Encrypter.Decrypt); err != nil {
+		return nil, err
+	}
+
+	if rotation.Verify {
+		verifiedBroker := *broker
+		verifiedBroker.Credentials = rotation.Credentials
+		verifiedBroker.PreviousCredentials = nil
+		if _, err := c.BrokerClient.GetCatalog(ctx, &verifiedBroker); err != nil {
+			return nil, &util.HTTPError{
+				ErrorType:   "BrokerError",
+				Description: fmt.Sprintf("could not verify the new credentials of broker %s: %v", broker.Name, err),
+				StatusCode:  http.StatusBadRequest,
+			}
+		}
+	}
 
-	return catalog, nil
+	now := time.Now().UTC()
+	if rotation.gracePeriod > 0 {
+		broker.PreviousCredentials = broker.Credentials
+		broker.PreviousCredentialsExpireAt = now.Add(rotation.gracePeriod)
+	} else {
+		broker.PreviousCredentials = nil
+		broker.PreviousCredentialsExpireAt = time.Time{}
+	}
+	broker.Credentials = rotation.Credentials
+	broker.UpdatedAt = now
+
+	if err := transformBrokerCredentials(ctx, broker, c.Encrypter.Encrypt); err != nil {
+		return nil, err
+	}
+	if err := c.Repository.Broker().Update(ctx, broker); err != nil {
+		return nil, util.HandleStorageError(err, "broker")
+	}
+
+	broker.Credentials = nil
+	broker.BindingCredentials = nil
+	return util.NewJSONResponse(http.StatusOK, broker)
 }
 
-func getBrokerCatalogServicesAndPlans(catalog *osbc.CatalogResponse) ([]*osbc.Service, map[string][]*osbc.Plan, error) {
-	services := make([]*osbc.Service, 0, len(catalog.Services))
-	plans := make(map[string][]*osbc.Plan)
+func convertExistingServiceOfferringsToMaps(serviceOfferings []*types.ServiceOffering) (map[string]*types.ServiceOffering, map[string][]*types.ServicePlan) {
+	serviceOfferingsMap := make(map[string]*types.ServiceOffering)
+	servicePlansMap := make(map[string][]*types.ServicePlan)
 
-	for serviceIndex := range catalog.Services {
-		services = append(services, &catalog.Services[serviceIndex])
-		plansForService := make([]*osbc.Plan, 0)
-		for planIndex := range catalog.Services[serviceIndex].Plans {
-			plansForService = append(plansForService, &catalog.Services[serviceIndex].Plans[planIndex])
+	for serviceOfferingIndex := range serviceOfferings {
+		serviceOfferingsMap[serviceOfferings[serviceOfferingIndex].CatalogID] = serviceOfferings[serviceOfferingIndex]
+		for servicePlanIndex := range serviceOfferings[serviceOfferingIndex].Plans {
+			servicePlansMap[serviceOfferings[serviceOfferingIndex].CatalogID] = append(servicePlansMap[serviceOfferings[serviceOfferingIndex].CatalogID], serviceOfferings[serviceOfferingIndex].Plans[servicePlanIndex])
 		}
-		plans[catalog.Services[serviceIndex].ID] = plansForService
 	}
-	return services, plans, nil
+
+	return serviceOfferingsMap, servicePlansMap
 }
 
-func osbcCatalogServiceToServiceOffering(serviceOffering *types.ServiceOffering, service *osbc.Service) error {
-	serviceTagsBytes, err := json.Marshal(service.Tags)
-	if err != nil {
-		return fmt.Errorf("could not marshal service tags: %s", err)
+// fetchBrokerCatalog returns the catalog provided in the request for virtual brokers and fetches the catalog
+// from the service broker otherwise. The returned flag reports whether the catalog differs from the last stored one.
+func (c *Controller) fetchBrokerCatalog(ctx context.Context, broker *types.Broker) (*brokerCatalog, bool, error) {
+	if !broker.IsVirtual() {
+		return c.getBrokerCatalog(ctx, broker)
 	}
-	serviceRequiresBytes, err := json.Marshal(service.Requires)
-	if err != nil {
-		return fmt.Errorf("could not marshal service requires: %s", err)
+	if len(broker.Catalog) == 0 {
+		return nil, false, &util.HTTPError{
+			ErrorType:   "BadRequest",
+			Description: fmt.Sprintf("missing catalog for virtual broker %s", broker.Name),
+			StatusCode:  http.StatusBadRequest,
+		}
+	}
+	catalog := &brokerCatalog{}
+	if err := json.Unmarshal(broker.Catalog, catalog); err != nil {
+		return nil, false, &util.HTTPError{
+			ErrorType:   "BadRequest",
+			Description: fmt.Sprintf("invalid catalog for virtual broker %s: %v", broker.Name, err),
+			StatusCode:  http.StatusBadRequest,
+		}
 	}
-	serviceMetadataBytes, err := json.Marshal(service.Metadata)
+	// the catalog is stored as service offerings and plans
+	broker.Catalog = nil
+	// virtual brokers are answered by the Service Manager itself
+	broker.APIVersion = brokerclient.APIVersions[0]
+	return catalog, true, nil
+}
+
+// getBrokerCatalog fetches the catalog of the broker conditionally using the validators of the last stored catalog.
+// The catalog is reported as not modified if the broker confirms it has not changed or if its hash matches the hash
+// of the last stored catalog. The validators, the hash and the negotiated API version of the broker are updated.
+func (c *Controller) getBrokerCatalog(ctx context.Context, broker *types.Broker) (*brokerCatalog, bool, error) {
+	log.C(ctx).Debugf("Fetching catalog of service broker with name %s accessible at %s", broker.Name, broker.BrokerURL)
+	response, err := c.BrokerClient.GetCatalogIfModified(ctx, broker, broker.CatalogETag, broker.CatalogLastModified)
 	if err != nil {
-		return fmt.Errorf("could not marshal service metadata: %s", err)
+		return nil, false, &util.HTTPError{
+			ErrorType:   "BrokerError",
+			Description: fmt.Sprintf("error fetching catalog from broker %s: %v", broker.Name, err),
+			StatusCode:  http.StatusBadRequest,
+		}
+	}
+	broker.APIVersion = response.APIVersion
+	if response.NotModified {
+		log.C(ctx).Debugf("Broker with name %s reported that its catalog is not modified", broker.Name)
+		return nil, false, nil
 	}
 
-	serviceOffering.Name = service.Name
-	serviceOffering.Description = service.Description
-	serviceOffering.Bindable = service.Bindable
-	serviceOffering.InstancesRetrievable = service.BindingsRetrievable
-	serviceOffering.BindingsRetrievable = service.BindingsRetrievable
-	serviceOffering.PlanUpdatable = boolPointerToBool(service.PlanUpdatable, serviceOffering.PlanUpdatable)
-	serviceOffering.CatalogID = service.ID
-	serviceOffering.CatalogName = service.Name
-	serviceOffering.Tags = json.RawMessage(serviceTagsBytes)
-	serviceOffering.Requires = json.RawMessage(serviceRequiresBytes)
-	serviceOffering.Metadata = json.RawMessage(serviceMetadataBytes)
+	catalog := &brokerCatalog{}
+	if err := json.Unmarshal(response.Catalog, catalog); err != nil {
+		return nil, false, &util.HTTPError{
+			ErrorType:   "BrokerError",
+			Description: fmt.Sprintf("error fetching catalog from broker %s: invalid catalog: %v", broker.Name, err),
+			StatusCode:  http.StatusBadRequest,
+		}
+	}
 
-	return nil
+	catalogHash := fmt.Sprintf("%x", sha256.Sum256(response.Catalog))
+	modified := catalogHash != broker.CatalogHash
+	broker.CatalogETag = response.ETag
+	broker.CatalogLastModified = response.LastModified
+	broker.CatalogHash = catalogHash
+	return catalog, modified, nil
 }
 
-func osbcCatalogPlanToServicePlan(servicePlan *types.ServicePlan, plan *catalogPlanWithServiceOfferingID) error {
-	planMetadataBytes, err := json.Marshal(plan.Metadata)
+// validateBrokerCatalog checks the broker catalog for violations of the OSB specification. Catalogs of brokers with
+// lenient catalog validation are accepted regardless of the violations which are only logged.
+func validateBrokerCatalog(ctx context.Context, broker *types.Broker, catalog *brokerCatalog) error {
+	serviceOfferings, err := catalogToServiceOfferings(catalog)
 	if err != nil {
-		return fmt.Errorf("could not marshal plan metadata: %s", err)
+		return err
 	}
-	schemasBytes := make([]byte, 0)
-	if plan.Schemas != nil {
-		schemasBytes, err = json.Marshal(plan.Schemas)
-		if err != nil {
-			return fmt.Errorf("could not marshal plan service instance create schema: %s", err)
-		}
+	violations := validateCatalog(serviceOfferings)
+	if len(violations) == 0 {
+		return nil
 	}
 
-	servicePlan.Name = plan.Plan.Name
-	servicePlan.Description = plan.Plan.Description
-	servicePlan.CatalogID = plan.Plan.ID
-	servicePlan.CatalogName = plan.Plan.Name
-	servicePlan.Free = boolPointerToBool(plan.Plan.Free, servicePlan.Free)
-	servicePlan.Bindable = boolPointerToBool(plan.Plan.Bindable, plan.ServiceOffering.Bindable)
-	servicePlan.PlanUpdatable = boolPointerToBool(&plan.ServiceOffering.PlanUpdatable, servicePlan.PlanUpdatable)
-	servicePlan.Metadata = json.RawMessage(planMetadataBytes)
-	servicePlan.Schemas = schemasBytes
-	servicePlan.ServiceOfferingID = plan.ServiceOffering.ID
+	if broker.CatalogValidation == types.CatalogValidationLenient {
+		for _, violation := range violations {
+			log.C(ctx).Warnf("Catalog of broker %s does not conform to the OSB specification: %s", broker.Name, violation)
+		}
+		return nil
+	}
 
-	return nil
+	messages := make([]string, 0, len(violations))
+	for _, violation := range violations {
+		messages = append(messages, violation.String())
+	}
+	return &util.HTTPError{
+		ErrorType:   "BadRequest",
+		Description: fmt.Sprintf("catalog of broker %s does not conform to the OSB specification: %s", broker.Name, strings.Join(messages, "; ")),
+		Details:     violations,
+		StatusCode:  http.StatusBadRequest,
+	}
 }
 
-func osbcClient(ctx context.Context, createFunc osbc.CreateFunc, broker *types.Broker) (osbc.Client, error) {
-	config := osbc.DefaultClientConfiguration()
-	config.Name = broker.Name
-	config.URL = broker.BrokerURL
-	config.AuthConfig = &osbc.AuthConfig{
-		BasicAuthConfig: &osbc.BasicAuthConfig{
-			Username: broker.Credentials.Basic.Username,
-			Password: broker.Credentials.Basic.Password,
-		},
-	}
-	log.C(ctx).Debug("Building OSB client for service broker with name: ", config.Name, " accessible at: ", config.URL)
-	return createFunc(config)
-}
+func getBrokerCatalogServicesAndPlans(catalog *brokerCatalog) ([]*catalogService, map[string][]*catalogPlan, error) {
+	services := make([]*catalogService, 0, len(catalog.Services))
+	plans := make(map[string][]*catalogPlan)
 
-func transformBrokerCredentials(ctx context.Context, broker *types.Broker, transformationFunc func(context.Context, []byte) ([]byte, error)) error {
-	if broker.Credentials != nil {
-		transformedPassword, err := transformationFunc(ctx, []byte(broker.Credentials.Basic.Password))
-		if err != nil {
-			return err
-		}
-		broker.Credentials.Basic.Password = string(transformedPassword)
+	for _, service := range catalog.Services {
+		services = append(services, service)
+		plans[service.ID] = append(make([]*catalogPlan, 0, len(service.Plans)), service.Plans...)
 	}
-	return nil
+	return services, plans, nil
 }
 
-func boolPointerToBool(value *bool, defaultValue bool) bool {
-	if value == nil {
-		return defaultValue
-	}
-	return *value
+func transformBrokerCredentials(ctx context.Context, broker *types.Broker, transformationFunc types.TransformFunc) error {
+	return broker.TransformSecrets(ctx, transformationFunc)
 }
 
-func (c *Controller) resyncBrokerAndCatalog(ctx context.Context, broker *types.Broker, catalog *osbc.CatalogResponse, changes []*query.LabelChange) error {
+func (c *Controller) resyncBrokerAndCatalog(ctx context.Context, broker *types.Broker, catalog *brokerCatalog, changes []*query.LabelChange) error {
 	log.C(ctx).Debugf("Updating catalog storage for broker with id %s", broker.ID)
 	if err := c.Repository.InTransaction(ctx, func(ctx context.Context, txStorage storage.Warehouse) error {
 		if err := txStorage.Broker().Update(ctx, broker, changes...); err != nil {
@@ -425,7 +619,7 @@ func (c *Controller) resyncBrokerAndCatalog(ctx context.Context, broker *types.B
 			existingServiceOffering, ok := existingServicesOfferingsMap[catalogService.ID]
 			delete(existingServicesOfferingsMap, catalogService.ID)
 			if ok {
-				if err := osbcCatalogServiceToServiceOffering(existingServiceOffering, catalogService); err != nil {
+				if err := catalogServiceToServiceOffering(existingServiceOffering, catalogService); err != nil {
 					return err
 				}
 				existingServiceOffering.UpdatedAt = time.Now().UTC()
@@ -446,7 +640,7 @@ func (c *Controller) resyncBrokerAndCatalog(ctx context.Context, broker *types.B
 					return fmt.Errorf("could not generate GUID for service_plan: %s", err)
 				}
 				existingServiceOffering = &types.ServiceOffering{}
-				if err := osbcCatalogServiceToServiceOffering(existingServiceOffering, catalogService); err != nil {
+				if err := catalogServiceToServiceOffering(existingServiceOffering, catalogService); err != nil {
 					return err
 				}
 				existingServiceOffering.ID = serviceUUID.String()
@@ -472,7 +666,7 @@ func (c *Controller) resyncBrokerAndCatalog(ctx context.Context, broker *types.B
 			catalogPlansForService := catalogPlansMap[catalogService.ID]
 			for catalogPlanOfCatalogServiceIndex := range catalogPlansForService {
 				catalogPlan := &catalogPlanWithServiceOfferingID{
-					Plan:            catalogPlansForService[catalogPlanOfCatalogServiceIndex],
+					catalogPlan:     catalogPlansForService[catalogPlanOfCatalogServiceIndex],
 					ServiceOffering: existingServiceOffering,
 				}
 				catalogPlans = append(catalogPlans, catalogPlan)
@@ -501,10 +695,15 @@ func (c *Controller) resyncBrokerAndCatalog(ctx context.Context, broker *types.B
 					}
 				}
 				if existingPlan != nil {
-					if err := osbcCatalogPlanToServicePlan(existingPlan, catalogPlan); err != nil {
+					previousMaintenanceVersion := existingPlan.MaintenanceVersion()
+					if err := catalogPlanToServicePlan(existingPlan, catalogPlan); err != nil {
 						return err
 					}
 					existingPlan.UpdatedAt = time.Now().UTC()
+					if maintenanceVersion := existingPlan.MaintenanceVersion(); maintenanceVersion != "" && maintenanceVersion != previousMaintenanceVersion {
+						log.C(ctx).Infof("Maintenance version of plan with catalog id %s of broker with id %s changed from %q to %q", existingPlan.CatalogID, broker.ID, previousMaintenanceVersion, maintenanceVersion)
+						existingPlan.MaintenanceUpdatedAt = existingPlan.UpdatedAt
+					}
 
 					if err := existingPlan.Validate(); err != nil {
 						return &util.HTTPError{
@@ -559,7 +758,7 @@ func createPlan(ctx context.Context, txStorage storage.Warehouse, catalogPlan *c
 		return fmt.Errorf("could not generate GUID for service_plan: %s", err)
 	}
 	servicePlan := &types.ServicePlan{}
-	if err := osbcCatalogPlanToServicePlan(servicePlan, catalogPlan); err != nil {
+	if err := catalogPlanToServicePlan(servicePlan, catalogPlan); err != nil {
 		return err
 	}
 	servicePlan.ID = planUUID.String()
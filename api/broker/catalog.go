@@ -0,0 +1,141 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Peripli/service-manager/pkg/catalog"
+	"github.com/Peripli/service-manager/pkg/types"
+)
+
+// brokerCatalog is the catalog as published by the broker on /v2/catalog
+type brokerCatalog = catalog.Catalog
+
+type catalogService = catalog.Service
+
+type catalogPlan = catalog.Plan
+
+type catalogPlanWithServiceOfferingID struct {
+	*catalogPlan
+	ServiceOffering *types.ServiceOffering
+}
+
+// transformBrokerCatalog passes the catalog fetched from the broker through the registered catalog transformers
+// and returns the catalog that should be stored
+func (c *Controller) transformBrokerCatalog(ctx context.Context, broker *types.Broker, fetchedCatalog *brokerCatalog) (*brokerCatalog, error) {
+	if len(c.CatalogTransformers) == 0 {
+		return fetchedCatalog, nil
+	}
+	var transformedCatalog *brokerCatalog
+	handler := c.CatalogTransformers.Chain(catalog.HandlerFunc(func(ctx context.Context, req *catalog.Request) error {
+		transformedCatalog = req.Catalog
+		return nil
+	}))
+	if err := handler.Handle(ctx, &catalog.Request{Broker: broker, Catalog: fetchedCatalog}); err != nil {
+		return nil, err
+	}
+	if transformedCatalog == nil {
+		return nil, fmt.Errorf("catalog of broker %s was not passed through all catalog transformers", broker.Name)
+	}
+	return transformedCatalog, nil
+}
+
+func catalogToServiceOfferings(catalog *brokerCatalog) ([]*types.ServiceOffering, error) {
+	serviceOfferings := make([]*types.ServiceOffering, 0, len(catalog.Services))
+	for _, service := range catalog.Services {
+		serviceOffering := &types.ServiceOffering{}
+		if err := catalogServiceToServiceOffering(serviceOffering, service); err != nil {
+			return nil, err
+		}
+		for _, plan := range service.Plans {
+			servicePlan := &types.ServicePlan{}
+			if err := catalogPlanToServicePlan(servicePlan, &catalogPlanWithServiceOfferingID{
+				catalogPlan:     plan,
+				ServiceOffering: serviceOffering,
+			}); err != nil {
+				return nil, err
+			}
+			serviceOffering.Plans = append(serviceOffering.Plans, servicePlan)
+		}
+		serviceOfferings = append(serviceOfferings, serviceOffering)
+	}
+	return serviceOfferings, nil
+}
+
+func catalogServiceToServiceOffering(serviceOffering *types.ServiceOffering, service *catalogService) error {
+	serviceTagsBytes, err := json.Marshal(service.Tags)
+	if err != nil {
+		return fmt.Errorf("could not marshal service tags: %s", err)
+	}
+	serviceRequiresBytes, err := json.Marshal(service.Requires)
+	if err != nil {
+		return fmt.Errorf("could not marshal service requires: %s", err)
+	}
+	serviceMetadataBytes, err := json.Marshal(service.Metadata)
+	if err != nil {
+		return fmt.Errorf("could not marshal service metadata: %s", err)
+	}
+
+	serviceOffering.Name = service.Name
+	serviceOffering.Description = service.Description
+	serviceOffering.Bindable = service.Bindable
+	serviceOffering.InstancesRetrievable = service.InstancesRetrievable
+	serviceOffering.BindingsRetrievable = service.BindingsRetrievable
+	serviceOffering.PlanUpdatable = service.PlanUpdatable
+	serviceOffering.AllowContextUpdates = service.AllowContextUpdates
+	serviceOffering.CatalogID = service.ID
+	serviceOffering.CatalogName = service.Name
+	serviceOffering.Tags = json.RawMessage(serviceTagsBytes)
+	serviceOffering.Requires = json.RawMessage(serviceRequiresBytes)
+	serviceOffering.Metadata = json.RawMessage(serviceMetadataBytes)
+	serviceOffering.DashboardClient = service.DashboardClient
+
+	return nil
+}
+
+func catalogPlanToServicePlan(servicePlan *types.ServicePlan, plan *catalogPlanWithServiceOfferingID) error {
+	planMetadataBytes, err := json.Marshal(plan.Metadata)
+	if err != nil {
+		return fmt.Errorf("could not marshal plan metadata: %s", err)
+	}
+	schemasBytes := make([]byte, 0)
+	if plan.Schemas != nil {
+		schemasBytes, err = json.Marshal(plan.Schemas)
+		if err != nil {
+			return fmt.Errorf("could not marshal plan schemas: %s", err)
+		}
+	}
+
+	servicePlan.Name = plan.Name
+	servicePlan.Description = plan.Description
+	servicePlan.CatalogID = plan.ID
+	servicePlan.CatalogName = plan.Name
+	servicePlan.Free = plan.Free
+	servicePlan.Bindable = plan.Bindable
+	servicePlan.PlanUpdatable = plan.PlanUpdatable
+	servicePlan.BindingsRetrievable = plan.BindingsRetrievable
+	servicePlan.MaximumPollingDuration = plan.MaximumPollingDuration
+	servicePlan.Metadata = json.RawMessage(planMetadataBytes)
+	servicePlan.Schemas = schemasBytes
+	servicePlan.MaintenanceInfo = plan.MaintenanceInfo
+	servicePlan.ServiceOfferingID = plan.ServiceOffering.ID
+
+	return nil
+}
@@ -0,0 +1,219 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package broker
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// supportedPermissions are the permissions a service offering may list in its requires field
+var supportedPermissions = map[string]bool{
+	"syslog_drain":     true,
+	"route_forwarding": true,
+	"volume_mount":     true,
+}
+
+// semanticVersionRegexp matches versions as defined by Semantic Versioning 2.0.0
+var semanticVersionRegexp = regexp.MustCompile(`^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
+
+// CatalogViolation describes a part of a broker catalog which does not conform to the OSB specification
+type CatalogViolation struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (v *CatalogViolation) String() string {
+	return fmt.Sprintf("%s: %s", v.Path, v.Message)
+}
+
+// catalogValidator validates broker catalogs against the rules of the OSB specification
+type catalogValidator struct {
+	violations []*CatalogViolation
+}
+
+// validateCatalog returns the violations of the OSB specification found in the catalog
+func validateCatalog(serviceOfferings []*types.ServiceOffering) []*CatalogViolation {
+	validator := &catalogValidator{}
+	validator.validate(serviceOfferings)
+	return validator.violations
+}
+
+func (cv *catalogValidator) addViolation(path, format string, args ...interface{}) {
+	cv.violations = append(cv.violations, &CatalogViolation{
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (cv *catalogValidator) validate(serviceOfferings []*types.ServiceOffering) {
+	serviceIDs := make(map[string]string)
+	serviceNames := make(map[string]string)
+	planIDs := make(map[string]string)
+
+	for serviceIndex, serviceOffering := range serviceOfferings {
+		servicePath := fmt.Sprintf("services[%d]", serviceIndex)
+		cv.validateServiceOffering(servicePath, serviceOffering)
+
+		if path, found := serviceIDs[serviceOffering.CatalogID]; found && serviceOffering.CatalogID != "" {
+			cv.addViolation(servicePath+".id", "duplicate service id %s also used by %s", serviceOffering.CatalogID, path)
+		}
+		serviceIDs[serviceOffering.CatalogID] = servicePath
+
+		if path, found := serviceNames[serviceOffering.CatalogName]; found && serviceOffering.CatalogName != "" {
+			cv.addViolation(servicePath+".name", "duplicate service name %s also used by %s", serviceOffering.CatalogName, path)
+		}
+		serviceNames[serviceOffering.CatalogName] = servicePath
+
+		planNames := make(map[string]string)
+		for planIndex, servicePlan := range serviceOffering.Plans {
+			planPath := fmt.Sprintf("%s.plans[%d]", servicePath, planIndex)
+			cv.validateServicePlan(planPath, serviceOffering, servicePlan)
+
+			if path, found := planIDs[servicePlan.CatalogID]; found && servicePlan.CatalogID != "" {
+				cv.addViolation(planPath+".id", "duplicate plan id %s also used by %s", servicePlan.CatalogID, path)
+			}
+			planIDs[servicePlan.CatalogID] = planPath
+
+			if path, found := planNames[servicePlan.CatalogName]; found && servicePlan.CatalogName != "" {
+				cv.addViolation(planPath+".name", "duplicate plan name %s also used by %s", servicePlan.CatalogName, path)
+			}
+			planNames[servicePlan.CatalogName] = planPath
+		}
+	}
+}
+
+func (cv *catalogValidator) validateServiceOffering(path string, serviceOffering *types.ServiceOffering) {
+	cv.validateID(path, serviceOffering.CatalogID)
+	if serviceOffering.CatalogName == "" {
+		cv.addViolation(path+".name", "service name is required")
+	}
+	if serviceOffering.Description == "" {
+		cv.addViolation(path+".description", "service description is required")
+	}
+	if len(serviceOffering.Plans) == 0 {
+		cv.addViolation(path+".plans", "service must contain at least one plan")
+	}
+
+	bindable := serviceOffering.Bindable
+	for _, servicePlan := range serviceOffering.Plans {
+		bindable = bindable || servicePlan.IsBindable(serviceOffering)
+	}
+	if serviceOffering.BindingsRetrievable && !bindable {
+		cv.addViolation(path+".bindings_retrievable", "bindings can be retrievable only for services with bindable plans")
+	}
+
+	if len(serviceOffering.Requires) == 0 {
+		return
+	}
+	var permissions []string
+	if err := json.Unmarshal(serviceOffering.Requires, &permissions); err != nil {
+		cv.addViolation(path+".requires", "requires must be an array of strings")
+		return
+	}
+	for _, permission := range permissions {
+		if !supportedPermissions[permission] {
+			cv.addViolation(path+".requires", "unsupported permission %s", permission)
+		}
+	}
+	if len(permissions) != 0 && !bindable {
+		cv.addViolation(path+".requires", "permissions can be required only by services with bindable plans")
+	}
+}
+
+func (cv *catalogValidator) validateServicePlan(path string, serviceOffering *types.ServiceOffering, servicePlan *types.ServicePlan) {
+	cv.validateID(path, servicePlan.CatalogID)
+	if servicePlan.CatalogName == "" {
+		cv.addViolation(path+".name", "plan name is required")
+	}
+	if servicePlan.Description == "" {
+		cv.addViolation(path+".description", "plan description is required")
+	}
+	cv.validateSchemas(path+".schemas", serviceOffering, servicePlan)
+	cv.validateMaintenanceInfo(path+".maintenance_info", servicePlan)
+}
+
+func (cv *catalogValidator) validateMaintenanceInfo(path string, servicePlan *types.ServicePlan) {
+	if len(servicePlan.MaintenanceInfo) == 0 || string(servicePlan.MaintenanceInfo) == "null" {
+		return
+	}
+	maintenanceInfo := &types.MaintenanceInfo{}
+	if err := json.Unmarshal(servicePlan.MaintenanceInfo, maintenanceInfo); err != nil {
+		cv.addViolation(path, "maintenance info must be an object: %s", err)
+		return
+	}
+	if !semanticVersionRegexp.MatchString(maintenanceInfo.Version) {
+		cv.addViolation(path+".version", "version %q is not a semantic version", maintenanceInfo.Version)
+	}
+}
+
+func (cv *catalogValidator) validateID(path, id string) {
+	if id == "" {
+		cv.addViolation(path+".id", "id is required")
+		return
+	}
+	if util.HasRFC3986ReservedSymbols(id) {
+		cv.addViolation(path+".id", "id %s contains RFC 3986 reserved characters", id)
+	}
+}
+
+func (cv *catalogValidator) validateSchemas(path string, serviceOffering *types.ServiceOffering, servicePlan *types.ServicePlan) {
+	if len(servicePlan.Schemas) == 0 {
+		return
+	}
+	schemas := struct {
+		ServiceInstance *struct {
+			Create *inputParametersSchema `json:"create"`
+			Update *inputParametersSchema `json:"update"`
+		} `json:"service_instance"`
+		ServiceBinding *struct {
+			Create *inputParametersSchema `json:"create"`
+		} `json:"service_binding"`
+	}{}
+	if err := json.Unmarshal(servicePlan.Schemas, &schemas); err != nil {
+		cv.addViolation(path, "schemas must be an object: %s", err)
+		return
+	}
+	if schemas.ServiceInstance != nil {
+		cv.validateSchema(path+".service_instance.create.parameters", schemas.ServiceInstance.Create)
+		cv.validateSchema(path+".service_instance.update.parameters", schemas.ServiceInstance.Update)
+	}
+	if schemas.ServiceBinding != nil {
+		if schemas.ServiceBinding.Create != nil && !servicePlan.IsBindable(serviceOffering) {
+			cv.addViolation(path+".service_binding", "binding schemas can be provided only for bindable plans")
+		}
+		cv.validateSchema(path+".service_binding.create.parameters", schemas.ServiceBinding.Create)
+	}
+}
+
+type inputParametersSchema struct {
+	Parameters json.RawMessage `json:"parameters"`
+}
+
+func (cv *catalogValidator) validateSchema(path string, schema *inputParametersSchema) {
+	if schema == nil || len(schema.Parameters) == 0 || string(schema.Parameters) == "null" {
+		return
+	}
+	if _, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(schema.Parameters)); err != nil {
+		cv.addViolation(path, "invalid JSON schema: %s", err)
+	}
+}
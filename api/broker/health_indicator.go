@@ -0,0 +1,207 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package broker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/brokerclient"
+	"github.com/Peripli/service-manager/pkg/health"
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/pkg/security"
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/storage"
+)
+
+// probeResult is the outcome of the last catalog probes of a single broker
+type probeResult struct {
+	name                string
+	resilience          *types.Resilience
+	latency             time.Duration
+	consecutiveFailures int
+	lastProbe           time.Time
+	lastSuccess         time.Time
+	err                 error
+}
+
+// health returns the health of the broker. The state of the circuit breaker of the broker is reported if the broker
+// has one configured.
+func (r *probeResult) health(circuitBreakerState string) *health.Health {
+	healthz := health.New().
+		WithDetail("name", r.name).
+		WithDetail("latency", r.latency.String()).
+		WithDetail("consecutive_failures", r.consecutiveFailures).
+		WithDetail("last_probe", r.lastProbe)
+	if !r.lastSuccess.IsZero() {
+		healthz.WithDetail("last_success", r.lastSuccess)
+	}
+	if circuitBreakerState != "" {
+		healthz.WithDetail("circuit_breaker", circuitBreakerState)
+	}
+	if r.err != nil {
+		return healthz.WithDetail("error", r.err.Error()).Down()
+	}
+	return healthz.Up()
+}
+
+// HealthIndicator periodically probes the catalog endpoints of the registered brokers and reports their
+// reachability and latency. The probes run in the background so that health requests are never blocked
+// by slow or unreachable brokers, and they bypass the circuit breakers of the brokers.
+type HealthIndicator struct {
+	repository   storage.Repository
+	encrypter    security.Encrypter
+	brokerClient *brokerclient.Client
+	interval     time.Duration
+
+	mutex   sync.RWMutex
+	results map[string]*probeResult
+}
+
+// NewHealthIndicator returns a broker health indicator which probes the brokers every interval until the context is done
+func NewHealthIndicator(ctx context.Context, repository storage.Repository, encrypter security.Encrypter, brokerClient *brokerclient.Client, interval time.Duration) *HealthIndicator {
+	indicator := &HealthIndicator{
+		repository:   repository,
+		encrypter:    encrypter,
+		brokerClient: brokerClient,
+		interval:     interval,
+		results:      make(map[string]*probeResult),
+	}
+	go indicator.run(ctx)
+	return indicator
+}
+
+// Name returns the name of the brokers component
+func (i *HealthIndicator) Name() string {
+	return "brokers"
+}
+
+// Health reports how many of the probed brokers are unreachable. The health endpoint does not require
+// authentication, so the results of the individual brokers are only returned by BrokerHealth. Unreachable brokers
+// do not affect the status of the Service Manager itself.
+func (i *HealthIndicator) Health() *health.Health {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	unreachable := 0
+	for _, result := range i.results {
+		if result.err != nil {
+			unreachable++
+		}
+	}
+	return health.New().
+		WithDetail("probed", len(i.results)).
+		WithDetail("unreachable", unreachable).
+		Up()
+}
+
+// BrokerHealth returns the result of the last probe of the broker and the current state of its circuit breaker or
+// nil if the broker has not been probed yet
+func (i *HealthIndicator) BrokerHealth(brokerID string) *health.Health {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	result, found := i.results[brokerID]
+	if !found {
+		return nil
+	}
+	circuitBreakerState := i.brokerClient.CircuitBreakerState(&types.Broker{
+		ID:         brokerID,
+		Name:       result.name,
+		Resilience: result.resilience,
+	})
+	return result.health(circuitBreakerState)
+}
+
+func (i *HealthIndicator) run(ctx context.Context) {
+	ticker := time.NewTicker(i.interval)
+	defer ticker.Stop()
+	for {
+		i.probeBrokers(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (i *HealthIndicator) probeBrokers(ctx context.Context) {
+	brokers, err := i.repository.Broker().List(ctx)
+	if err != nil {
+		log.C(ctx).WithError(err).Error("Could not list brokers for health probing")
+		return
+	}
+
+	probed := make(map[string]bool, len(brokers))
+	wg := sync.WaitGroup{}
+	for _, broker := range brokers {
+		if broker.IsVirtual() || broker.State == types.BrokerStateDisabled {
+			continue
+		}
+		probed[broker.ID] = true
+		wg.Add(1)
+		go func(broker *types.Broker) {
+			defer wg.Done()
+			i.probeBroker(ctx, broker)
+		}(broker)
+	}
+	wg.Wait()
+
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	for brokerID := range i.results {
+		if !probed[brokerID] {
+			delete(i.results, brokerID)
+		}
+	}
+}
+
+func (i *HealthIndicator) probeBroker(ctx context.Context, broker *types.Broker) {
+	ctx, cancel := context.WithTimeout(ctx, i.interval)
+	defer cancel()
+
+	start := time.Now()
+	err := broker.TransformSecrets(ctx, i.encrypter.Decrypt)
+	if err == nil {
+		err = i.brokerClient.ProbeCatalog(ctx, broker)
+	}
+	latency := time.Since(start)
+	if err != nil {
+		log.C(ctx).WithError(err).Warnf("Health probe of broker with name %s failed", broker.Name)
+	}
+
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	result, found := i.results[broker.ID]
+	if !found {
+		result = &probeResult{}
+		i.results[broker.ID] = result
+	}
+	result.name = broker.Name
+	result.resilience = broker.Resilience
+	result.latency = latency
+	result.lastProbe = start
+	result.err = err
+	if err != nil {
+		result.consecutiveFailures++
+	} else {
+		result.consecutiveFailures = 0
+		result.lastSuccess = start
+	}
+}
@@ -0,0 +1,72 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+// Package catalog_override contains logic for building the Service Manager catalog overrides API
+package catalog_override
+
+import (
+	"net/http"
+
+	"github.com/Peripli/service-manager/pkg/web"
+)
+
+// Routes returns slice of routes which handle catalog override operations
+func (c *Controller) Routes() []web.Route {
+	return []web.Route{
+		{
+			Endpoint: web.Endpoint{
+				Method: http.MethodPost,
+				Path:   web.CatalogOverridesURL,
+			},
+			Handler: c.createCatalogOverride,
+		},
+		{
+			Endpoint: web.Endpoint{
+				Method: http.MethodGet,
+				Path:   web.CatalogOverridesURL + "/{catalog_override_id}",
+			},
+			Handler: c.getCatalogOverride,
+		},
+		{
+			Endpoint: web.Endpoint{
+				Method: http.MethodGet,
+				Path:   web.CatalogOverridesURL,
+			},
+			Handler: c.listCatalogOverrides,
+		},
+		{
+			Endpoint: web.Endpoint{
+				Method: http.MethodDelete,
+				Path:   web.CatalogOverridesURL,
+			},
+			Handler: c.deleteAllCatalogOverrides,
+		},
+		{
+			Endpoint: web.Endpoint{
+				Method: http.MethodDelete,
+				Path:   web.CatalogOverridesURL + "/{catalog_override_id}",
+			},
+			Handler: c.deleteCatalogOverride,
+		},
+		{
+			Endpoint: web.Endpoint{
+				Method: http.MethodPatch,
+				Path:   web.CatalogOverridesURL + "/{catalog_override_id}",
+			},
+			Handler: c.patchCatalogOverride,
+		},
+	}
+}
@@ -0,0 +1,128 @@
+package catalog_override
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/pkg/query"
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
+	"github.com/Peripli/service-manager/pkg/web"
+	"github.com/Peripli/service-manager/storage"
+	"github.com/gofrs/uuid"
+)
+
+const reqCatalogOverrideID = "catalog_override_id"
+
+// Controller implements api.Controller by providing catalog overrides API logic
+type Controller struct {
+	CatalogOverrideStorage storage.CatalogOverride
+}
+
+var _ web.Controller = &Controller{}
+
+func (c *Controller) createCatalogOverride(r *web.Request) (*web.Response, error) {
+	ctx := r.Context()
+	log.C(ctx).Debug("Creating new catalog override")
+
+	catalogOverride := &types.CatalogOverride{}
+	if err := util.BytesToObject(r.Body, catalogOverride); err != nil {
+		return nil, err
+	}
+
+	UUID, err := uuid.NewV4()
+	if err != nil {
+		return nil, fmt.Errorf("could not generate GUID for catalog override: %s", err)
+	}
+	catalogOverride.ID = UUID.String()
+
+	currentTime := time.Now().UTC()
+	catalogOverride.CreatedAt = currentTime
+	catalogOverride.UpdatedAt = currentTime
+
+	if _, err := c.CatalogOverrideStorage.Create(ctx, catalogOverride); err != nil {
+		return nil, util.HandleStorageError(err, "catalog_override")
+	}
+
+	return util.NewJSONResponse(http.StatusCreated, catalogOverride)
+}
+
+func (c *Controller) getCatalogOverride(r *web.Request) (*web.Response, error) {
+	catalogOverrideID := r.PathParams[reqCatalogOverrideID]
+	ctx := r.Context()
+	log.C(ctx).Debugf("Getting catalog override with id %s", catalogOverrideID)
+
+	catalogOverride, err := c.CatalogOverrideStorage.Get(ctx, catalogOverrideID)
+	if err = util.HandleStorageError(err, "catalog_override"); err != nil {
+		return nil, err
+	}
+	return util.NewJSONResponse(http.StatusOK, catalogOverride)
+}
+
+func (c *Controller) listCatalogOverrides(r *web.Request) (*web.Response, error) {
+	ctx := r.Context()
+	log.C(ctx).Debug("Listing catalog overrides")
+
+	catalogOverrides, err := c.CatalogOverrideStorage.List(ctx, query.CriteriaForContext(ctx)...)
+	if err != nil {
+		return nil, util.HandleSelectionError(err)
+	}
+	return util.NewJSONResponse(http.StatusOK, &types.CatalogOverrides{
+		CatalogOverrides: catalogOverrides,
+	})
+}
+
+func (c *Controller) deleteAllCatalogOverrides(r *web.Request) (*web.Response, error) {
+	ctx := r.Context()
+	log.C(ctx).Debug("Deleting catalog overrides...")
+
+	if err := c.CatalogOverrideStorage.Delete(ctx, query.CriteriaForContext(ctx)...); err != nil {
+		return nil, util.HandleSelectionError(err, "catalog_override")
+	}
+	return util.NewJSONResponse(http.StatusOK, map[string]string{})
+}
+
+func (c *Controller) deleteCatalogOverride(r *web.Request) (*web.Response, error) {
+	catalogOverrideID := r.PathParams[reqCatalogOverrideID]
+	ctx := r.Context()
+	log.C(ctx).Debugf("Deleting catalog override with id %s", catalogOverrideID)
+
+	byIDQuery := query.ByField(query.EqualsOperator, "id", catalogOverrideID)
+	if err := c.CatalogOverrideStorage.Delete(ctx, byIDQuery); err != nil {
+		return nil, util.HandleStorageError(err, "catalog_override")
+	}
+	return util.NewJSONResponse(http.StatusOK, map[string]string{})
+}
+
+func (c *Controller) patchCatalogOverride(r *web.Request) (*web.Response, error) {
+	catalogOverrideID := r.PathParams[reqCatalogOverrideID]
+	ctx := r.Context()
+	log.C(ctx).Debugf("Updating catalog override with id %s", catalogOverrideID)
+
+	catalogOverride, err := c.CatalogOverrideStorage.Get(ctx, catalogOverrideID)
+	if err != nil {
+		return nil, util.HandleStorageError(err, "catalog_override")
+	}
+
+	createdAt := catalogOverride.CreatedAt
+	serviceOfferingID := catalogOverride.ServiceOfferingID
+	servicePlanID := catalogOverride.ServicePlanID
+
+	if err := util.BytesToObject(r.Body, catalogOverride); err != nil {
+		return nil, err
+	}
+
+	// the target of an override cannot be changed
+	catalogOverride.ID = catalogOverrideID
+	catalogOverride.ServiceOfferingID = serviceOfferingID
+	catalogOverride.ServicePlanID = servicePlanID
+	catalogOverride.CreatedAt = createdAt
+	catalogOverride.UpdatedAt = time.Now().UTC()
+
+	if err := c.CatalogOverrideStorage.Update(ctx, catalogOverride); err != nil {
+		return nil, util.HandleStorageError(err, "catalog_override")
+	}
+	return util.NewJSONResponse(http.StatusOK, catalogOverride)
+}
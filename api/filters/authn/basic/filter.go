@@ -36,6 +36,11 @@ func (ba *basicAuthnFilter) FilterMatchers() []web.FilterMatcher {
 				web.Path(web.OSBURL + "/**"),
 			},
 		},
+		{
+			Matchers: []web.Matcher{
+				web.Path(web.AggregatedOSBURL + "/**"),
+			},
+		},
 		{
 			Matchers: []web.Matcher{
 				web.Methods(http.MethodGet),
@@ -66,5 +71,23 @@ func (ba *basicAuthnFilter) FilterMatchers() []web.FilterMatcher {
 				web.Path(web.VisibilitiesURL + "/**"),
 			},
 		},
+		{
+			Matchers: []web.Matcher{
+				web.Methods(http.MethodGet),
+				web.Path(web.ServiceInstancesURL + "/**"),
+			},
+		},
+		{
+			Matchers: []web.Matcher{
+				web.Methods(http.MethodGet),
+				web.Path(web.ServiceBindingsURL + "/**"),
+			},
+		},
+		{
+			Matchers: []web.Matcher{
+				web.Methods(http.MethodGet),
+				web.Path(web.OperationsURL + "/**"),
+			},
+		},
 	}
 }
@@ -43,6 +43,10 @@ func (ba *bearerAuthnFilter) FilterMatchers() []web.FilterMatcher {
 					web.ServiceOfferingsURL+"/**",
 					web.ServicePlansURL+"/**",
 					web.VisibilitiesURL+"/**",
+					web.CatalogOverridesURL+"/**",
+					web.ServiceInstancesURL+"/**",
+					web.ServiceBindingsURL+"/**",
+					web.OperationsURL+"/**",
 				),
 			},
 		},
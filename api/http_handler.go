@@ -18,6 +18,7 @@ package api
 
 import (
 	"context"
+	"io"
 	"net/http"
 
 	"github.com/Peripli/service-manager/pkg/log"
@@ -64,16 +65,24 @@ func (h *HTTPHandler) serve(res http.ResponseWriter, req *http.Request) error {
 	if err != nil {
 		return err
 	}
+	stream := response.Stream()
+	if stream != nil {
+		defer stream.Close()
+	}
 
-	// copy response headers
+	// copy response headers; the length of buffered bodies is set when they are written
 	for k, v := range response.Header {
-		if k != "Content-Length" {
+		if k != "Content-Length" || stream != nil {
 			res.Header()[k] = v
 		}
 	}
 
 	res.WriteHeader(response.StatusCode)
-	_, err = res.Write(response.Body)
+	if stream != nil {
+		_, err = io.Copy(res, stream)
+	} else {
+		_, err = res.Write(response.Body)
+	}
 	if err != nil {
 		// HTTP headers and status are sent already
 		// if we return an error, the error Handler will try to send them again
@@ -18,6 +18,7 @@ package api
 
 import (
 	"bytes"
+	"io/ioutil"
 	"net/http/httptest"
 	"strconv"
 
@@ -175,6 +176,29 @@ var _ = Describe("Handler", func() {
 				Expect(response.Code).To(Equal(fakeHandlerResponse.StatusCode))
 			})
 		})
+
+		Context("when the web.Handler returns a streamed response", func() {
+			BeforeEach(func() {
+				headers := http.Header{}
+				headers.Add("Content-Length", strconv.Itoa(len(validJSON)))
+				headers.Add("Random-Header", "random-value")
+				fakeHandler.HandleReturns(web.NewStreamedResponse(http.StatusCreated, headers, ioutil.NopCloser(strings.NewReader(validJSON))), nil)
+			})
+
+			It("streams the body to the HTTPHandler's response", func() {
+				response := makeRequest("", "http://example.com", "", map[string]string{})
+
+				Expect(response.Code).To(Equal(http.StatusCreated))
+				Expect(response.Body.String()).To(Equal(validJSON))
+			})
+
+			Specify("Content-Length header is copied to the HTTPHandler's response", func() {
+				response := makeRequest("", "http://example.com", "", map[string]string{})
+
+				Expect(response.Header().Get("Content-Length")).To(Equal(strconv.Itoa(len(validJSON))))
+				Expect(response.Header().Get("Random-Header")).To(Equal("random-value"))
+			})
+		})
 	})
 
 	Describe("Handle", func() {
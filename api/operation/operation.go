@@ -0,0 +1,44 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+// Package operation contains logic for building the Service Manager asynchronous operations API
+package operation
+
+import (
+	"net/http"
+
+	"github.com/Peripli/service-manager/pkg/web"
+)
+
+// Routes returns slice of routes which handle asynchronous operations
+func (c *Controller) Routes() []web.Route {
+	return []web.Route{
+		{
+			Endpoint: web.Endpoint{
+				Method: http.MethodGet,
+				Path:   web.OperationsURL + "/{operation_id}",
+			},
+			Handler: c.getOperation,
+		},
+		{
+			Endpoint: web.Endpoint{
+				Method: http.MethodGet,
+				Path:   web.OperationsURL,
+			},
+			Handler: c.listOperations,
+		},
+	}
+}
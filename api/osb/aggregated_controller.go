@@ -0,0 +1,298 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package osb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/Peripli/service-manager/pkg/brokerclient"
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/pkg/query"
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
+	"github.com/Peripli/service-manager/pkg/web"
+	"github.com/Peripli/service-manager/storage"
+)
+
+const (
+	aggregatedCatalogURL                        = web.AggregatedOSBURL + "/v2/catalog"
+	aggregatedServiceInstanceURL                = web.AggregatedOSBURL + "/v2/service_instances/{instance_id}"
+	aggregatedServiceInstanceLastOperationURL   = web.AggregatedOSBURL + "/v2/service_instances/{instance_id}/last_operation"
+	aggregatedServiceBindingURL                 = web.AggregatedOSBURL + "/v2/service_instances/{instance_id}/service_bindings/{binding_id}"
+	aggregatedServiceBindingLastOperationURL    = web.AggregatedOSBURL + "/v2/service_instances/{instance_id}/service_bindings/{binding_id}/last_operation"
+	aggregatedServiceBindingAdaptCredentialsURL = web.AggregatedOSBURL + "/v2/service_instances/{instance_id}/service_bindings/{binding_id}/adapt_credentials"
+)
+
+// aggregatedController implements api.Controller by providing an OSB API which presents the merged catalog of all
+// service brokers and routes the requests for service instances and bindings to the broker which owns them
+type aggregatedController struct {
+	*controller
+	brokerStorage storage.Broker
+}
+
+var _ web.Controller = &aggregatedController{}
+
+// NewAggregatedController returns new OSB controller for all service brokers registered in SM. The requests are
+// routed by the catalog ids of the requested service and plan or by the tracked service instances if the requests
+// do not reference a service.
+func NewAggregatedController(brokerFetcher BrokerFetcher, catalogFetcher CatalogFetcher, brokerClient *brokerclient.Client, instanceTracker InstanceTracker, orphanMitigator OrphanMitigator, repository storage.Repository) web.Controller {
+	return &aggregatedController{
+		controller: &controller{
+			brokerFetcher:   brokerFetcher,
+			catalogFetcher:  catalogFetcher,
+			brokerClient:    brokerClient,
+			instanceTracker: instanceTracker,
+			orphanMitigator: orphanMitigator,
+			repository:      repository,
+		},
+		brokerStorage: repository.Broker(),
+	}
+}
+
+// Routes implements api.Controller.Routes by providing the routes for the aggregated OSB API
+func (c *aggregatedController) Routes() []web.Route {
+	return []web.Route{
+		{Endpoint: web.Endpoint{Method: http.MethodGet, Path: aggregatedCatalogURL}, Handler: c.aggregatedCatalogHandler},
+
+		{Endpoint: web.Endpoint{Method: http.MethodGet, Path: aggregatedServiceInstanceURL}, Handler: c.routingHandler},
+		{Endpoint: web.Endpoint{Method: http.MethodPut, Path: aggregatedServiceInstanceURL}, Handler: c.routingHandler},
+		{Endpoint: web.Endpoint{Method: http.MethodPatch, Path: aggregatedServiceInstanceURL}, Handler: c.routingHandler},
+		{Endpoint: web.Endpoint{Method: http.MethodDelete, Path: aggregatedServiceInstanceURL}, Handler: c.routingHandler},
+
+		{Endpoint: web.Endpoint{Method: http.MethodGet, Path: aggregatedServiceBindingURL}, Handler: c.routingHandler},
+		{Endpoint: web.Endpoint{Method: http.MethodPut, Path: aggregatedServiceBindingURL}, Handler: c.routingHandler},
+		{Endpoint: web.Endpoint{Method: http.MethodDelete, Path: aggregatedServiceBindingURL}, Handler: c.routingHandler},
+
+		{Endpoint: web.Endpoint{Method: http.MethodGet, Path: aggregatedServiceInstanceLastOperationURL}, Handler: c.routingHandler},
+		{Endpoint: web.Endpoint{Method: http.MethodGet, Path: aggregatedServiceBindingLastOperationURL}, Handler: c.routingHandler},
+
+		{Endpoint: web.Endpoint{Method: http.MethodPost, Path: aggregatedServiceBindingAdaptCredentialsURL}, Handler: c.routingHandler},
+	}
+}
+
+func (c *aggregatedController) aggregatedCatalogHandler(r *web.Request) (*web.Response, error) {
+	catalog, err := c.aggregateCatalog(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	return util.NewJSONResponse(http.StatusOK, &types.ServiceOfferings{
+		ServiceOfferings: catalog.services,
+	})
+}
+
+// routingHandler forwards the request to the broker which owns the requested service instance
+func (c *aggregatedController) routingHandler(r *web.Request) (*web.Response, error) {
+	brokerID, err := routeAggregatedRequest(c.repository, r)
+	if err != nil {
+		return nil, err
+	}
+	log.C(r.Context()).Debugf("Routing %s request to %s to broker with id %s", r.Method, r.URL.Path, brokerID)
+	if r.PathParams == nil {
+		r.PathParams = make(map[string]string)
+	}
+	r.PathParams[BrokerIDPathParam] = brokerID
+	return c.proxyHandler(r)
+}
+
+// routeAggregatedRequest returns the id of the broker to which the request to the aggregated OSB API has to be
+// forwarded. Requests which create or modify resources are routed by the service and plan catalog ids in their body.
+// Other requests are routed to the broker of the tracked service instance or by the catalog ids in their query.
+func routeAggregatedRequest(repository storage.Repository, r *web.Request) (string, error) {
+	ctx := r.Context()
+	if serviceID := gjson.GetBytes(r.Body, "service_id").String(); serviceID != "" {
+		return routeByCatalogIDs(ctx, repository, serviceID, gjson.GetBytes(r.Body, "plan_id").String())
+	}
+
+	instanceID := r.PathParams["instance_id"]
+	instance, err := repository.ServiceInstance().Get(ctx, instanceID)
+	if err == nil {
+		return instance.BrokerID, nil
+	}
+	if err != util.ErrNotFoundInStorage {
+		return "", err
+	}
+
+	params := r.URL.Query()
+	if serviceID := params.Get("service_id"); serviceID != "" {
+		return routeByCatalogIDs(ctx, repository, serviceID, params.Get("plan_id"))
+	}
+	return "", &util.HTTPError{
+		ErrorType:   "NotFound",
+		Description: fmt.Sprintf("could not determine the service broker of service instance %s", instanceID),
+		StatusCode:  http.StatusNotFound,
+	}
+}
+
+// routeByCatalogIDs returns the id of the only broker which offers the service and the plan with the specified catalog
+// ids. The plan id is optional. The brokers are looked up in the stored catalogs by the catalog ids, so the catalogs of
+// all brokers are aggregated only for the catalog requests.
+func routeByCatalogIDs(ctx context.Context, repository storage.Repository, serviceID, planID string) (string, error) {
+	serviceOfferings, err := repository.ServiceOffering().List(ctx, query.ByField(query.EqualsOperator, "catalog_id", serviceID))
+	if err != nil {
+		return "", err
+	}
+	serviceBrokers, err := enabledBrokerIDs(ctx, repository, serviceOfferings)
+	if err != nil {
+		return "", err
+	}
+	if len(serviceBrokers) > 1 {
+		return "", catalogIDCollisionError("service", serviceID)
+	}
+	if len(serviceBrokers) == 0 {
+		return "", &util.HTTPError{
+			ErrorType:   "BadRequest",
+			Description: fmt.Sprintf("service with catalog id %s is not offered", serviceID),
+			StatusCode:  http.StatusBadRequest,
+		}
+	}
+	if planID == "" {
+		return serviceBrokers[0], nil
+	}
+
+	servicePlans, err := repository.ServicePlan().List(ctx, query.ByField(query.EqualsOperator, "catalog_id", planID))
+	if err != nil {
+		return "", err
+	}
+	planServiceOfferingIDs := make([]string, 0, len(servicePlans))
+	for _, servicePlan := range servicePlans {
+		planServiceOfferingIDs = append(planServiceOfferingIDs, servicePlan.ServiceOfferingID)
+	}
+	planServiceOfferings := make([]*types.ServiceOffering, 0)
+	if len(planServiceOfferingIDs) > 0 {
+		planServiceOfferings, err = repository.ServiceOffering().List(ctx, query.ByField(query.InOperator, "id", planServiceOfferingIDs...))
+		if err != nil {
+			return "", err
+		}
+	}
+	planBrokers, err := enabledBrokerIDs(ctx, repository, planServiceOfferings)
+	if err != nil {
+		return "", err
+	}
+	if len(planBrokers) > 1 {
+		return "", catalogIDCollisionError("plan", planID)
+	}
+	if len(planBrokers) == 0 || planBrokers[0] != serviceBrokers[0] {
+		return "", &util.HTTPError{
+			ErrorType:   "BadRequest",
+			Description: fmt.Sprintf("plan with catalog id %s of service with catalog id %s is not offered", planID, serviceID),
+			StatusCode:  http.StatusBadRequest,
+		}
+	}
+	return serviceBrokers[0], nil
+}
+
+// enabledBrokerIDs returns the ids of the brokers of the service offerings which are not disabled
+func enabledBrokerIDs(ctx context.Context, repository storage.Repository, serviceOfferings []*types.ServiceOffering) ([]string, error) {
+	if len(serviceOfferings) == 0 {
+		return []string{}, nil
+	}
+	brokerIDs := make([]string, 0, len(serviceOfferings))
+	for _, serviceOffering := range serviceOfferings {
+		brokerIDs = append(brokerIDs, serviceOffering.BrokerID)
+	}
+	brokers, err := repository.Broker().List(ctx, query.ByField(query.InOperator, "id", brokerIDs...))
+	if err != nil {
+		return nil, err
+	}
+	result := make([]string, 0, len(brokers))
+	for _, broker := range brokers {
+		if broker.State != types.BrokerStateDisabled {
+			result = append(result, broker.ID)
+		}
+	}
+	return result, nil
+}
+
+// aggregatedCatalog is the merged catalog of all service brokers
+type aggregatedCatalog struct {
+	// services contains the services and plans offered by a single broker
+	services []*types.ServiceOffering
+	// serviceBrokers contains the ids of the brokers which offer the services with the given catalog ids
+	serviceBrokers map[string][]string
+	// planBrokers contains the ids of the brokers which offer the plans with the given catalog ids
+	planBrokers map[string][]string
+}
+
+// aggregateCatalog merges the catalogs of the brokers which are not disabled. The services and plans whose catalog
+// ids are offered by more than one broker are left out as the requests for them cannot be routed.
+func (c *aggregatedController) aggregateCatalog(ctx context.Context) (*aggregatedCatalog, error) {
+	if c.catalogFetcher == nil {
+		return nil, errors.New("the aggregated OSB API requires a catalog fetcher")
+	}
+	brokers, err := c.brokerStorage.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog := &aggregatedCatalog{
+		services:       make([]*types.ServiceOffering, 0),
+		serviceBrokers: make(map[string][]string),
+		planBrokers:    make(map[string][]string),
+	}
+	services := make([]*types.ServiceOffering, 0)
+	for _, broker := range brokers {
+		if broker.State == types.BrokerStateDisabled {
+			continue
+		}
+		brokerCatalog, err := c.catalogFetcher.FetchCatalog(ctx, broker.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, service := range brokerCatalog.ServiceOfferings {
+			catalog.serviceBrokers[service.ID] = append(catalog.serviceBrokers[service.ID], broker.ID)
+			for _, plan := range service.Plans {
+				catalog.planBrokers[plan.ID] = append(catalog.planBrokers[plan.ID], broker.ID)
+			}
+		}
+		services = append(services, brokerCatalog.ServiceOfferings...)
+	}
+
+	logger := log.C(ctx)
+	for _, service := range services {
+		if brokerIDs := catalog.serviceBrokers[service.ID]; len(brokerIDs) > 1 {
+			logger.Errorf("Service with catalog id %s is offered by brokers with ids %v and is left out of the aggregated catalog", service.ID, brokerIDs)
+			continue
+		}
+		plans := make([]*types.ServicePlan, 0, len(service.Plans))
+		for _, plan := range service.Plans {
+			if brokerIDs := catalog.planBrokers[plan.ID]; len(brokerIDs) > 1 {
+				logger.Errorf("Plan with catalog id %s is offered by brokers with ids %v and is left out of the aggregated catalog", plan.ID, brokerIDs)
+				continue
+			}
+			plans = append(plans, plan)
+		}
+		if len(plans) == 0 {
+			continue
+		}
+		service.Plans = plans
+		catalog.services = append(catalog.services, service)
+	}
+	return catalog, nil
+}
+
+func catalogIDCollisionError(kind, catalogID string) *util.HTTPError {
+	return &util.HTTPError{
+		ErrorType:   "Conflict",
+		Description: fmt.Sprintf("%s with catalog id %s is offered by more than one service broker", kind, catalogID),
+		StatusCode:  http.StatusConflict,
+	}
+}
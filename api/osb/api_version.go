@@ -0,0 +1,102 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package osb
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/Peripli/service-manager/pkg/brokerclient"
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
+	"github.com/Peripli/service-manager/pkg/web"
+)
+
+const (
+	// originatingIdentityHeader is the OSB header carrying the identity of the platform user that initiated the request
+	originatingIdentityHeader = "X-Broker-API-Originating-Identity"
+
+	// the OSB API versions which introduced the features that are checked when requests are downgraded
+	maintenanceInfoAPIVersion     = "2.15"
+	instanceRetrievalAPIVersion   = "2.14"
+	asyncBindingsAPIVersion       = "2.14"
+	originatingIdentityAPIVersion = "2.13"
+	contextAPIVersion             = "2.12"
+)
+
+// adaptToBrokerAPIVersion downgrades requests sent with a higher OSB API version than the one supported by the broker.
+// Optional features which are not supported by the broker are removed from the request, while requests relying on
+// unsupported features are rejected. Requests towards brokers whose API version has not been detected are not changed.
+func adaptToBrokerAPIVersion(r *web.Request, broker *types.Broker, osbPath string) *util.HTTPError {
+	brokerVersion := broker.APIVersion
+	requestVersion := r.Header.Get(brokerclient.APIVersionHeader)
+	if brokerVersion == "" || brokerclient.CompareAPIVersions(requestVersion, brokerVersion) <= 0 {
+		return nil
+	}
+
+	supports := func(version string) bool {
+		return brokerclient.CompareAPIVersions(brokerVersion, version) >= 0
+	}
+	unsupported := func(feature string) *util.HTTPError {
+		return &util.HTTPError{
+			ErrorType:   "UnsupportedAPIVersion",
+			Description: fmt.Sprintf("service broker %s supports OSB API version %s which does not support %s", broker.Name, brokerVersion, feature),
+			StatusCode:  http.StatusPreconditionFailed,
+		}
+	}
+
+	if r.Method == http.MethodGet && !supports(instanceRetrievalAPIVersion) {
+		switch {
+		case instancePathPattern.MatchString(osbPath):
+			return unsupported("fetching service instances")
+		case bindingPathPattern.MatchString(osbPath):
+			return unsupported("fetching service bindings")
+		case bindingLastOperationPathPattern.MatchString(osbPath):
+			return unsupported("asynchronous service bindings")
+		}
+	}
+	if len(r.Body) != 0 && gjson.GetBytes(r.Body, "maintenance_info").Exists() && !supports(maintenanceInfoAPIVersion) {
+		return unsupported("maintenance info")
+	}
+
+	if bindingPathPattern.MatchString(osbPath) && !supports(asyncBindingsAPIVersion) {
+		// brokers which do not support asynchronous bindings respond synchronously which is allowed by the platforms
+		query := r.URL.Query()
+		query.Del("accepts_incomplete")
+		r.URL.RawQuery = query.Encode()
+	}
+	if !supports(originatingIdentityAPIVersion) {
+		r.Header.Del(originatingIdentityHeader)
+		r.Header.Del(originatingIdentitySignatureHeader)
+	}
+	if len(r.Body) != 0 && !supports(contextAPIVersion) && gjson.GetBytes(r.Body, "context").Exists() {
+		body, err := sjson.DeleteBytes(r.Body, "context")
+		if err != nil {
+			return &util.HTTPError{
+				ErrorType:   "BadRequest",
+				Description: fmt.Sprintf("could not remove context from request: %s", err),
+				StatusCode:  http.StatusBadRequest,
+			}
+		}
+		r.Body = body
+	}
+	r.Header.Set(brokerclient.APIVersionHeader, brokerVersion)
+	return nil
+}
@@ -42,13 +42,9 @@ func (sbf *StorageBrokerFetcher) FetchBroker(ctx context.Context, brokerID strin
 		return nil, util.HandleStorageError(err, "broker")
 	}
 
-	password := broker.Credentials.Basic.Password
-	plaintextPassword, err := sbf.Encrypter.Decrypt(ctx, []byte(password))
-	if err != nil {
+	if err := broker.TransformSecrets(ctx, sbf.Encrypter.Decrypt); err != nil {
 		return nil, err
 	}
 
-	broker.Credentials.Basic.Password = string(plaintextPassword)
-
 	return broker, nil
 }
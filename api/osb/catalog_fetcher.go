@@ -3,13 +3,18 @@ package osb
 import (
 	"context"
 
+	"github.com/Peripli/service-manager/pkg/query"
 	"github.com/Peripli/service-manager/pkg/types"
 	"github.com/Peripli/service-manager/storage"
 )
 
 // StorageCatalogFetcher fetches the broker's catalog from SM DB
 type StorageCatalogFetcher struct {
-	CatalogStorage storage.ServiceOffering
+	CatalogStorage         storage.ServiceOffering
+	CatalogOverrideStorage storage.CatalogOverride
+	// VisibilityStorage restricts the catalogs fetched by platforms to the plans visible to them; the complete
+	// catalogs are fetched if it is not set
+	VisibilityStorage storage.Visibility
 }
 
 // FetchCatalog implements osb.CatalogFetcher and fetches the catalog for the broker with the specified broker id from SM DB
@@ -18,6 +23,20 @@ func (scf *StorageCatalogFetcher) FetchCatalog(ctx context.Context, brokerID str
 	if err != nil {
 		return nil, err
 	}
+	if platform := types.PlatformIDFromContext(ctx); platform != "" && scf.VisibilityStorage != nil {
+		if catalog, err = scf.filterVisiblePlans(ctx, platform, catalog); err != nil {
+			return nil, err
+		}
+	}
+
+	// local overrides are keyed by the SM ids so they have to be applied before the ids are replaced
+	catalogOverrides, err := listCatalogOverrides(ctx, scf.CatalogOverrideStorage, catalog)
+	if err != nil {
+		return nil, err
+	}
+	if catalog, err = catalogOverrides.ApplyToServiceOfferings(catalog, true); err != nil {
+		return nil, err
+	}
 
 	// SM generates its own ids for the services and plans - currently for the platform we want to provide the original catalog id
 	for _, service := range catalog {
@@ -32,3 +51,70 @@ func (scf *StorageCatalogFetcher) FetchCatalog(ctx context.Context, brokerID str
 		ServiceOfferings: catalog,
 	}, nil
 }
+
+// listCatalogOverrides returns the catalog overrides of the provided service offerings and their plans
+func listCatalogOverrides(ctx context.Context, catalogOverrideStorage storage.CatalogOverride, serviceOfferings []*types.ServiceOffering) (*types.CatalogOverrides, error) {
+	result := &types.CatalogOverrides{}
+	var serviceOfferingIDs, servicePlanIDs []string
+	for _, serviceOffering := range serviceOfferings {
+		serviceOfferingIDs = append(serviceOfferingIDs, serviceOffering.ID)
+		for _, servicePlan := range serviceOffering.Plans {
+			servicePlanIDs = append(servicePlanIDs, servicePlan.ID)
+		}
+	}
+	if len(serviceOfferingIDs) != 0 {
+		overrides, err := catalogOverrideStorage.List(ctx, query.ByField(query.InOperator, "service_offering_id", serviceOfferingIDs...))
+		if err != nil {
+			return nil, err
+		}
+		result.CatalogOverrides = append(result.CatalogOverrides, overrides...)
+	}
+	if len(servicePlanIDs) != 0 {
+		overrides, err := catalogOverrideStorage.List(ctx, query.ByField(query.InOperator, "service_plan_id", servicePlanIDs...))
+		if err != nil {
+			return nil, err
+		}
+		result.CatalogOverrides = append(result.CatalogOverrides, overrides...)
+	}
+	return result, nil
+}
+
+// filterVisiblePlans removes the plans which are not visible to the platform and the services left without plans from
+// the catalog. The label criteria of the request restrict the visibilities of the platform but not the public ones.
+func (scf *StorageCatalogFetcher) filterVisiblePlans(ctx context.Context, platformID string, catalog []*types.ServiceOffering) ([]*types.ServiceOffering, error) {
+	criteria := []query.Criterion{query.ByField(query.EqualsOperator, "platform_id", platformID)}
+	for _, criterion := range query.CriteriaForContext(ctx) {
+		if criterion.Type == query.LabelQuery {
+			criteria = append(criteria, criterion)
+		}
+	}
+	platformVisibilities, err := scf.VisibilityStorage.List(ctx, criteria...)
+	if err != nil {
+		return nil, err
+	}
+	// public visibilities have no platform
+	publicVisibilities, err := scf.VisibilityStorage.List(ctx, query.ByField(query.EqualsOrNilOperator, "platform_id", ""))
+	if err != nil {
+		return nil, err
+	}
+	visiblePlans := make(map[string]bool)
+	for _, visibility := range append(platformVisibilities, publicVisibilities...) {
+		visiblePlans[visibility.ServicePlanID] = true
+	}
+
+	result := make([]*types.ServiceOffering, 0, len(catalog))
+	for _, serviceOffering := range catalog {
+		plans := make([]*types.ServicePlan, 0, len(serviceOffering.Plans))
+		for _, servicePlan := range serviceOffering.Plans {
+			if visiblePlans[servicePlan.ID] {
+				plans = append(plans, servicePlan)
+			}
+		}
+		if len(plans) == 0 {
+			continue
+		}
+		serviceOffering.Plans = plans
+		result = append(result, serviceOffering)
+	}
+	return result, nil
+}
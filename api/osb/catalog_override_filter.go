@@ -0,0 +1,90 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package osb
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
+	"github.com/Peripli/service-manager/pkg/web"
+	"github.com/Peripli/service-manager/storage"
+)
+
+// CatalogOverrideFilter rejects the provision and update requests for plans which are hidden from the catalog by
+// a catalog override of the plan or of its service offering
+type CatalogOverrideFilter struct {
+	Repository storage.Repository
+}
+
+func (cof *CatalogOverrideFilter) Name() string {
+	return "CatalogOverrideFilter"
+}
+
+func (cof *CatalogOverrideFilter) Run(req *web.Request, next web.Handler) (*web.Response, error) {
+	ctx := req.Context()
+	serviceID := gjson.GetBytes(req.Body, "service_id").String()
+	planID := gjson.GetBytes(req.Body, "plan_id").String()
+	if planID == "" {
+		// the update does not change the plan of the service instance
+		return next.Handle(req)
+	}
+
+	brokerID, err := requestBrokerID(cof.Repository, req)
+	if err != nil {
+		return nil, err
+	}
+	servicePlan, err := findServicePlan(ctx, cof.Repository, brokerID, serviceID, planID)
+	if err != nil {
+		return nil, err
+	}
+	if servicePlan == nil {
+		// requests for plans which are not in the catalog are left to the broker
+		return next.Handle(req)
+	}
+	serviceOffering := &types.ServiceOffering{ID: servicePlan.ServiceOfferingID, Plans: []*types.ServicePlan{servicePlan}}
+	catalogOverrides, err := listCatalogOverrides(ctx, cof.Repository.CatalogOverride(), []*types.ServiceOffering{serviceOffering})
+	if err != nil {
+		return nil, err
+	}
+	for _, override := range catalogOverrides.CatalogOverrides {
+		if override.Hidden {
+			log.C(ctx).Debugf("Plan with catalog id %s of service with catalog id %s of broker with id %s is hidden by catalog override with id %s", planID, serviceID, brokerID, override.ID)
+			return nil, &util.HTTPError{
+				ErrorType:   "Forbidden",
+				Description: fmt.Sprintf("plan with catalog id %s of service with catalog id %s is not available", planID, serviceID),
+				StatusCode:  http.StatusForbidden,
+			}
+		}
+	}
+	return next.Handle(req)
+}
+
+func (cof *CatalogOverrideFilter) FilterMatchers() []web.FilterMatcher {
+	return []web.FilterMatcher{
+		{
+			Matchers: []web.Matcher{
+				web.Path(web.OSBURL+"/*/v2/service_instances/*", web.AggregatedOSBURL+"/v2/service_instances/*"),
+				web.Methods(http.MethodPut, http.MethodPatch),
+			},
+		},
+	}
+}
@@ -0,0 +1,720 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package osb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/tidwall/gjson"
+
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/pkg/query"
+	"github.com/Peripli/service-manager/pkg/security"
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
+	"github.com/Peripli/service-manager/pkg/web"
+	"github.com/Peripli/service-manager/storage"
+)
+
+// InstanceTracker is implemented by providers which record the service instances and bindings managed through the OSB API
+type InstanceTracker interface {
+	// Track records the outcome of the OSB request with the specified path relative to the broker URL
+	Track(ctx context.Context, request *web.Request, broker *types.Broker, osbPath string, response *web.Response) error
+
+	// Fetch answers the OSB request with the specified path relative to the broker URL which fetches a service
+	// instance or binding that cannot be fetched from the broker. It returns nil if the request has to be forwarded
+	// to the broker.
+	Fetch(ctx context.Context, request *web.Request, broker *types.Broker, osbPath string) (*web.Response, error)
+}
+
+// StorageInstanceTracker records the service instances and bindings and their asynchronous operations in the storage
+type StorageInstanceTracker struct {
+	Repository storage.Repository
+
+	// Encrypter encrypts the credentials of the recorded service bindings
+	Encrypter security.Encrypter
+
+	// MaximumPollingDuration is the duration after which asynchronous operations on plans which do not specify
+	// maximum_polling_duration are considered failed; 0 means that such operations have no deadline
+	MaximumPollingDuration time.Duration
+}
+
+var _ InstanceTracker = &StorageInstanceTracker{}
+
+// Track records the service instances and bindings created by successful provision and bind requests and removes the
+// ones deleted by successful deprovision and unbind requests. Asynchronous operations accepted by the broker are
+// recorded together with their operation tokens and are completed when the last operation of the service instance
+// or binding is reported as finished by the broker.
+func (t *StorageInstanceTracker) Track(ctx context.Context, request *web.Request, broker *types.Broker, osbPath string, response *web.Response) error {
+	if m := instancePathPattern.FindStringSubmatch(osbPath); m != nil {
+		switch request.Method {
+		case http.MethodPut:
+			return t.trackProvision(ctx, request, broker, m[1], response)
+		case http.MethodPatch:
+			return t.trackUpdate(ctx, request, broker, m[1], response)
+		case http.MethodDelete:
+			return t.trackDeprovision(ctx, broker, m[1], response)
+		}
+	}
+	if m := instanceLastOperationPathPattern.FindStringSubmatch(osbPath); m != nil {
+		return t.trackInstanceLastOperation(ctx, m[1], response)
+	}
+	if m := bindingPathPattern.FindStringSubmatch(osbPath); m != nil {
+		switch request.Method {
+		case http.MethodPut:
+			return t.trackBind(ctx, request, broker, m[1], m[2], response)
+		case http.MethodDelete:
+			return t.trackUnbind(ctx, broker, m[1], m[2], response)
+		}
+	}
+	if m := bindingLastOperationPathPattern.FindStringSubmatch(osbPath); m != nil {
+		return t.trackBindingLastOperation(ctx, m[2], response)
+	}
+	return nil
+}
+
+// Fetch answers the requests which fetch service instances of services that are not instances_retrievable and
+// service bindings of services and plans that are not bindings_retrievable with the parameters, the dashboard URL and
+// the credentials recorded when they were created. Requests for resources which are not tracked are forwarded to the
+// broker and requests for resources created by other platforms are answered with 404 Not Found.
+func (t *StorageInstanceTracker) Fetch(ctx context.Context, request *web.Request, broker *types.Broker, osbPath string) (*web.Response, error) {
+	if request.Method != http.MethodGet {
+		return nil, nil
+	}
+	if m := instancePathPattern.FindStringSubmatch(osbPath); m != nil {
+		return t.fetchServiceInstance(ctx, broker, m[1])
+	}
+	if m := bindingPathPattern.FindStringSubmatch(osbPath); m != nil {
+		return t.fetchServiceBinding(ctx, broker, m[1], m[2])
+	}
+	return nil, nil
+}
+
+func (t *StorageInstanceTracker) fetchServiceInstance(ctx context.Context, broker *types.Broker, instanceID string) (*web.Response, error) {
+	instance, err := t.Repository.ServiceInstance().Get(ctx, instanceID)
+	if err == util.ErrNotFoundInStorage {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if instance.BrokerID != broker.ID {
+		return nil, nil
+	}
+	if instance.PlatformID != types.PlatformIDFromContext(ctx) {
+		return util.NewJSONResponse(http.StatusNotFound, map[string]string{})
+	}
+	plan, offering, err := t.servicePlanWithOffering(ctx, instance.ServicePlanID)
+	if err != nil || offering == nil || offering.InstancesRetrievable {
+		return nil, err
+	}
+	log.C(ctx).Debugf("Service instances of service %s of broker %s are not retrievable. Fetching service instance with id %s from SM DB", offering.Name, broker.Name, instanceID)
+	if !instance.Ready && instance.PendingOperation == types.OperationTypeCreate {
+		return util.NewJSONResponse(http.StatusNotFound, map[string]string{})
+	}
+	return util.NewJSONResponse(http.StatusOK, &serviceInstanceResponse{
+		ServiceID:    offering.CatalogID,
+		PlanID:       plan.CatalogID,
+		DashboardURL: instance.DashboardURL,
+		Parameters:   instance.Parameters,
+	})
+}
+
+func (t *StorageInstanceTracker) fetchServiceBinding(ctx context.Context, broker *types.Broker, instanceID, bindingID string) (*web.Response, error) {
+	binding, err := t.Repository.ServiceBinding().Get(ctx, bindingID)
+	if err == util.ErrNotFoundInStorage {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if binding.BrokerID != broker.ID || binding.ServiceInstanceID != instanceID {
+		return nil, nil
+	}
+	if binding.PlatformID != types.PlatformIDFromContext(ctx) {
+		return util.NewJSONResponse(http.StatusNotFound, map[string]string{})
+	}
+	instance, err := t.Repository.ServiceInstance().Get(ctx, instanceID)
+	if err == util.ErrNotFoundInStorage {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	plan, offering, err := t.servicePlanWithOffering(ctx, instance.ServicePlanID)
+	if err != nil || offering == nil || plan.IsBindingsRetrievable(offering) {
+		return nil, err
+	}
+	log.C(ctx).Debugf("Service bindings of plan %s of broker %s are not retrievable. Fetching service binding with id %s from SM DB", plan.Name, broker.Name, bindingID)
+	if !binding.Ready && binding.PendingOperation == types.OperationTypeCreate {
+		return util.NewJSONResponse(http.StatusNotFound, map[string]string{})
+	}
+	if t.Encrypter == nil {
+		binding.Credentials = nil
+	} else if err := binding.TransformSecrets(ctx, t.Encrypter.Decrypt); err != nil {
+		return nil, err
+	}
+	return util.NewJSONResponse(http.StatusOK, &serviceBindingResponse{
+		Credentials: binding.Credentials,
+		Parameters:  binding.Parameters,
+	})
+}
+
+// servicePlanWithOffering returns the plan with the specified Service Manager id and the service offering it belongs
+// to. Both are nil if the plan is no longer offered by the broker.
+func (t *StorageInstanceTracker) servicePlanWithOffering(ctx context.Context, servicePlanID string) (*types.ServicePlan, *types.ServiceOffering, error) {
+	plan, err := t.Repository.ServicePlan().Get(ctx, servicePlanID)
+	if err == util.ErrNotFoundInStorage {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	offering, err := t.Repository.ServiceOffering().Get(ctx, plan.ServiceOfferingID)
+	if err == util.ErrNotFoundInStorage {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return plan, offering, nil
+}
+
+func (t *StorageInstanceTracker) trackProvision(ctx context.Context, request *web.Request, broker *types.Broker, instanceID string, response *web.Response) error {
+	if !isSuccessfulOperation(response) {
+		return nil
+	}
+	servicePlanID, err := t.servicePlanID(ctx, broker, request.Body)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	instance := &types.ServiceInstance{
+		ID:            instanceID,
+		BrokerID:      broker.ID,
+		PlatformID:    types.PlatformIDFromContext(ctx),
+		ServicePlanID: servicePlanID,
+		Context:       requestContext(request.Body),
+		Parameters:    requestParameters(request.Body),
+		DashboardURL:  gjson.GetBytes(response.Body, "dashboard_url").String(),
+		Ready:         response.StatusCode != http.StatusAccepted,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if !instance.Ready {
+		instance.PendingOperation = types.OperationTypeCreate
+	}
+
+	existingInstance, err := t.Repository.ServiceInstance().Get(ctx, instanceID)
+	switch {
+	case err == util.ErrNotFoundInStorage:
+		_, err = t.Repository.ServiceInstance().Create(ctx, instance)
+	case err == nil && (existingInstance.BrokerID != instance.BrokerID || existingInstance.PlatformID != instance.PlatformID):
+		log.C(ctx).Warnf("Service instance with id %s is owned by another broker or platform. Skipping tracking of its provisioning", instanceID)
+		return nil
+	case err == nil:
+		instance.CreatedAt = existingInstance.CreatedAt
+		err = t.Repository.ServiceInstance().Update(ctx, instance)
+	}
+	if err != nil || instance.Ready {
+		return err
+	}
+	return t.createOperation(ctx, &types.Operation{
+		Type:         types.OperationTypeCreate,
+		ResourceType: types.ServiceInstanceResourceType,
+		ResourceID:   instanceID,
+		BrokerID:     broker.ID,
+	}, servicePlanID, response)
+}
+
+// trackUpdate records the plan, the context and the parameters of updated service instances. The parameters of the
+// update are merged with the recorded ones. The plan, the context and the parameters of asynchronous updates are
+// recorded when the update is accepted by the broker.
+func (t *StorageInstanceTracker) trackUpdate(ctx context.Context, request *web.Request, broker *types.Broker, instanceID string, response *web.Response) error {
+	if !isSuccessfulOperation(response) {
+		return nil
+	}
+	instance, err := t.Repository.ServiceInstance().Get(ctx, instanceID)
+	if err == util.ErrNotFoundInStorage {
+		log.C(ctx).Debugf("Service instance with id %s is not tracked. Skipping tracking of its update", instanceID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if gjson.GetBytes(request.Body, "plan_id").Exists() {
+		if instance.ServicePlanID, err = t.servicePlanID(ctx, broker, request.Body); err != nil {
+			return err
+		}
+	}
+	if osbContext := requestContext(request.Body); len(osbContext) != 0 {
+		instance.Context = osbContext
+	}
+	if parameters := requestParameters(request.Body); len(parameters) != 0 {
+		if instance.Parameters, err = mergeParameters(instance.Parameters, parameters); err != nil {
+			return err
+		}
+	}
+	if dashboardURL := gjson.GetBytes(response.Body, "dashboard_url"); dashboardURL.Exists() {
+		instance.DashboardURL = dashboardURL.String()
+	}
+	if response.StatusCode == http.StatusAccepted {
+		instance.PendingOperation = types.OperationTypeUpdate
+	} else {
+		instance.PendingOperation = ""
+	}
+	instance.UpdatedAt = time.Now().UTC()
+	if err := t.Repository.ServiceInstance().Update(ctx, instance); err != nil || response.StatusCode != http.StatusAccepted {
+		return err
+	}
+	return t.createOperation(ctx, &types.Operation{
+		Type:         types.OperationTypeUpdate,
+		ResourceType: types.ServiceInstanceResourceType,
+		ResourceID:   instanceID,
+		BrokerID:     broker.ID,
+	}, instance.ServicePlanID, response)
+}
+
+func (t *StorageInstanceTracker) trackDeprovision(ctx context.Context, broker *types.Broker, instanceID string, response *web.Response) error {
+	switch response.StatusCode {
+	case http.StatusOK, http.StatusGone:
+		if err := t.supersedeOperations(ctx, instanceID); err != nil {
+			return err
+		}
+		return t.deleteServiceInstance(ctx, instanceID)
+	case http.StatusAccepted:
+		instance, err := t.updateServiceInstance(ctx, instanceID, func(instance *types.ServiceInstance) {
+			instance.PendingOperation = types.OperationTypeDelete
+		})
+		if err != nil || instance == nil {
+			return err
+		}
+		return t.createOperation(ctx, &types.Operation{
+			Type:         types.OperationTypeDelete,
+			ResourceType: types.ServiceInstanceResourceType,
+			ResourceID:   instanceID,
+			BrokerID:     broker.ID,
+		}, instance.ServicePlanID, response)
+	}
+	return nil
+}
+
+func (t *StorageInstanceTracker) trackInstanceLastOperation(ctx context.Context, instanceID string, response *web.Response) error {
+	if err := t.finishOperations(ctx, instanceID, response); err != nil {
+		return err
+	}
+	instance, err := t.Repository.ServiceInstance().Get(ctx, instanceID)
+	if err == util.ErrNotFoundInStorage {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if instance.PendingOperation == "" {
+		return nil
+	}
+
+	switch state, _ := lastOperationResult(response, instance.PendingOperation); state {
+	case types.OperationStateSucceeded:
+		if instance.PendingOperation == types.OperationTypeDelete {
+			return t.deleteServiceInstance(ctx, instanceID)
+		}
+		instance.Ready = true
+	case types.OperationStateFailed:
+		if instance.PendingOperation == types.OperationTypeCreate {
+			return t.deleteServiceInstance(ctx, instanceID)
+		}
+	default:
+		return nil
+	}
+	instance.PendingOperation = ""
+	instance.UpdatedAt = time.Now().UTC()
+	return t.Repository.ServiceInstance().Update(ctx, instance)
+}
+
+func (t *StorageInstanceTracker) trackBind(ctx context.Context, request *web.Request, broker *types.Broker, instanceID, bindingID string, response *web.Response) error {
+	if !isSuccessfulOperation(response) {
+		return nil
+	}
+	instance, err := t.Repository.ServiceInstance().Get(ctx, instanceID)
+	if err != nil {
+		if err == util.ErrNotFoundInStorage {
+			log.C(ctx).Debugf("Service instance with id %s is not tracked. Skipping tracking of binding with id %s", instanceID, bindingID)
+			return nil
+		}
+		return err
+	}
+	now := time.Now().UTC()
+	binding := &types.ServiceBinding{
+		ID:                bindingID,
+		ServiceInstanceID: instanceID,
+		BrokerID:          broker.ID,
+		PlatformID:        types.PlatformIDFromContext(ctx),
+		Context:           requestContext(request.Body),
+		Parameters:        requestParameters(request.Body),
+		Credentials:       responseCredentials(response.Body),
+		Ready:             response.StatusCode != http.StatusAccepted,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	if !binding.Ready {
+		binding.PendingOperation = types.OperationTypeCreate
+	}
+	if t.Encrypter == nil {
+		binding.Credentials = nil
+	} else if err := binding.TransformSecrets(ctx, t.Encrypter.Encrypt); err != nil {
+		return err
+	}
+
+	existingBinding, err := t.Repository.ServiceBinding().Get(ctx, bindingID)
+	switch {
+	case err == util.ErrNotFoundInStorage:
+		_, err = t.Repository.ServiceBinding().Create(ctx, binding)
+	case err == nil && (existingBinding.BrokerID != binding.BrokerID || existingBinding.PlatformID != binding.PlatformID):
+		log.C(ctx).Warnf("Service binding with id %s is owned by another broker or platform. Skipping tracking of its creation", bindingID)
+		return nil
+	case err == nil:
+		binding.CreatedAt = existingBinding.CreatedAt
+		err = t.Repository.ServiceBinding().Update(ctx, binding)
+	}
+	if err != nil || binding.Ready {
+		return err
+	}
+	return t.createOperation(ctx, &types.Operation{
+		Type:              types.OperationTypeCreate,
+		ResourceType:      types.ServiceBindingResourceType,
+		ResourceID:        bindingID,
+		ServiceInstanceID: instanceID,
+		BrokerID:          broker.ID,
+	}, instance.ServicePlanID, response)
+}
+
+func (t *StorageInstanceTracker) trackUnbind(ctx context.Context, broker *types.Broker, instanceID, bindingID string, response *web.Response) error {
+	switch response.StatusCode {
+	case http.StatusOK, http.StatusGone:
+		if err := t.supersedeOperations(ctx, bindingID); err != nil {
+			return err
+		}
+		return t.deleteServiceBinding(ctx, bindingID)
+	case http.StatusAccepted:
+		binding, err := t.updateServiceBinding(ctx, bindingID, func(binding *types.ServiceBinding) {
+			binding.PendingOperation = types.OperationTypeDelete
+		})
+		if err != nil || binding == nil {
+			return err
+		}
+		instance, err := t.Repository.ServiceInstance().Get(ctx, instanceID)
+		if err != nil {
+			return err
+		}
+		return t.createOperation(ctx, &types.Operation{
+			Type:              types.OperationTypeDelete,
+			ResourceType:      types.ServiceBindingResourceType,
+			ResourceID:        bindingID,
+			ServiceInstanceID: instanceID,
+			BrokerID:          broker.ID,
+		}, instance.ServicePlanID, response)
+	}
+	return nil
+}
+
+func (t *StorageInstanceTracker) trackBindingLastOperation(ctx context.Context, bindingID string, response *web.Response) error {
+	if err := t.finishOperations(ctx, bindingID, response); err != nil {
+		return err
+	}
+	binding, err := t.Repository.ServiceBinding().Get(ctx, bindingID)
+	if err == util.ErrNotFoundInStorage {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if binding.PendingOperation == "" {
+		return nil
+	}
+
+	switch state, _ := lastOperationResult(response, binding.PendingOperation); state {
+	case types.OperationStateSucceeded:
+		if binding.PendingOperation == types.OperationTypeDelete {
+			return t.deleteServiceBinding(ctx, bindingID)
+		}
+		binding.Ready = true
+	case types.OperationStateFailed:
+		if binding.PendingOperation == types.OperationTypeCreate {
+			return t.deleteServiceBinding(ctx, bindingID)
+		}
+	default:
+		return nil
+	}
+	binding.PendingOperation = ""
+	binding.UpdatedAt = time.Now().UTC()
+	return t.Repository.ServiceBinding().Update(ctx, binding)
+}
+
+// updateServiceInstance updates the tracked service instance and returns it. The returned instance is nil if the
+// service instance is not tracked.
+func (t *StorageInstanceTracker) updateServiceInstance(ctx context.Context, instanceID string, updateFunc func(instance *types.ServiceInstance)) (*types.ServiceInstance, error) {
+	instance, err := t.Repository.ServiceInstance().Get(ctx, instanceID)
+	if err == util.ErrNotFoundInStorage {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	updateFunc(instance)
+	instance.UpdatedAt = time.Now().UTC()
+	return instance, t.Repository.ServiceInstance().Update(ctx, instance)
+}
+
+// updateServiceBinding updates the tracked service binding and returns it. The returned binding is nil if the
+// service binding is not tracked.
+func (t *StorageInstanceTracker) updateServiceBinding(ctx context.Context, bindingID string, updateFunc func(binding *types.ServiceBinding)) (*types.ServiceBinding, error) {
+	binding, err := t.Repository.ServiceBinding().Get(ctx, bindingID)
+	if err == util.ErrNotFoundInStorage {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	updateFunc(binding)
+	binding.UpdatedAt = time.Now().UTC()
+	return binding, t.Repository.ServiceBinding().Update(ctx, binding)
+}
+
+// createOperation records an asynchronous operation accepted by the broker. Operations which are still in progress
+// for the same resource are superseded by the new operation.
+func (t *StorageInstanceTracker) createOperation(ctx context.Context, operation *types.Operation, servicePlanID string, response *web.Response) error {
+	if err := t.supersedeOperations(ctx, operation.ResourceID); err != nil {
+		return err
+	}
+	UUID, err := uuid.NewV4()
+	if err != nil {
+		return fmt.Errorf("could not generate GUID for operation: %s", err)
+	}
+	deadline, err := t.operationDeadline(ctx, servicePlanID)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	operation.ID = UUID.String()
+	operation.State = types.OperationStateInProgress
+	operation.Token = gjson.GetBytes(response.Body, "operation").String()
+	operation.Deadline = deadline
+	operation.CreatedAt = now
+	operation.UpdatedAt = now
+	_, err = t.Repository.Operation().Create(ctx, operation)
+	return err
+}
+
+// finishOperations sets the final state reported by the last operation response to the operations of the resource
+// which are still in progress
+func (t *StorageInstanceTracker) finishOperations(ctx context.Context, resourceID string, response *web.Response) error {
+	return t.updateOperations(ctx, resourceID, func(operation *types.Operation) (string, string) {
+		return lastOperationResult(response, operation.Type)
+	})
+}
+
+// supersedeOperations fails the operations of the resource which are still in progress as the broker has accepted
+// a new request for the resource
+func (t *StorageInstanceTracker) supersedeOperations(ctx context.Context, resourceID string) error {
+	return t.updateOperations(ctx, resourceID, func(operation *types.Operation) (string, string) {
+		return types.OperationStateFailed, "superseded by a new operation"
+	})
+}
+
+// updateOperations sets the state and the description returned by the result function to the operations of the
+// resource which are still in progress. Operations for which the result function returns an empty state are not changed.
+func (t *StorageInstanceTracker) updateOperations(ctx context.Context, resourceID string, result func(operation *types.Operation) (string, string)) error {
+	operations, err := t.Repository.Operation().List(ctx,
+		query.ByField(query.EqualsOperator, "resource_id", resourceID),
+		query.ByField(query.EqualsOperator, "state", types.OperationStateInProgress))
+	if err != nil {
+		return err
+	}
+	for _, operation := range operations {
+		state, description := result(operation)
+		if state == "" {
+			continue
+		}
+		operation.State = state
+		operation.Description = description
+		operation.UpdatedAt = time.Now().UTC()
+		if err := t.Repository.Operation().Update(ctx, operation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// operationDeadline returns the time until which an operation on the plan is polled. The maximum_polling_duration
+// of the plan takes precedence over the configured maximum polling duration.
+func (t *StorageInstanceTracker) operationDeadline(ctx context.Context, servicePlanID string) (time.Time, error) {
+	plan, err := t.Repository.ServicePlan().Get(ctx, servicePlanID)
+	if err != nil && err != util.ErrNotFoundInStorage {
+		return time.Time{}, err
+	}
+	now := time.Now().UTC()
+	if plan != nil && plan.MaximumPollingDuration > 0 {
+		return now.Add(time.Duration(plan.MaximumPollingDuration) * time.Second), nil
+	}
+	if t.MaximumPollingDuration > 0 {
+		return now.Add(t.MaximumPollingDuration), nil
+	}
+	return time.Time{}, nil
+}
+
+func (t *StorageInstanceTracker) deleteServiceInstance(ctx context.Context, instanceID string) error {
+	byID := query.ByField(query.EqualsOperator, "id", instanceID)
+	if err := t.Repository.ServiceInstance().Delete(ctx, byID); err != nil && err != util.ErrNotFoundInStorage {
+		return err
+	}
+	return nil
+}
+
+func (t *StorageInstanceTracker) deleteServiceBinding(ctx context.Context, bindingID string) error {
+	byID := query.ByField(query.EqualsOperator, "id", bindingID)
+	if err := t.Repository.ServiceBinding().Delete(ctx, byID); err != nil && err != util.ErrNotFoundInStorage {
+		return err
+	}
+	return nil
+}
+
+// servicePlanID returns the Service Manager id of the plan referenced by the catalog ids in the OSB request body
+func (t *StorageInstanceTracker) servicePlanID(ctx context.Context, broker *types.Broker, body []byte) (string, error) {
+	serviceID := gjson.GetBytes(body, "service_id").String()
+	planID := gjson.GetBytes(body, "plan_id").String()
+	servicePlan, err := findServicePlan(ctx, t.Repository, broker.ID, serviceID, planID)
+	if err != nil {
+		return "", err
+	}
+	if servicePlan == nil {
+		return "", fmt.Errorf("plan with catalog id %s of service with catalog id %s not found for broker %s", planID, serviceID, broker.Name)
+	}
+	return servicePlan.ID, nil
+}
+
+// findServicePlan returns the plan of the specified broker with the specified service and plan catalog ids or nil
+// if the broker offers no such plan
+func findServicePlan(ctx context.Context, repository storage.Repository, brokerID, serviceID, planID string) (*types.ServicePlan, error) {
+	serviceOfferings, err := repository.ServiceOffering().ListWithServicePlansByBrokerID(ctx, brokerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, serviceOffering := range serviceOfferings {
+		if serviceOffering.CatalogID != serviceID {
+			continue
+		}
+		for _, servicePlan := range serviceOffering.Plans {
+			if servicePlan.CatalogID == planID {
+				return servicePlan, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// requestBrokerID returns the id of the broker to which the OSB request is sent. Requests to the aggregated OSB API do
+// not specify the broker, in which case it is resolved the same way the aggregated OSB API routes the request.
+func requestBrokerID(repository storage.Repository, req *web.Request) (string, error) {
+	if brokerID := req.PathParams[BrokerIDPathParam]; brokerID != "" {
+		return brokerID, nil
+	}
+	return routeAggregatedRequest(repository, req)
+}
+
+// lastOperationResult returns the final state and the description of an operation of the specified type reported by
+// the last operation response. The returned state is empty if the operation has not finished.
+func lastOperationResult(response *web.Response, operationType string) (string, string) {
+	if response.StatusCode == http.StatusGone && operationType == types.OperationTypeDelete {
+		return types.OperationStateSucceeded, ""
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", ""
+	}
+	switch state := gjson.GetBytes(response.Body, "state").String(); state {
+	case types.OperationStateSucceeded, types.OperationStateFailed:
+		return state, gjson.GetBytes(response.Body, "description").String()
+	}
+	return "", ""
+}
+
+func isSuccessfulOperation(response *web.Response) bool {
+	return response.StatusCode == http.StatusOK || response.StatusCode == http.StatusCreated || response.StatusCode == http.StatusAccepted
+}
+
+// requestContext returns the OSB context object from the request body
+func requestContext(body []byte) json.RawMessage {
+	osbContext := gjson.GetBytes(body, "context")
+	if !osbContext.IsObject() {
+		return nil
+	}
+	return json.RawMessage(osbContext.Raw)
+}
+
+// requestParameters returns the configuration parameters from the request body
+func requestParameters(body []byte) json.RawMessage {
+	parameters := gjson.GetBytes(body, "parameters")
+	if !parameters.IsObject() {
+		return nil
+	}
+	return json.RawMessage(parameters.Raw)
+}
+
+// responseCredentials returns the binding credentials from the response body
+func responseCredentials(body []byte) json.RawMessage {
+	credentials := gjson.GetBytes(body, "credentials")
+	if !credentials.IsObject() {
+		return nil
+	}
+	return json.RawMessage(credentials.Raw)
+}
+
+// mergeParameters adds the parameters of an update to the recorded parameters. Parameters which are present in both
+// are replaced by the parameters of the update.
+func mergeParameters(recorded, update json.RawMessage) (json.RawMessage, error) {
+	parameters := make(map[string]json.RawMessage)
+	if len(recorded) != 0 {
+		if err := json.Unmarshal(recorded, &parameters); err != nil {
+			return nil, err
+		}
+	}
+	updateParameters := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(update, &updateParameters); err != nil {
+		return nil, err
+	}
+	for name, value := range updateParameters {
+		parameters[name] = value
+	}
+	return json.Marshal(parameters)
+}
+
+type serviceInstanceResponse struct {
+	ServiceID    string          `json:"service_id"`
+	PlanID       string          `json:"plan_id"`
+	DashboardURL string          `json:"dashboard_url,omitempty"`
+	Parameters   json.RawMessage `json:"parameters,omitempty"`
+}
+
+type serviceBindingResponse struct {
+	Credentials json.RawMessage `json:"credentials,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
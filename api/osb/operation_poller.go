@@ -0,0 +1,182 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package osb
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/brokerclient"
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/pkg/query"
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
+	"github.com/Peripli/service-manager/pkg/web"
+	"github.com/Peripli/service-manager/storage"
+)
+
+// OperationPoller polls the last operation endpoints of the brokers on behalf of the platforms so that asynchronous
+// operations are resolved even if the platforms stop polling. Operations which do not finish before their deadline
+// are failed.
+type OperationPoller struct {
+	repository      storage.Repository
+	brokerFetcher   BrokerFetcher
+	brokerClient    *brokerclient.Client
+	instanceTracker InstanceTracker
+	interval        time.Duration
+}
+
+// NewOperationPoller returns an operation poller which polls the operations in progress every interval until the
+// context is done
+func NewOperationPoller(ctx context.Context, repository storage.Repository, brokerFetcher BrokerFetcher, brokerClient *brokerclient.Client, instanceTracker InstanceTracker, interval time.Duration) *OperationPoller {
+	poller := &OperationPoller{
+		repository:      repository,
+		brokerFetcher:   brokerFetcher,
+		brokerClient:    brokerClient,
+		instanceTracker: instanceTracker,
+		interval:        interval,
+	}
+	go poller.run(ctx)
+	return poller
+}
+
+func (p *OperationPoller) run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.pollOperations(ctx)
+		}
+	}
+}
+
+func (p *OperationPoller) pollOperations(ctx context.Context) {
+	operations, err := p.repository.Operation().List(ctx, query.ByField(query.EqualsOperator, "state", types.OperationStateInProgress))
+	if err != nil {
+		log.C(ctx).WithError(err).Error("Could not list operations in progress")
+		return
+	}
+	for _, operation := range operations {
+		if err := p.pollOperation(ctx, operation); err != nil {
+			log.C(ctx).WithError(err).Errorf("Could not poll the last operation of %s with id %s", operation.ResourceType, operation.ResourceID)
+		}
+	}
+}
+
+func (p *OperationPoller) pollOperation(ctx context.Context, operation *types.Operation) error {
+	broker, err := p.brokerFetcher.FetchBroker(ctx, operation.BrokerID)
+	if err != nil {
+		return err
+	}
+	osbPath := lastOperationPath(operation)
+	request, err := http.NewRequest(http.MethodGet, broker.BrokerURL+osbPath, nil)
+	if err != nil {
+		return err
+	}
+
+	var response *web.Response
+	if !operation.Deadline.IsZero() && time.Now().UTC().After(operation.Deadline) {
+		log.C(ctx).Warnf("Operation with id %s did not finish within the maximum polling duration", operation.ID)
+		response, err = util.NewJSONResponse(http.StatusOK, map[string]string{
+			"state":       types.OperationStateFailed,
+			"description": "operation did not finish within the maximum polling duration",
+		})
+	} else {
+		if operation.ResourceType == types.ServiceBindingResourceType &&
+			brokerclient.CompareAPIVersions(brokerclient.BrokerAPIVersion(broker), asyncBindingsAPIVersion) < 0 {
+			return nil
+		}
+		response, err = p.fetchLastOperation(ctx, request, broker, operation)
+	}
+	if err != nil {
+		return err
+	}
+	return p.instanceTracker.Track(ctx, &web.Request{Request: request}, broker, osbPath, response)
+}
+
+func (p *OperationPoller) fetchLastOperation(ctx context.Context, request *http.Request, broker *types.Broker, operation *types.Operation) (*web.Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.interval)
+	defer cancel()
+
+	params, err := p.lastOperationParams(ctx, operation)
+	if err != nil {
+		return nil, err
+	}
+	request = request.WithContext(ctx)
+	request.URL.RawQuery = params.Encode()
+	request.Header.Set(brokerclient.APIVersionHeader, brokerclient.BrokerAPIVersion(broker))
+
+	response, err := p.brokerClient.Do(request, broker)
+	if err != nil {
+		return nil, fmt.Errorf("could not reach service broker %s at %s: %s", broker.Name, request.URL, err)
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &web.Response{
+		StatusCode: response.StatusCode,
+		Header:     response.Header,
+		Body:       body,
+	}, nil
+}
+
+// lastOperationParams returns the query parameters of the last operation request which identify the operation and
+// the service and plan of its service instance
+func (p *OperationPoller) lastOperationParams(ctx context.Context, operation *types.Operation) (url.Values, error) {
+	params := url.Values{}
+	if operation.Token != "" {
+		params.Set("operation", operation.Token)
+	}
+	instanceID := operation.ResourceID
+	if operation.ResourceType == types.ServiceBindingResourceType {
+		instanceID = operation.ServiceInstanceID
+	}
+	instance, err := p.repository.ServiceInstance().Get(ctx, instanceID)
+	if err == util.ErrNotFoundInStorage {
+		return params, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	plan, err := p.repository.ServicePlan().Get(ctx, instance.ServicePlanID)
+	if err != nil {
+		return nil, err
+	}
+	service, err := p.repository.ServiceOffering().Get(ctx, plan.ServiceOfferingID)
+	if err != nil {
+		return nil, err
+	}
+	params.Set("service_id", service.CatalogID)
+	params.Set("plan_id", plan.CatalogID)
+	return params, nil
+}
+
+func lastOperationPath(operation *types.Operation) string {
+	if operation.ResourceType == types.ServiceBindingResourceType {
+		return fmt.Sprintf("/v2/service_instances/%s/service_bindings/%s/last_operation", operation.ServiceInstanceID, operation.ResourceID)
+	}
+	return fmt.Sprintf("/v2/service_instances/%s/last_operation", operation.ResourceID)
+}
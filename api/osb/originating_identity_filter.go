@@ -0,0 +1,124 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package osb
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Peripli/service-manager/pkg/util"
+	"github.com/Peripli/service-manager/pkg/web"
+)
+
+const (
+	// originatingIdentitySignatureHeader carries the signature of the originating identity computed as the base64
+	// encoded HMAC-SHA256 of the value of the originating identity header
+	originatingIdentitySignatureHeader = "X-Broker-API-Originating-Identity-Signature"
+
+	// serviceManagerPlatform is the platform of the originating identities reserved for Service Manager itself
+	serviceManagerPlatform = "service-manager"
+)
+
+// OriginatingIdentityFilter forwards the originating identity of OSB requests to the brokers. The identities
+// provided by the platforms are validated and, if a verification key is configured, their signatures are verified.
+// If a signing key is configured, the verified identities are signed so that brokers can trust them. Identities
+// which were not verified are forwarded without signature.
+type OriginatingIdentityFilter struct {
+	SigningKey      string
+	VerificationKey string
+}
+
+func (oif *OriginatingIdentityFilter) Name() string {
+	return "OriginatingIdentityFilter"
+}
+
+func (oif *OriginatingIdentityFilter) Run(req *web.Request, next web.Handler) (*web.Response, error) {
+	signature := req.Header.Get(originatingIdentitySignatureHeader)
+	// only the signatures of Service Manager are forwarded to the brokers
+	req.Header.Del(originatingIdentitySignatureHeader)
+
+	identity := req.Header.Get(originatingIdentityHeader)
+	if identity == "" {
+		return next.Handle(req)
+	}
+	if err := validateOriginatingIdentity(identity); err != nil {
+		return nil, err
+	}
+	if oif.VerificationKey == "" {
+		return next.Handle(req)
+	}
+	if !hmac.Equal([]byte(signature), []byte(signOriginatingIdentity(oif.VerificationKey, identity))) {
+		return nil, &util.HTTPError{
+			ErrorType:   "BadRequest",
+			Description: "originating identity signature is missing or invalid",
+			StatusCode:  http.StatusBadRequest,
+		}
+	}
+	if oif.SigningKey != "" {
+		req.Header.Set(originatingIdentitySignatureHeader, signOriginatingIdentity(oif.SigningKey, identity))
+	}
+	return next.Handle(req)
+}
+
+// validateOriginatingIdentity verifies that the identity consists of a platform other than service-manager and a
+// base64 encoded JSON object separated by a space as required by the OSB specification
+func validateOriginatingIdentity(identity string) error {
+	invalid := func(reason string) error {
+		return &util.HTTPError{
+			ErrorType:   "BadRequest",
+			Description: fmt.Sprintf("invalid originating identity: %s", reason),
+			StatusCode:  http.StatusBadRequest,
+		}
+	}
+	parts := strings.Split(identity, " ")
+	if len(parts) != 2 || parts[0] == "" {
+		return invalid("platform and value must be separated by a single space")
+	}
+	if parts[0] == serviceManagerPlatform {
+		return invalid(fmt.Sprintf("platform %s is reserved for Service Manager", serviceManagerPlatform))
+	}
+	value, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return invalid("value is not base64 encoded")
+	}
+	object := make(map[string]interface{})
+	if err := json.Unmarshal(value, &object); err != nil {
+		return invalid("value is not a JSON object")
+	}
+	return nil
+}
+
+func signOriginatingIdentity(key, identity string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(identity))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (oif *OriginatingIdentityFilter) FilterMatchers() []web.FilterMatcher {
+	return []web.FilterMatcher{
+		{
+			Matchers: []web.Matcher{
+				web.Path(web.OSBURL+"/**", web.AggregatedOSBURL+"/**"),
+			},
+		},
+	}
+}
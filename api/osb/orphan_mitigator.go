@@ -0,0 +1,312 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package osb
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/tidwall/gjson"
+
+	"github.com/Peripli/service-manager/pkg/brokerclient"
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/pkg/query"
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/web"
+	"github.com/Peripli/service-manager/storage"
+)
+
+const (
+	// maxBackoffShift limits the exponential growth of the delay between the orphan mitigation attempts
+	maxBackoffShift = 10
+
+	// orphanMitigationLease is the time for which a claimed orphan mitigation is not processed by other Service
+	// Manager instances. Orphan mitigations of instances which stop while processing them are retried afterwards.
+	orphanMitigationLease = 5 * time.Minute
+)
+
+// OrphanMitigator is implemented by providers which clean up the service instances and bindings that may have been
+// left orphaned at the brokers by failed provision and bind requests
+type OrphanMitigator interface {
+	// Mitigate schedules the deletion of the service instance or binding with the specified path relative to the
+	// broker URL. The request is the failed provision or bind request.
+	Mitigate(ctx context.Context, broker *types.Broker, osbPath string, request *web.Request) error
+}
+
+// StorageOrphanMitigator persists the orphan mitigations in the storage and sends the compensating deprovision and
+// unbind requests to the brokers in the background. Failed requests are retried with exponential backoff until the
+// maximum number of attempts is reached. Requests accepted asynchronously are followed by polling the last operation.
+type StorageOrphanMitigator struct {
+	repository      storage.Repository
+	brokerFetcher   BrokerFetcher
+	brokerClient    *brokerclient.Client
+	instanceTracker InstanceTracker
+	backoff         time.Duration
+	maxAttempts     int
+
+	scheduled chan struct{}
+}
+
+var _ OrphanMitigator = &StorageOrphanMitigator{}
+
+// NewStorageOrphanMitigator returns an orphan mitigator which processes the pending orphan mitigations until the
+// context is done. The backoff is the delay before the first retry of a failed attempt. The instance tracker is optional.
+func NewStorageOrphanMitigator(ctx context.Context, repository storage.Repository, brokerFetcher BrokerFetcher, brokerClient *brokerclient.Client, instanceTracker InstanceTracker, backoff time.Duration, maxAttempts int) *StorageOrphanMitigator {
+	mitigator := &StorageOrphanMitigator{
+		repository:      repository,
+		brokerFetcher:   brokerFetcher,
+		brokerClient:    brokerClient,
+		instanceTracker: instanceTracker,
+		backoff:         backoff,
+		maxAttempts:     maxAttempts,
+		scheduled:       make(chan struct{}, 1),
+	}
+	go mitigator.run(ctx)
+	return mitigator
+}
+
+// Mitigate persists the orphan mitigation so that it survives restarts and triggers its first attempt
+func (m *StorageOrphanMitigator) Mitigate(ctx context.Context, broker *types.Broker, osbPath string, request *web.Request) error {
+	UUID, err := uuid.NewV4()
+	if err != nil {
+		return fmt.Errorf("could not generate GUID for orphan mitigation: %s", err)
+	}
+	resourceType := types.ServiceInstanceResourceType
+	if bindingPathPattern.MatchString(osbPath) {
+		resourceType = types.ServiceBindingResourceType
+	}
+	now := time.Now().UTC()
+	orphanMitigation := &types.OrphanMitigation{
+		ID:            UUID.String(),
+		BrokerID:      broker.ID,
+		ResourceType:  resourceType,
+		Path:          osbPath,
+		ServiceID:     gjson.GetBytes(request.Body, "service_id").String(),
+		PlanID:        gjson.GetBytes(request.Body, "plan_id").String(),
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if _, err := m.repository.OrphanMitigation().Create(ctx, orphanMitigation); err != nil {
+		return err
+	}
+	log.C(ctx).Infof("Scheduled orphan mitigation of %s at %s of broker %s", resourceType, osbPath, broker.Name)
+
+	select {
+	case m.scheduled <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (m *StorageOrphanMitigator) run(ctx context.Context) {
+	ticker := time.NewTicker(m.backoff)
+	defer ticker.Stop()
+	for {
+		m.processOrphanMitigations(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-m.scheduled:
+		}
+	}
+}
+
+func (m *StorageOrphanMitigator) processOrphanMitigations(ctx context.Context) {
+	orphanMitigations, err := m.repository.OrphanMitigation().Claim(ctx, time.Now().UTC(), orphanMitigationLease)
+	if err != nil {
+		log.C(ctx).WithError(err).Error("Could not claim pending orphan mitigations")
+		return
+	}
+	for _, orphanMitigation := range orphanMitigations {
+		if err := m.attempt(ctx, orphanMitigation); err != nil {
+			log.C(ctx).WithError(err).Errorf("Could not process orphan mitigation with id %s", orphanMitigation.ID)
+		}
+	}
+}
+
+func (m *StorageOrphanMitigator) attempt(ctx context.Context, orphanMitigation *types.OrphanMitigation) error {
+	logger := log.C(ctx)
+	broker, err := m.brokerFetcher.FetchBroker(ctx, orphanMitigation.BrokerID)
+	if err != nil {
+		return m.retry(ctx, orphanMitigation.BrokerID, orphanMitigation, fmt.Errorf("could not fetch broker: %s", err))
+	}
+	if orphanMitigation.DeletionAccepted {
+		return m.pollLastOperation(ctx, broker, orphanMitigation)
+	}
+
+	request, response, err := m.sendDelete(ctx, broker, orphanMitigation)
+	if err == nil {
+		switch response.StatusCode {
+		case http.StatusOK, http.StatusGone:
+			logger.Infof("Orphan mitigation of %s at %s of broker %s succeeded with status %d",
+				orphanMitigation.ResourceType, orphanMitigation.Path, broker.Name, response.StatusCode)
+			m.track(ctx, request, broker, orphanMitigation.Path, response)
+			return m.delete(ctx, orphanMitigation)
+		case http.StatusAccepted:
+			logger.Infof("Orphan mitigation of %s at %s of broker %s was accepted. Polling its last operation",
+				orphanMitigation.ResourceType, orphanMitigation.Path, broker.Name)
+			m.track(ctx, request, broker, orphanMitigation.Path, response)
+			orphanMitigation.DeletionAccepted = true
+			orphanMitigation.Operation = gjson.GetBytes(response.Body, "operation").String()
+			return m.postpone(ctx, orphanMitigation)
+		}
+		err = fmt.Errorf("service broker %s replied with status %d", broker.Name, response.StatusCode)
+	}
+	return m.retry(ctx, broker.Name, orphanMitigation, err)
+}
+
+// pollLastOperation fetches the last operation of the accepted compensating request. The orphan mitigation is removed
+// once the deletion succeeds and the compensating request is sent again if the deletion fails.
+func (m *StorageOrphanMitigator) pollLastOperation(ctx context.Context, broker *types.Broker, orphanMitigation *types.OrphanMitigation) error {
+	logger := log.C(ctx)
+	osbPath := orphanMitigation.Path + "/last_operation"
+	params := m.params(orphanMitigation)
+	if orphanMitigation.Operation != "" {
+		params.Set("operation", orphanMitigation.Operation)
+	}
+	request, response, err := m.send(ctx, broker, http.MethodGet, osbPath, params)
+	if err != nil {
+		return m.retry(ctx, broker.Name, orphanMitigation, err)
+	}
+	m.track(ctx, request, broker, osbPath, response)
+
+	switch state, description := lastOperationResult(response, types.OperationTypeDelete); state {
+	case types.OperationStateSucceeded:
+		logger.Infof("Orphan mitigation of %s at %s of broker %s succeeded",
+			orphanMitigation.ResourceType, orphanMitigation.Path, broker.Name)
+		return m.delete(ctx, orphanMitigation)
+	case types.OperationStateFailed:
+		orphanMitigation.DeletionAccepted = false
+		orphanMitigation.Operation = ""
+		return m.retry(ctx, broker.Name, orphanMitigation, fmt.Errorf("service broker %s failed the deletion: %s", broker.Name, description))
+	}
+	if response.StatusCode != http.StatusOK {
+		return m.retry(ctx, broker.Name, orphanMitigation, fmt.Errorf("service broker %s replied to the last operation request with status %d", broker.Name, response.StatusCode))
+	}
+	return m.postpone(ctx, orphanMitigation)
+}
+
+// retry schedules the next attempt of a failed orphan mitigation with exponential backoff or gives up on it after the
+// maximum number of attempts
+func (m *StorageOrphanMitigator) retry(ctx context.Context, brokerName string, orphanMitigation *types.OrphanMitigation, err error) error {
+	logger := log.C(ctx)
+	orphanMitigation.Attempts++
+	orphanMitigation.LastError = err.Error()
+	if orphanMitigation.Attempts >= m.maxAttempts {
+		logger.Errorf("Giving up orphan mitigation of %s at %s of broker %s after %d attempts: %s",
+			orphanMitigation.ResourceType, orphanMitigation.Path, brokerName, orphanMitigation.Attempts, err)
+		return m.delete(ctx, orphanMitigation)
+	}
+	shift := uint(orphanMitigation.Attempts - 1)
+	if shift > maxBackoffShift {
+		shift = maxBackoffShift
+	}
+	now := time.Now().UTC()
+	orphanMitigation.NextAttemptAt = now.Add(m.backoff << shift)
+	orphanMitigation.UpdatedAt = now
+	logger.Warnf("Orphan mitigation attempt %d of %s at %s of broker %s failed: %s. Retrying at %s",
+		orphanMitigation.Attempts, orphanMitigation.ResourceType, orphanMitigation.Path, brokerName, err, orphanMitigation.NextAttemptAt)
+	return m.repository.OrphanMitigation().Update(ctx, orphanMitigation)
+}
+
+// postpone schedules the next poll of the last operation of an accepted compensating request
+func (m *StorageOrphanMitigator) postpone(ctx context.Context, orphanMitigation *types.OrphanMitigation) error {
+	now := time.Now().UTC()
+	orphanMitigation.NextAttemptAt = now.Add(m.backoff)
+	orphanMitigation.UpdatedAt = now
+	return m.repository.OrphanMitigation().Update(ctx, orphanMitigation)
+}
+
+func (m *StorageOrphanMitigator) track(ctx context.Context, request *http.Request, broker *types.Broker, osbPath string, response *web.Response) {
+	if m.instanceTracker == nil {
+		return
+	}
+	if err := m.instanceTracker.Track(ctx, &web.Request{Request: request}, broker, osbPath, response); err != nil {
+		log.C(ctx).WithError(err).Errorf("Could not track orphan mitigation request to %s", osbPath)
+	}
+}
+
+// sendDelete sends the compensating deprovision or unbind request to the broker
+func (m *StorageOrphanMitigator) sendDelete(ctx context.Context, broker *types.Broker, orphanMitigation *types.OrphanMitigation) (*http.Request, *web.Response, error) {
+	params := m.params(orphanMitigation)
+	if orphanMitigation.ResourceType == types.ServiceInstanceResourceType ||
+		brokerclient.CompareAPIVersions(brokerclient.BrokerAPIVersion(broker), asyncBindingsAPIVersion) >= 0 {
+		params.Set("accepts_incomplete", "true")
+	}
+	return m.send(ctx, broker, http.MethodDelete, orphanMitigation.Path, params)
+}
+
+// params returns the query parameters which identify the service and plan of the orphaned resource
+func (m *StorageOrphanMitigator) params(orphanMitigation *types.OrphanMitigation) url.Values {
+	params := url.Values{}
+	params.Set("service_id", orphanMitigation.ServiceID)
+	params.Set("plan_id", orphanMitigation.PlanID)
+	return params
+}
+
+func (m *StorageOrphanMitigator) send(ctx context.Context, broker *types.Broker, method, osbPath string, params url.Values) (*http.Request, *web.Response, error) {
+	request, err := http.NewRequest(method, broker.BrokerURL+osbPath+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	request.Header.Set(brokerclient.APIVersionHeader, brokerclient.BrokerAPIVersion(broker))
+
+	response, err := m.brokerClient.Do(request.WithContext(ctx), broker)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not reach service broker %s at %s: %s", broker.Name, request.URL, err)
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return request, &web.Response{
+		StatusCode: response.StatusCode,
+		Header:     response.Header,
+		Body:       body,
+	}, nil
+}
+
+func (m *StorageOrphanMitigator) delete(ctx context.Context, orphanMitigation *types.OrphanMitigation) error {
+	return m.repository.OrphanMitigation().Delete(ctx, query.ByField(query.EqualsOperator, "id", orphanMitigation.ID))
+}
+
+// requiresOrphanMitigation returns true if the response of the provision or bind request is one for which the OSB
+// specification requires the platform to attempt orphan mitigation. These are timeouts, server errors including the
+// ones returned when the broker cannot be reached, unexpected success statuses and 201 Created with a malformed body.
+func requiresOrphanMitigation(request *web.Request, osbPath string, response *web.Response) bool {
+	if request.Method != http.MethodPut || !(instancePathPattern.MatchString(osbPath) || bindingPathPattern.MatchString(osbPath)) {
+		return false
+	}
+	switch code := response.StatusCode; {
+	case code == http.StatusRequestTimeout || code >= http.StatusInternalServerError:
+		return true
+	case code == http.StatusCreated:
+		return !gjson.ValidBytes(response.Body) || !gjson.ParseBytes(response.Body).IsObject()
+	case code == http.StatusOK || code == http.StatusAccepted:
+		return false
+	default:
+		return code >= http.StatusOK && code < http.StatusMultipleChoices
+	}
+}
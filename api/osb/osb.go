@@ -19,6 +19,7 @@ package osb
 
 import (
 	"net/http"
+	"regexp"
 
 	"github.com/Peripli/service-manager/pkg/web"
 )
@@ -38,6 +39,14 @@ const (
 	serviceBindingAdaptCredentialsURL = baseURL + "/v2/service_instances/{instance_id}/service_bindings/{binding_id}/adapt_credentials"
 )
 
+// patterns of the OSB paths relative to the broker URL which capture the instance and binding ids
+var (
+	instancePathPattern              = regexp.MustCompile("^/v2/service_instances/([^/]+)$")
+	instanceLastOperationPathPattern = regexp.MustCompile("^/v2/service_instances/([^/]+)/last_operation$")
+	bindingPathPattern               = regexp.MustCompile("^/v2/service_instances/([^/]+)/service_bindings/([^/]+)$")
+	bindingLastOperationPathPattern  = regexp.MustCompile("^/v2/service_instances/([^/]+)/service_bindings/([^/]+)/last_operation$")
+)
+
 // Routes implements api.Controller.Routes by providing the routes for the OSB API
 func (c *controller) Routes() []web.Route {
 	return []web.Route{
@@ -21,21 +21,26 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"math"
+	"net"
 	"net/http"
-	"net/http/httptest"
-	"net/http/httputil"
 	"net/url"
 	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/Peripli/service-manager/pkg/brokerclient"
 	"github.com/Peripli/service-manager/pkg/log"
 	"github.com/Peripli/service-manager/pkg/types"
 	"github.com/Peripli/service-manager/pkg/util"
 	"github.com/Peripli/service-manager/pkg/web"
+	"github.com/Peripli/service-manager/storage"
 )
 
-var osbPathPattern = regexp.MustCompile("^" + web.OSBURL + "/[^/]+(/.*)$")
+// osbPathPattern captures the OSB path relative to the broker URL from the URLs of the per broker and the aggregated OSB APIs
+var osbPathPattern = regexp.MustCompile("^(?:" + web.OSBURL + "/[^/]+|" + web.AggregatedOSBURL + ")(/.*)$")
 
 // BrokerFetcher is implemented by OSB proxy providers
 type BrokerFetcher interface {
@@ -49,17 +54,27 @@ type CatalogFetcher interface {
 
 // controller implements api.Controller by providing OSB API logic
 type controller struct {
-	brokerFetcher  BrokerFetcher
-	catalogFetcher CatalogFetcher
+	brokerFetcher   BrokerFetcher
+	catalogFetcher  CatalogFetcher
+	brokerClient    *brokerclient.Client
+	instanceTracker InstanceTracker
+	orphanMitigator OrphanMitigator
+	repository      storage.Repository
 }
 
 var _ web.Controller = &controller{}
 
-// NewController returns new OSB controller
-func NewController(brokerFetcher BrokerFetcher, catalogFetcher CatalogFetcher, _ http.RoundTripper) web.Controller {
+// NewController returns new OSB controller. The broker client provides the authentication and the transports used
+// for proxying the requests to the service brokers. The instance tracker and the orphan mitigator are optional. The
+// requests to virtual brokers are answered using the service instances and bindings recorded in the repository.
+func NewController(brokerFetcher BrokerFetcher, catalogFetcher CatalogFetcher, brokerClient *brokerclient.Client, instanceTracker InstanceTracker, orphanMitigator OrphanMitigator, repository storage.Repository) web.Controller {
 	controller := &controller{
-		brokerFetcher:  brokerFetcher,
-		catalogFetcher: catalogFetcher,
+		brokerFetcher:   brokerFetcher,
+		catalogFetcher:  catalogFetcher,
+		brokerClient:    brokerClient,
+		instanceTracker: instanceTracker,
+		orphanMitigator: orphanMitigator,
+		repository:      repository,
 	}
 	return controller
 }
@@ -103,6 +118,16 @@ func (c *controller) handler(f func(r *web.Request, logger *logrus.Entry, id str
 
 func (c *controller) catalog(r *web.Request, logger *logrus.Entry, brokerID string) (*web.Response, error) {
 	ctx := r.Context()
+	broker, err := c.brokerFetcher.FetchBroker(ctx, brokerID)
+	if err != nil {
+		return nil, err
+	}
+	if broker.State == types.BrokerStateDisabled {
+		logger.Debugf("Broker with id %s is disabled. Hiding its catalog", brokerID)
+		return util.NewJSONResponse(http.StatusOK, &types.ServiceOfferings{
+			ServiceOfferings: []*types.ServiceOffering{},
+		})
+	}
 	if c.catalogFetcher == nil {
 		logger.Debugf("No catalog fetcher was specified. Fetching catalog for broker with id %s from service broker catalog endpoint", brokerID)
 		return c.proxy(r, logger, brokerID)
@@ -112,12 +137,6 @@ func (c *controller) catalog(r *web.Request, logger *logrus.Entry, brokerID stri
 	if err != nil {
 		return nil, err
 	}
-	if len(catalog.ServiceOfferings) == 0 {
-		logger.Debugf("Could not find any service offerings for broker with id %s. Checking if broker actually exists...", brokerID)
-		if _, err := c.brokerFetcher.FetchBroker(ctx, brokerID); err != nil {
-			return nil, err
-		}
-	}
 
 	return util.NewJSONResponse(http.StatusOK, catalog)
 }
@@ -130,60 +149,215 @@ func (c *controller) proxy(r *web.Request, logger *logrus.Entry, brokerID string
 	}
 	logger.Debugf("Fetched broker %s with id %s accessible at %s", broker.ID, broker.Name, broker.BrokerURL)
 
-	targetBrokerURL, _ := url.Parse(broker.BrokerURL)
+	if err := checkBrokerState(broker, r.Method); err != nil {
+		logger.Debugf("Rejecting %s request to broker with id %s: %s", r.Method, broker.ID, err.Description)
+		return util.NewJSONResponse(err.StatusCode, err)
+	}
 
 	m := osbPathPattern.FindStringSubmatch(r.URL.Path)
 	if m == nil || len(m) < 2 {
 		return nil, fmt.Errorf("could not get OSB path from URL %s", r.URL)
 	}
 
-	modifiedRequest := r.Request.WithContext(ctx)
-	modifiedRequest.SetBasicAuth(broker.Credentials.Basic.Username, broker.Credentials.Basic.Password)
-	modifiedRequest.Body = ioutil.NopCloser(bytes.NewReader(r.Body))
-	modifiedRequest.ContentLength = int64(len(r.Body))
-	modifiedRequest.URL.Path = m[1]
-
-	// This is needed because the request is shallow copy of the request to the Service Manager
-	// This sets the host header to point to the service broker that the request will be proxied to
-	modifiedRequest.Host = targetBrokerURL.Host
+	if broker.IsVirtual() {
+		logger.Debugf("Answering %s request to virtual broker with id %s", r.Method, broker.ID)
+		virtual := &virtualBroker{repository: c.repository, broker: broker}
+		resp, err := virtual.response(r, m[1])
+		if err != nil {
+			return nil, err
+		}
+		c.track(r, broker, m[1], resp)
+		return resp, nil
+	}
 
-	proxy := buildProxy(targetBrokerURL, logger, broker)
+	if c.instanceTracker != nil {
+		resp, err := c.instanceTracker.Fetch(ctx, r, broker, m[1])
+		if err != nil {
+			return nil, err
+		}
+		if resp != nil {
+			return resp, nil
+		}
+	}
 
-	recorder := httptest.NewRecorder()
+	targetBrokerURL, _ := url.Parse(broker.BrokerURL)
 
-	proxy.ServeHTTP(recorder, modifiedRequest)
+	if err := adaptToBrokerAPIVersion(r, broker, m[1]); err != nil {
+		logger.Debugf("Rejecting %s request to broker with id %s: %s", r.Method, broker.ID, err.Description)
+		return util.NewJSONResponse(err.StatusCode, err)
+	}
 
-	respBody, err := ioutil.ReadAll(recorder.Body)
+	brokerRequest := newBrokerRequest(r, targetBrokerURL, m[1])
+	roundTripper, err := c.brokerClient.RoundTripper(broker)
 	if err != nil {
 		return nil, err
 	}
 
-	resp := &web.Response{
-		StatusCode: recorder.Code,
-		Header:     recorder.Header(),
-		Body:       respBody,
+	logger.Debugf("Forwarding OSB request to service broker %s at %s", broker.Name, brokerRequest.URL)
+	var resp *web.Response
+	brokerResponse, err := roundTripper.RoundTrip(brokerRequest)
+	if circuitOpenErr, isCircuitOpen := err.(*brokerclient.CircuitOpenError); isCircuitOpen {
+		logger.Debugf("Rejecting %s request to broker with id %s: %s", r.Method, broker.ID, circuitOpenErr)
+		return circuitOpenResponse(circuitOpenErr)
+	}
+	if err != nil {
+		logger.WithError(err).Errorf("Error while forwarding request to service broker %s", broker.Name)
+		httpErr := &util.HTTPError{
+			ErrorType:   "ServiceBrokerErr",
+			Description: fmt.Sprintf("could not reach service broker %s at %s", broker.Name, brokerRequest.URL),
+			StatusCode:  http.StatusBadGateway,
+		}
+		if resp, err = util.NewJSONResponse(httpErr.StatusCode, httpErr); err != nil {
+			return nil, err
+		}
+	} else {
+		logger.Debugf("Service broker %s replied with status %d", broker.Name, brokerResponse.StatusCode)
+		removeHopByHopHeaders(brokerResponse.Header)
+		resp = web.NewStreamedResponse(brokerResponse.StatusCode, brokerResponse.Header, brokerResponse.Body)
+	}
+
+	if c.inspectsResponse(r, m[1]) {
+		if err := resp.Buffer(); err != nil {
+			return nil, err
+		}
+	}
+	c.track(r, broker, m[1], resp)
+	if c.orphanMitigator != nil && requiresOrphanMitigation(r, m[1], resp) {
+		logger.Warnf("%s request to %s of broker %s failed with status %d. Starting orphan mitigation", r.Method, m[1], broker.Name, resp.StatusCode)
+		if err := c.orphanMitigator.Mitigate(ctx, broker, m[1], r); err != nil {
+			logger.WithError(err).Errorf("Could not schedule orphan mitigation of %s of broker %s", m[1], broker.Name)
+		}
+	}
+	return resp, nil
+}
+
+// inspectsResponse returns true if the response of the OSB request is inspected by the instance tracker or by the
+// orphan mitigation and has to be buffered. The responses of requests which fetch resources are streamed.
+func (c *controller) inspectsResponse(r *web.Request, osbPath string) bool {
+	if c.instanceTracker == nil && c.orphanMitigator == nil {
+		return false
+	}
+	return r.Method != http.MethodGet ||
+		instanceLastOperationPathPattern.MatchString(osbPath) ||
+		bindingLastOperationPathPattern.MatchString(osbPath)
+}
+
+// track records the outcome of the OSB request using the instance tracker. Tracking failures are only logged as the
+// request has already been processed by the broker.
+func (c *controller) track(r *web.Request, broker *types.Broker, osbPath string, resp *web.Response) {
+	if c.instanceTracker == nil {
+		return
+	}
+	ctx := r.Context()
+	if err := c.instanceTracker.Track(ctx, r, broker, osbPath, resp); err != nil {
+		log.C(ctx).WithError(err).Errorf("Could not track %s request to %s of broker with id %s", r.Method, osbPath, broker.ID)
+	}
+}
+
+// checkBrokerState returns an OSB error if the broker does not accept requests with the specified method in its current
+// state. Brokers in maintenance accept only requests which do not create or modify service instances and bindings.
+func checkBrokerState(broker *types.Broker, method string) *util.HTTPError {
+	switch broker.State {
+	case types.BrokerStateDisabled:
+		return &util.HTTPError{
+			ErrorType:   "BrokerUnavailable",
+			Description: fmt.Sprintf("service broker %s is disabled", broker.Name),
+			StatusCode:  http.StatusServiceUnavailable,
+		}
+	case types.BrokerStateMaintenance:
+		if method == http.MethodGet || method == http.MethodDelete {
+			return nil
+		}
+		return &util.HTTPError{
+			ErrorType:   "BrokerUnavailable",
+			Description: fmt.Sprintf("service broker %s is in maintenance and accepts only deprovision and unbind requests", broker.Name),
+			StatusCode:  http.StatusServiceUnavailable,
+		}
 	}
+	return nil
+}
+
+// circuitOpenResponse returns the response to requests which were not sent because the circuit breaker of the broker
+// is open. The Retry-After header tells the platform when the broker will be tried again.
+func circuitOpenResponse(err *brokerclient.CircuitOpenError) (*web.Response, error) {
+	resp, respErr := util.NewJSONResponse(http.StatusServiceUnavailable, &util.HTTPError{
+		ErrorType:   "BrokerUnavailable",
+		Description: fmt.Sprintf("service broker %s is temporarily unavailable as its recent requests failed", err.BrokerName),
+		StatusCode:  http.StatusServiceUnavailable,
+	})
+	if respErr != nil {
+		return nil, respErr
+	}
+	retryAfter := int64(math.Ceil(err.RetryAfter.Seconds()))
+	resp.Header.Set("Retry-After", strconv.FormatInt(retryAfter, 10))
 	return resp, nil
 }
 
-func buildProxy(targetBrokerURL *url.URL, logger *logrus.Entry, broker *types.Broker) *httputil.ReverseProxy {
-	proxy := httputil.NewSingleHostReverseProxy(targetBrokerURL)
-	director := proxy.Director
-	proxy.Director = func(request *http.Request) {
-		director(request)
-		logger.Debugf("Forwarded OSB request to service broker %s at %s", broker.Name, request.URL)
+// hopByHopHeaders are the headers which apply only to a single connection and are not forwarded by proxies
+var hopByHopHeaders = []string{
+	"Connection",
+	"Proxy-Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// newBrokerRequest creates the request which forwards the OSB request to the broker at the specified OSB path
+func newBrokerRequest(r *web.Request, targetBrokerURL *url.URL, osbPath string) *http.Request {
+	brokerRequest := r.Request.WithContext(r.Context())
+	brokerRequest.Body = ioutil.NopCloser(bytes.NewReader(r.Body))
+	brokerRequest.ContentLength = int64(len(r.Body))
+	brokerRequest.RequestURI = ""
+	brokerRequest.Close = false
+
+	brokerURL := *r.URL
+	brokerURL.Scheme = targetBrokerURL.Scheme
+	brokerURL.Host = targetBrokerURL.Host
+	brokerURL.Path = strings.TrimSuffix(targetBrokerURL.Path, "/") + osbPath
+	brokerURL.RawPath = ""
+	if targetBrokerURL.RawQuery != "" {
+		if brokerURL.RawQuery == "" {
+			brokerURL.RawQuery = targetBrokerURL.RawQuery
+		} else {
+			brokerURL.RawQuery = targetBrokerURL.RawQuery + "&" + brokerURL.RawQuery
+		}
 	}
-	proxy.ModifyResponse = func(response *http.Response) error {
-		logger.Debugf("Service broker %s replied with status %d", broker.Name, response.StatusCode)
-		return nil
+	brokerRequest.URL = &brokerURL
+	// This sets the host header to point to the service broker that the request will be proxied to
+	brokerRequest.Host = targetBrokerURL.Host
+
+	brokerRequest.Header = make(http.Header, len(r.Header))
+	for key, values := range r.Header {
+		brokerRequest.Header[key] = append([]string(nil), values...)
 	}
-	proxy.ErrorHandler = func(writer http.ResponseWriter, request *http.Request, e error) {
-		logger.WithError(e).Errorf("Error while forwarding request to service broker %s", broker.Name)
-		util.WriteError(&util.HTTPError{
-			ErrorType:   "ServiceBrokerErr",
-			Description: fmt.Sprintf("could not reach service broker %s at %s", broker.Name, request.URL),
-			StatusCode:  http.StatusBadGateway,
-		}, writer)
+	removeHopByHopHeaders(brokerRequest.Header)
+	if _, ok := brokerRequest.Header["User-Agent"]; !ok {
+		// prevents the default user agent of the Go HTTP client from being sent
+		brokerRequest.Header.Set("User-Agent", "")
+	}
+	if clientIP, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		if forwardedFor := brokerRequest.Header.Get("X-Forwarded-For"); forwardedFor != "" {
+			clientIP = forwardedFor + ", " + clientIP
+		}
+		brokerRequest.Header.Set("X-Forwarded-For", clientIP)
+	}
+	return brokerRequest
+}
+
+// removeHopByHopHeaders removes the hop-by-hop headers including the ones listed in the Connection header
+func removeHopByHopHeaders(header http.Header) {
+	for _, connectionHeader := range header["Connection"] {
+		for _, name := range strings.Split(connectionHeader, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				header.Del(name)
+			}
+		}
+	}
+	for _, name := range hopByHopHeaders {
+		header.Del(name)
 	}
-	return proxy
 }
@@ -0,0 +1,149 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package osb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
+	"github.com/Peripli/service-manager/pkg/web"
+	"github.com/Peripli/service-manager/storage"
+)
+
+// ParametersValidationFilter rejects the provision, update and bind requests whose parameters do not conform to the
+// JSON schemas of the requested plan. Brokers can disable the validation of the requests for their plans.
+type ParametersValidationFilter struct {
+	Repository storage.Repository
+}
+
+func (pvf *ParametersValidationFilter) Name() string {
+	return "ParametersValidationFilter"
+}
+
+func (pvf *ParametersValidationFilter) Run(req *web.Request, next web.Handler) (*web.Response, error) {
+	ctx := req.Context()
+	parameters := gjson.GetBytes(req.Body, "parameters")
+	if !parameters.Exists() {
+		return next.Handle(req)
+	}
+
+	servicePlan, brokerID, err := pvf.servicePlan(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if servicePlan == nil || len(servicePlan.Schemas) == 0 {
+		return next.Handle(req)
+	}
+	broker, err := pvf.Repository.Broker().Get(ctx, brokerID)
+	if err != nil {
+		return nil, util.HandleStorageError(err, "broker")
+	}
+	if broker.ParametersValidation == types.ParametersValidationDisabled {
+		return next.Handle(req)
+	}
+
+	schemaPath := parametersSchemaPath(req)
+	schema := gjson.GetBytes(servicePlan.Schemas, schemaPath)
+	if !schema.IsObject() {
+		return next.Handle(req)
+	}
+	result, err := gojsonschema.Validate(gojsonschema.NewStringLoader(schema.Raw), gojsonschema.NewStringLoader(parameters.Raw))
+	if err != nil {
+		// invalid schemas are rejected by the catalog validation unless it is lenient
+		log.C(ctx).WithError(err).Warnf("Could not validate parameters against schema %s of plan with id %s", schemaPath, servicePlan.ID)
+		return next.Handle(req)
+	}
+	if !result.Valid() {
+		violations := make([]string, 0, len(result.Errors()))
+		for _, violation := range result.Errors() {
+			violations = append(violations, violation.String())
+		}
+		return nil, &util.HTTPError{
+			ErrorType:   "BadRequest",
+			Description: fmt.Sprintf("parameters do not conform to schema %s of plan with catalog id %s: %s", schemaPath, servicePlan.CatalogID, strings.Join(violations, "; ")),
+			StatusCode:  http.StatusBadRequest,
+		}
+	}
+	return next.Handle(req)
+}
+
+// servicePlan returns the plan referenced by the request and the id of its broker or nil if the plan is not known.
+// Updates which do not change the plan are validated against the current plan of the tracked service instance.
+func (pvf *ParametersValidationFilter) servicePlan(ctx context.Context, req *web.Request) (*types.ServicePlan, string, error) {
+	serviceID := gjson.GetBytes(req.Body, "service_id").String()
+	planID := gjson.GetBytes(req.Body, "plan_id").String()
+	if planID == "" {
+		instance, err := pvf.Repository.ServiceInstance().Get(ctx, req.PathParams["instance_id"])
+		if err == util.ErrNotFoundInStorage {
+			return nil, "", nil
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		servicePlan, err := pvf.Repository.ServicePlan().Get(ctx, instance.ServicePlanID)
+		if err == util.ErrNotFoundInStorage {
+			return nil, "", nil
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		return servicePlan, instance.BrokerID, nil
+	}
+
+	brokerID, err := requestBrokerID(pvf.Repository, req)
+	if err != nil {
+		return nil, "", err
+	}
+	servicePlan, err := findServicePlan(ctx, pvf.Repository, brokerID, serviceID, planID)
+	return servicePlan, brokerID, err
+}
+
+// parametersSchemaPath returns the path of the parameters schema for the request in the schemas of a plan
+func parametersSchemaPath(req *web.Request) string {
+	if _, isBindingRequest := req.PathParams["binding_id"]; isBindingRequest {
+		return "service_binding.create.parameters"
+	}
+	if req.Method == http.MethodPatch {
+		return "service_instance.update.parameters"
+	}
+	return "service_instance.create.parameters"
+}
+
+func (pvf *ParametersValidationFilter) FilterMatchers() []web.FilterMatcher {
+	return []web.FilterMatcher{
+		{
+			Matchers: []web.Matcher{
+				web.Path(web.OSBURL+"/*/v2/service_instances/*", web.AggregatedOSBURL+"/v2/service_instances/*"),
+				web.Methods(http.MethodPut, http.MethodPatch),
+			},
+		},
+		{
+			Matchers: []web.Matcher{
+				web.Path(web.OSBURL+"/*/v2/service_instances/*/service_bindings/*", web.AggregatedOSBURL+"/v2/service_instances/*/service_bindings/*"),
+				web.Methods(http.MethodPut),
+			},
+		},
+	}
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package osb
+
+import (
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
+	"github.com/Peripli/service-manager/pkg/web"
+	"github.com/Peripli/service-manager/storage"
+)
+
+// platformContextKey is the key of the context object under which the Service Manager platform is described
+const platformContextKey = "context.service_manager"
+
+// PlatformContextPlugin adds the id and the type of the Service Manager platform which sent the provision, update and
+// bind requests to their context, so that brokers know that the requests came through Service Manager. Brokers have
+// to enable the enrichment of the context of their requests.
+type PlatformContextPlugin struct {
+	Repository storage.Repository
+}
+
+var (
+	_ web.Provisioner    = &PlatformContextPlugin{}
+	_ web.ServiceUpdater = &PlatformContextPlugin{}
+	_ web.Binder         = &PlatformContextPlugin{}
+)
+
+func (p *PlatformContextPlugin) Name() string {
+	return "PlatformContextPlugin"
+}
+
+func (p *PlatformContextPlugin) Provision(req *web.Request, next web.Handler) (*web.Response, error) {
+	return p.addPlatformContext(req, next)
+}
+
+func (p *PlatformContextPlugin) UpdateService(req *web.Request, next web.Handler) (*web.Response, error) {
+	return p.addPlatformContext(req, next)
+}
+
+func (p *PlatformContextPlugin) Bind(req *web.Request, next web.Handler) (*web.Response, error) {
+	return p.addPlatformContext(req, next)
+}
+
+func (p *PlatformContextPlugin) addPlatformContext(req *web.Request, next web.Handler) (*web.Response, error) {
+	ctx := req.Context()
+	platform := types.PlatformFromContext(ctx)
+	if platform == nil || !gjson.ValidBytes(req.Body) {
+		return next.Handle(req)
+	}
+	// requests to the aggregated OSB API are routed to a broker after the plugins are run
+	brokerID, err := requestBrokerID(p.Repository, req)
+	if err != nil {
+		return nil, err
+	}
+	broker, err := p.Repository.Broker().Get(ctx, brokerID)
+	if err != nil {
+		return nil, util.HandleStorageError(err, "broker")
+	}
+	if broker.PlatformContext != types.PlatformContextEnabled {
+		return next.Handle(req)
+	}
+
+	body, err := sjson.SetBytes(req.Body, platformContextKey, map[string]string{
+		"platform_id":   platform.ID,
+		"platform_type": platform.Type,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req.Body = body
+	return next.Handle(req)
+}
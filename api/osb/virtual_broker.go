@@ -0,0 +1,257 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package osb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
+	"github.com/Peripli/service-manager/pkg/web"
+	"github.com/Peripli/service-manager/storage"
+)
+
+type virtualBrokerOperation struct {
+	State string `json:"state"`
+}
+
+type virtualBrokerBinding struct {
+	Credentials json.RawMessage `json:"credentials,omitempty"`
+}
+
+// virtualBroker answers the OSB requests to brokers which are defined entirely inside Service Manager. All
+// operations complete synchronously and bindings receive the static credentials configured for the broker. The
+// service instances and bindings of virtual brokers are the ones recorded by the instance tracker.
+type virtualBroker struct {
+	repository storage.Repository
+	broker     *types.Broker
+}
+
+// response answers the OSB request with the specified path relative to the broker URL
+func (vb *virtualBroker) response(r *web.Request, osbPath string) (*web.Response, error) {
+	ctx := r.Context()
+	if m := instancePathPattern.FindStringSubmatch(osbPath); m != nil {
+		return vb.serviceInstanceResponse(ctx, r, m[1])
+	}
+	if m := instanceLastOperationPathPattern.FindStringSubmatch(osbPath); m != nil {
+		instance, _, err := vb.serviceInstance(ctx, m[1])
+		return vb.lastOperationResponse(instance != nil, err)
+	}
+	if m := bindingPathPattern.FindStringSubmatch(osbPath); m != nil {
+		return vb.serviceBindingResponse(ctx, r, m[1], m[2])
+	}
+	if m := bindingLastOperationPathPattern.FindStringSubmatch(osbPath); m != nil {
+		binding, _, err := vb.serviceBinding(ctx, m[1], m[2])
+		return vb.lastOperationResponse(binding != nil, err)
+	}
+	if strings.HasSuffix(osbPath, "/adapt_credentials") {
+		return util.NewJSONResponse(http.StatusOK, &virtualBrokerBinding{Credentials: vb.broker.BindingCredentials})
+	}
+	return vb.errorResponse(http.StatusNotFound, "NotFound", "%s is not supported by virtual brokers", osbPath)
+}
+
+func (vb *virtualBroker) serviceInstanceResponse(ctx context.Context, r *web.Request, instanceID string) (*web.Response, error) {
+	instance, taken, err := vb.serviceInstance(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		if r.Method == http.MethodPut {
+			return vb.errorResponse(http.StatusConflict, "Conflict", "service instance with id %s already exists", instanceID)
+		}
+		return vb.errorResponse(http.StatusNotFound, "NotFound", "service instance with id %s does not exist", instanceID)
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		servicePlan, resp, err := vb.servicePlan(ctx, r.Body)
+		if servicePlan == nil {
+			return resp, err
+		}
+		if instance == nil {
+			return util.NewJSONResponse(http.StatusCreated, map[string]string{})
+		}
+		if instance.ServicePlanID != servicePlan.ID {
+			return vb.errorResponse(http.StatusConflict, "Conflict", "service instance with id %s already exists with another plan", instanceID)
+		}
+		return util.NewJSONResponse(http.StatusOK, map[string]string{})
+	case http.MethodPatch:
+		if instance == nil {
+			return vb.errorResponse(http.StatusNotFound, "NotFound", "service instance with id %s does not exist", instanceID)
+		}
+		if gjson.GetBytes(r.Body, "plan_id").Exists() {
+			if servicePlan, resp, err := vb.servicePlan(ctx, r.Body); servicePlan == nil {
+				return resp, err
+			}
+		}
+		return util.NewJSONResponse(http.StatusOK, map[string]string{})
+	case http.MethodDelete:
+		if instance == nil {
+			return util.NewJSONResponse(http.StatusGone, map[string]string{})
+		}
+		return util.NewJSONResponse(http.StatusOK, map[string]string{})
+	default:
+		if instance == nil {
+			return util.NewJSONResponse(http.StatusNotFound, map[string]string{})
+		}
+		servicePlan, serviceOffering, err := vb.servicePlanWithOffering(ctx, instance.ServicePlanID)
+		if err == util.ErrNotFoundInStorage {
+			return vb.errorResponse(http.StatusNotFound, "NotFound", "plan of service instance with id %s is no longer offered", instanceID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return util.NewJSONResponse(http.StatusOK, &serviceInstanceResponse{
+			ServiceID:  serviceOffering.CatalogID,
+			PlanID:     servicePlan.CatalogID,
+			Parameters: instance.Parameters,
+		})
+	}
+}
+
+func (vb *virtualBroker) serviceBindingResponse(ctx context.Context, r *web.Request, instanceID, bindingID string) (*web.Response, error) {
+	binding, taken, err := vb.serviceBinding(ctx, instanceID, bindingID)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		if r.Method == http.MethodPut {
+			return vb.errorResponse(http.StatusConflict, "Conflict", "service binding with id %s already exists", bindingID)
+		}
+		return vb.errorResponse(http.StatusNotFound, "NotFound", "service binding with id %s does not exist", bindingID)
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		instance, _, err := vb.serviceInstance(ctx, instanceID)
+		if err != nil {
+			return nil, err
+		}
+		if instance == nil {
+			return vb.errorResponse(http.StatusNotFound, "NotFound", "service instance with id %s does not exist", instanceID)
+		}
+		if servicePlan, resp, err := vb.servicePlan(ctx, r.Body); servicePlan == nil {
+			return resp, err
+		}
+		status := http.StatusCreated
+		if binding != nil {
+			status = http.StatusOK
+		}
+		return util.NewJSONResponse(status, &virtualBrokerBinding{Credentials: vb.broker.BindingCredentials})
+	case http.MethodDelete:
+		if binding == nil {
+			return util.NewJSONResponse(http.StatusGone, map[string]string{})
+		}
+		return util.NewJSONResponse(http.StatusOK, map[string]string{})
+	default:
+		if binding == nil {
+			return util.NewJSONResponse(http.StatusNotFound, map[string]string{})
+		}
+		return util.NewJSONResponse(http.StatusOK, &serviceBindingResponse{
+			Credentials: vb.broker.BindingCredentials,
+			Parameters:  binding.Parameters,
+		})
+	}
+}
+
+// lastOperationResponse reports the operations of recorded resources as succeeded and the ones of resources which
+// do not exist as gone since virtual brokers delete resources synchronously
+func (vb *virtualBroker) lastOperationResponse(exists bool, err error) (*web.Response, error) {
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return util.NewJSONResponse(http.StatusGone, map[string]string{})
+	}
+	return util.NewJSONResponse(http.StatusOK, &virtualBrokerOperation{State: types.OperationStateSucceeded})
+}
+
+// serviceInstance returns the recorded service instance of the virtual broker provisioned by the calling platform or
+// nil if there is no such instance. Taken reports whether the id belongs to a service instance of another broker or
+// platform.
+func (vb *virtualBroker) serviceInstance(ctx context.Context, instanceID string) (instance *types.ServiceInstance, taken bool, err error) {
+	instance, err = vb.repository.ServiceInstance().Get(ctx, instanceID)
+	if err == util.ErrNotFoundInStorage {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if instance.BrokerID != vb.broker.ID || instance.PlatformID != types.PlatformIDFromContext(ctx) {
+		return nil, true, nil
+	}
+	return instance, false, nil
+}
+
+// serviceBinding returns the recorded service binding of the service instance created by the calling platform or nil
+// if there is no such binding. Taken reports whether the id belongs to a service binding of another service
+// instance, broker or platform.
+func (vb *virtualBroker) serviceBinding(ctx context.Context, instanceID, bindingID string) (binding *types.ServiceBinding, taken bool, err error) {
+	binding, err = vb.repository.ServiceBinding().Get(ctx, bindingID)
+	if err == util.ErrNotFoundInStorage {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if binding.ServiceInstanceID != instanceID || binding.BrokerID != vb.broker.ID || binding.PlatformID != types.PlatformIDFromContext(ctx) {
+		return nil, true, nil
+	}
+	return binding, false, nil
+}
+
+// servicePlan returns the plan of the virtual catalog referenced by the catalog ids in the request body. If there is
+// no such plan, the returned plan is nil and the returned response rejects the request.
+func (vb *virtualBroker) servicePlan(ctx context.Context, body []byte) (*types.ServicePlan, *web.Response, error) {
+	serviceID := gjson.GetBytes(body, "service_id").String()
+	planID := gjson.GetBytes(body, "plan_id").String()
+	servicePlan, err := findServicePlan(ctx, vb.repository, vb.broker.ID, serviceID, planID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if servicePlan == nil {
+		resp, err := vb.errorResponse(http.StatusBadRequest, "BadRequest", "plan with catalog id %s of service with catalog id %s not found in the catalog of broker %s", planID, serviceID, vb.broker.Name)
+		return nil, resp, err
+	}
+	return servicePlan, nil, nil
+}
+
+func (vb *virtualBroker) servicePlanWithOffering(ctx context.Context, servicePlanID string) (*types.ServicePlan, *types.ServiceOffering, error) {
+	servicePlan, err := vb.repository.ServicePlan().Get(ctx, servicePlanID)
+	if err != nil {
+		return nil, nil, err
+	}
+	serviceOffering, err := vb.repository.ServiceOffering().Get(ctx, servicePlan.ServiceOfferingID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return servicePlan, serviceOffering, nil
+}
+
+func (vb *virtualBroker) errorResponse(statusCode int, errorType, format string, args ...interface{}) (*web.Response, error) {
+	return util.NewJSONResponse(statusCode, &util.HTTPError{
+		ErrorType:   errorType,
+		Description: fmt.Sprintf(format, args...),
+		StatusCode:  statusCode,
+	})
+}
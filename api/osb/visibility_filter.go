@@ -0,0 +1,94 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package osb
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/pkg/query"
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
+	"github.com/Peripli/service-manager/pkg/web"
+	"github.com/Peripli/service-manager/storage"
+)
+
+// VisibilityFilter rejects the provision and update requests of platforms for plans which are not visible to them.
+// A plan is visible to a platform if there is a visibility for the platform or a public visibility for the plan.
+type VisibilityFilter struct {
+	Repository storage.Repository
+}
+
+func (vf *VisibilityFilter) Name() string {
+	return "VisibilityFilter"
+}
+
+func (vf *VisibilityFilter) Run(req *web.Request, next web.Handler) (*web.Response, error) {
+	ctx := req.Context()
+	platform := types.PlatformIDFromContext(ctx)
+	if platform == "" {
+		return next.Handle(req)
+	}
+	serviceID := gjson.GetBytes(req.Body, "service_id").String()
+	planID := gjson.GetBytes(req.Body, "plan_id").String()
+	if planID == "" && req.Method == http.MethodPatch {
+		// the update does not change the plan of the service instance
+		return next.Handle(req)
+	}
+
+	brokerID, err := requestBrokerID(vf.Repository, req)
+	if err != nil {
+		return nil, err
+	}
+	servicePlan, err := findServicePlan(ctx, vf.Repository, brokerID, serviceID, planID)
+	if err != nil {
+		return nil, err
+	}
+	if servicePlan != nil {
+		byServicePlanID := query.ByField(query.EqualsOperator, "service_plan_id", servicePlan.ID)
+		visibilities, err := vf.Repository.Visibility().List(ctx, byServicePlanID)
+		if err != nil {
+			return nil, err
+		}
+		for _, visibility := range visibilities {
+			if visibility.PlatformID == "" || visibility.PlatformID == platform {
+				return next.Handle(req)
+			}
+		}
+	}
+
+	log.C(ctx).Debugf("Plan with catalog id %s of service with catalog id %s of broker with id %s is not visible to platform with id %s", planID, serviceID, brokerID, platform)
+	return nil, &util.HTTPError{
+		ErrorType:   "Forbidden",
+		Description: fmt.Sprintf("plan with catalog id %s of service with catalog id %s is not visible to the platform", planID, serviceID),
+		StatusCode:  http.StatusForbidden,
+	}
+}
+
+func (vf *VisibilityFilter) FilterMatchers() []web.FilterMatcher {
+	return []web.FilterMatcher{
+		{
+			Matchers: []web.Matcher{
+				web.Path(web.OSBURL+"/*/v2/service_instances/*", web.AggregatedOSBURL+"/v2/service_instances/*"),
+				web.Methods(http.MethodPut, http.MethodPatch),
+			},
+		},
+	}
+}
@@ -0,0 +1,73 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package service_binding
+
+import (
+	"net/http"
+
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/pkg/query"
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
+	"github.com/Peripli/service-manager/pkg/web"
+	"github.com/Peripli/service-manager/storage"
+)
+
+const reqServiceBindingID = "service_binding_id"
+
+// Controller implements api.Controller by providing service bindings API logic
+type Controller struct {
+	ServiceBindingStorage storage.ServiceBinding
+}
+
+func (c *Controller) getServiceBinding(r *web.Request) (*web.Response, error) {
+	serviceBindingID := r.PathParams[reqServiceBindingID]
+	ctx := r.Context()
+	log.C(ctx).Debugf("Getting service binding with id %s", serviceBindingID)
+
+	serviceBinding, err := c.ServiceBindingStorage.Get(ctx, serviceBindingID)
+	if err = util.HandleStorageError(err, "service_binding"); err != nil {
+		return nil, err
+	}
+	platformID := types.PlatformIDFromContext(ctx)
+	if platformID != "" && serviceBinding.PlatformID != platformID {
+		// platforms are not aware of the service bindings of other platforms
+		return nil, util.HandleStorageError(util.ErrNotFoundInStorage, "service_binding")
+	}
+	return util.NewJSONResponse(http.StatusOK, serviceBinding)
+}
+
+func (c *Controller) listServiceBindings(r *web.Request) (*web.Response, error) {
+	ctx := r.Context()
+	log.C(ctx).Debug("Listing service bindings")
+
+	platformID := types.PlatformIDFromContext(ctx)
+	if platformID != "" {
+		byPlatformID := query.ByField(query.EqualsOperator, "platform_id", platformID)
+		var err error
+		if ctx, err = query.AddCriteria(ctx, byPlatformID); err != nil {
+			return nil, util.HandleSelectionError(err)
+		}
+	}
+	serviceBindings, err := c.ServiceBindingStorage.List(ctx, query.CriteriaForContext(ctx)...)
+	if err != nil {
+		return nil, util.HandleSelectionError(err)
+	}
+	return util.NewJSONResponse(http.StatusOK, &types.ServiceBindings{
+		ServiceBindings: serviceBindings,
+	})
+}
@@ -0,0 +1,73 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package service_instance
+
+import (
+	"net/http"
+
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/pkg/query"
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
+	"github.com/Peripli/service-manager/pkg/web"
+	"github.com/Peripli/service-manager/storage"
+)
+
+const reqServiceInstanceID = "service_instance_id"
+
+// Controller implements api.Controller by providing service instances API logic
+type Controller struct {
+	ServiceInstanceStorage storage.ServiceInstance
+}
+
+func (c *Controller) getServiceInstance(r *web.Request) (*web.Response, error) {
+	serviceInstanceID := r.PathParams[reqServiceInstanceID]
+	ctx := r.Context()
+	log.C(ctx).Debugf("Getting service instance with id %s", serviceInstanceID)
+
+	serviceInstance, err := c.ServiceInstanceStorage.Get(ctx, serviceInstanceID)
+	if err = util.HandleStorageError(err, "service_instance"); err != nil {
+		return nil, err
+	}
+	platformID := types.PlatformIDFromContext(ctx)
+	if platformID != "" && serviceInstance.PlatformID != platformID {
+		// platforms are not aware of the service instances of other platforms
+		return nil, util.HandleStorageError(util.ErrNotFoundInStorage, "service_instance")
+	}
+	return util.NewJSONResponse(http.StatusOK, serviceInstance)
+}
+
+func (c *Controller) listServiceInstances(r *web.Request) (*web.Response, error) {
+	ctx := r.Context()
+	log.C(ctx).Debug("Listing service instances")
+
+	platformID := types.PlatformIDFromContext(ctx)
+	if platformID != "" {
+		byPlatformID := query.ByField(query.EqualsOperator, "platform_id", platformID)
+		var err error
+		if ctx, err = query.AddCriteria(ctx, byPlatformID); err != nil {
+			return nil, util.HandleSelectionError(err)
+		}
+	}
+	serviceInstances, err := c.ServiceInstanceStorage.List(ctx, query.CriteriaForContext(ctx)...)
+	if err != nil {
+		return nil, util.HandleSelectionError(err)
+	}
+	return util.NewJSONResponse(http.StatusOK, &types.ServiceInstances{
+		ServiceInstances: serviceInstances,
+	})
+}
@@ -34,6 +34,7 @@ const reqServiceOfferingID = "service_offering_id"
 // Controller implements api.Controller by providing service offerings API logic
 type Controller struct {
 	ServiceOfferingStorage storage.ServiceOffering
+	CatalogOverrideStorage storage.CatalogOverride
 }
 
 func (c *Controller) getServiceOffering(r *web.Request) (*web.Response, error) {
@@ -45,6 +46,15 @@ func (c *Controller) getServiceOffering(r *web.Request) (*web.Response, error) {
 	if err = util.HandleStorageError(err, "service_offering"); err != nil {
 		return nil, err
 	}
+	byServiceOfferingID := query.ByField(query.EqualsOperator, "service_offering_id", serviceOfferingID)
+	overrides, err := c.CatalogOverrideStorage.List(ctx, byServiceOfferingID)
+	if err != nil {
+		return nil, err
+	}
+	catalogOverrides := &types.CatalogOverrides{CatalogOverrides: overrides}
+	if _, err := catalogOverrides.ApplyToServiceOfferings([]*types.ServiceOffering{serviceOffering}, false); err != nil {
+		return nil, err
+	}
 	return util.NewJSONResponse(http.StatusOK, serviceOffering)
 }
 
@@ -58,6 +68,14 @@ func (c *Controller) listServiceOfferings(r *web.Request) (*web.Response, error)
 	if err != nil {
 		return nil, util.HandleSelectionError(err)
 	}
+	overrides, err := c.CatalogOverrideStorage.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	catalogOverrides := &types.CatalogOverrides{CatalogOverrides: overrides}
+	if serviceOfferings, err = catalogOverrides.ApplyToServiceOfferings(serviceOfferings, false); err != nil {
+		return nil, err
+	}
 
 	return util.NewJSONResponse(http.StatusOK, struct {
 		ServiceOfferings []*types.ServiceOffering `json:"service_offerings"`
@@ -31,7 +31,8 @@ const reqServicePlanID = "service_plan_id"
 
 // Controller implements api.Controller by providing service plans API logic
 type Controller struct {
-	ServicePlanStorage storage.ServicePlan
+	ServicePlanStorage     storage.ServicePlan
+	CatalogOverrideStorage storage.CatalogOverride
 }
 
 func (c *Controller) getServicePlan(r *web.Request) (*web.Response, error) {
@@ -43,6 +44,15 @@ func (c *Controller) getServicePlan(r *web.Request) (*web.Response, error) {
 	if err = util.HandleStorageError(err, "service_plan"); err != nil {
 		return nil, err
 	}
+	byServicePlanID := query.ByField(query.EqualsOperator, "service_plan_id", servicePlanID)
+	overrides, err := c.CatalogOverrideStorage.List(ctx, byServicePlanID)
+	if err != nil {
+		return nil, err
+	}
+	catalogOverrides := &types.CatalogOverrides{CatalogOverrides: overrides}
+	if err := catalogOverrides.ApplyToServicePlans([]*types.ServicePlan{servicePlan}); err != nil {
+		return nil, err
+	}
 	return util.NewJSONResponse(http.StatusOK, servicePlan)
 }
 
@@ -56,6 +66,14 @@ func (c *Controller) ListServicePlans(r *web.Request) (*web.Response, error) {
 	if err != nil {
 		return nil, util.HandleSelectionError(err)
 	}
+	overrides, err := c.CatalogOverrideStorage.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	catalogOverrides := &types.CatalogOverrides{CatalogOverrides: overrides}
+	if err := catalogOverrides.ApplyToServicePlans(servicePlans); err != nil {
+		return nil, err
+	}
 
 	return util.NewJSONResponse(http.StatusOK, &types.ServicePlans{
 		ServicePlans: servicePlans,
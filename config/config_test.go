@@ -19,6 +19,7 @@ package config_test
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/Peripli/service-manager/api"
 	cfg "github.com/Peripli/service-manager/config"
@@ -119,6 +120,41 @@ var _ = Describe("config", func() {
 				assertErrorDuringValidate()
 			})
 		})
+
+		Context("when API broker health interval is negative", func() {
+			It("returns an error", func() {
+				config.API.BrokerHealthInterval = -time.Second
+				assertErrorDuringValidate()
+			})
+		})
+
+		Context("when API operation polling interval is negative", func() {
+			It("returns an error", func() {
+				config.API.OperationPollingInterval = -time.Second
+				assertErrorDuringValidate()
+			})
+		})
+
+		Context("when API maximum polling duration is negative", func() {
+			It("returns an error", func() {
+				config.API.MaximumPollingDuration = -time.Second
+				assertErrorDuringValidate()
+			})
+		})
+
+		Context("when API orphan mitigation max attempts is negative", func() {
+			It("returns an error", func() {
+				config.API.OrphanMitigationMaxAttempts = -1
+				assertErrorDuringValidate()
+			})
+		})
+
+		Context("when API orphan mitigation backoff is not positive", func() {
+			It("returns an error", func() {
+				config.API.OrphanMitigationBackoff = 0
+				assertErrorDuringValidate()
+			})
+		})
 	})
 
 	Describe("New", func() {
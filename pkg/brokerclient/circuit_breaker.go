@@ -0,0 +1,146 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package brokerclient
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/types"
+)
+
+const (
+	// CircuitBreakerClosed is the state of circuit breakers which let all requests towards the broker through
+	CircuitBreakerClosed = "closed"
+
+	// CircuitBreakerOpen is the state of circuit breakers which reject all requests towards the broker
+	CircuitBreakerOpen = "open"
+
+	// CircuitBreakerHalfOpen is the state of circuit breakers which let a single trial request towards the broker
+	// through to decide whether the broker has recovered
+	CircuitBreakerHalfOpen = "half_open"
+)
+
+// CircuitOpenError is returned for requests which are not sent because the circuit breaker of the broker is open
+type CircuitOpenError struct {
+	BrokerName string
+	// RetryAfter is the time after which a trial request towards the broker will be let through
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker of broker %s is open", e.BrokerName)
+}
+
+// circuitBreaker counts the consecutive failed requests towards a broker. After the threshold of the broker is
+// reached, the requests are rejected until the timeout of the broker passes. Then a single trial request is let
+// through which either closes the circuit breaker or opens it again.
+type circuitBreaker struct {
+	mutex               sync.Mutex
+	consecutiveFailures int
+	openedAt            time.Time
+	trialInProgress     bool
+}
+
+func (cb *circuitBreaker) allow(broker *types.Broker, now time.Time) error {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	resilience := broker.Resilience
+	if cb.consecutiveFailures < resilience.CircuitBreakerThreshold {
+		return nil
+	}
+	closesAt := cb.openedAt.Add(resilience.CircuitBreakerTimeoutDuration())
+	if now.Before(closesAt) {
+		return &CircuitOpenError{BrokerName: broker.Name, RetryAfter: closesAt.Sub(now)}
+	}
+	if cb.trialInProgress {
+		return &CircuitOpenError{BrokerName: broker.Name, RetryAfter: resilience.CircuitBreakerTimeoutDuration()}
+	}
+	cb.trialInProgress = true
+	return nil
+}
+
+func (cb *circuitBreaker) record(broker *types.Broker, failed bool, now time.Time) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	cb.trialInProgress = false
+	if !failed {
+		cb.consecutiveFailures = 0
+		return
+	}
+	cb.consecutiveFailures++
+	if cb.consecutiveFailures >= broker.Resilience.CircuitBreakerThreshold {
+		cb.openedAt = now
+	}
+}
+
+// release lets another trial request through if the request whose outcome is not relevant was a trial request
+func (cb *circuitBreaker) release() {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	cb.trialInProgress = false
+}
+
+func (cb *circuitBreaker) state(broker *types.Broker, now time.Time) string {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	if cb.consecutiveFailures < broker.Resilience.CircuitBreakerThreshold {
+		return CircuitBreakerClosed
+	}
+	if now.Before(cb.openedAt.Add(broker.Resilience.CircuitBreakerTimeoutDuration())) {
+		return CircuitBreakerOpen
+	}
+	return CircuitBreakerHalfOpen
+}
+
+type circuitBreakers struct {
+	mutex    sync.Mutex
+	breakers map[string]*circuitBreaker
+}
+
+// get returns the circuit breaker of the broker or nil if the broker has no circuit breaker configured
+func (cbs *circuitBreakers) get(broker *types.Broker) *circuitBreaker {
+	if !circuitBreakerEnabled(broker) {
+		return nil
+	}
+
+	cbs.mutex.Lock()
+	defer cbs.mutex.Unlock()
+
+	breaker, found := cbs.breakers[broker.ID]
+	if !found {
+		breaker = &circuitBreaker{}
+		cbs.breakers[broker.ID] = breaker
+	}
+	return breaker
+}
+
+func (cbs *circuitBreakers) evict(brokerID string) {
+	cbs.mutex.Lock()
+	defer cbs.mutex.Unlock()
+
+	delete(cbs.breakers, brokerID)
+}
+
+func circuitBreakerEnabled(broker *types.Broker) bool {
+	return broker.Resilience != nil && broker.Resilience.CircuitBreakerThreshold > 0
+}
@@ -0,0 +1,198 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package brokerclient_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/brokerclient"
+	"github.com/Peripli/service-manager/pkg/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Broker resilience", func() {
+	var (
+		server   *httptest.Server
+		client   *brokerclient.Client
+		broker   *types.Broker
+		statuses []int
+		requests int
+	)
+
+	send := func(method string) (*http.Response, error) {
+		request, err := http.NewRequest(method, server.URL+"/v2/service_instances/id", nil)
+		Expect(err).ToNot(HaveOccurred())
+		roundTripper, err := client.RoundTripper(broker)
+		Expect(err).ToNot(HaveOccurred())
+		response, err := roundTripper.RoundTrip(request)
+		if err == nil {
+			response.Body.Close()
+		}
+		return response, err
+	}
+
+	BeforeEach(func() {
+		statuses = nil
+		requests = 0
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			status := http.StatusOK
+			if len(statuses) > 0 {
+				status, statuses = statuses[0], statuses[1:]
+			}
+			w.WriteHeader(status)
+		}))
+		broker = &types.Broker{
+			ID:         "broker-id",
+			Name:       "broker",
+			BrokerURL:  server.URL,
+			Resilience: &types.Resilience{},
+		}
+		client = brokerclient.NewClient(brokerclient.NewTransports(false), brokerclient.NewTokenSource(http.DefaultClient.Do))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("retries", func() {
+		BeforeEach(func() {
+			broker.Resilience.Retries = 2
+		})
+
+		It("retries get requests while the broker is unavailable", func() {
+			statuses = []int{http.StatusServiceUnavailable, http.StatusBadGateway}
+			response, err := send(http.MethodGet)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.StatusCode).To(Equal(http.StatusOK))
+			Expect(requests).To(Equal(3))
+		})
+
+		It("returns the last response when the retries are exhausted", func() {
+			statuses = []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable}
+			response, err := send(http.MethodGet)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.StatusCode).To(Equal(http.StatusServiceUnavailable))
+			Expect(requests).To(Equal(3))
+		})
+
+		It("does not retry requests rejected by the broker", func() {
+			statuses = []int{http.StatusInternalServerError}
+			response, err := send(http.MethodGet)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.StatusCode).To(Equal(http.StatusInternalServerError))
+			Expect(requests).To(Equal(1))
+		})
+
+		It("does not retry requests which are not idempotent", func() {
+			statuses = []int{http.StatusServiceUnavailable}
+			response, err := send(http.MethodPut)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.StatusCode).To(Equal(http.StatusServiceUnavailable))
+			Expect(requests).To(Equal(1))
+		})
+	})
+
+	Describe("circuit breaker", func() {
+		BeforeEach(func() {
+			broker.Resilience.CircuitBreakerThreshold = 2
+			broker.Resilience.CircuitBreakerTimeout = 1
+		})
+
+		It("is not reported for brokers without circuit breaker", func() {
+			broker.Resilience = nil
+			Expect(client.CircuitBreakerState(broker)).To(BeEmpty())
+		})
+
+		It("opens after the threshold of consecutive failures is reached", func() {
+			statuses = []int{http.StatusInternalServerError, http.StatusInternalServerError}
+			send(http.MethodPut)
+			Expect(client.CircuitBreakerState(broker)).To(Equal(brokerclient.CircuitBreakerClosed))
+			send(http.MethodPut)
+			Expect(client.CircuitBreakerState(broker)).To(Equal(brokerclient.CircuitBreakerOpen))
+
+			_, err := send(http.MethodPut)
+			Expect(err).To(HaveOccurred())
+			circuitOpenErr, ok := err.(*brokerclient.CircuitOpenError)
+			Expect(ok).To(BeTrue())
+			Expect(circuitOpenErr.RetryAfter).To(BeNumerically(">", 0))
+			Expect(circuitOpenErr.RetryAfter).To(BeNumerically("<=", time.Second))
+			Expect(requests).To(Equal(2))
+		})
+
+		It("is reset by successful requests", func() {
+			statuses = []int{http.StatusInternalServerError, http.StatusOK, http.StatusInternalServerError}
+			send(http.MethodPut)
+			send(http.MethodPut)
+			send(http.MethodPut)
+			Expect(client.CircuitBreakerState(broker)).To(Equal(brokerclient.CircuitBreakerClosed))
+		})
+
+		It("lets a trial request through after the timeout", func() {
+			statuses = []int{http.StatusInternalServerError, http.StatusInternalServerError}
+			send(http.MethodPut)
+			send(http.MethodPut)
+
+			Eventually(func() string {
+				return client.CircuitBreakerState(broker)
+			}, 2*time.Second, 100*time.Millisecond).Should(Equal(brokerclient.CircuitBreakerHalfOpen))
+			response, err := send(http.MethodPut)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.StatusCode).To(Equal(http.StatusOK))
+			Expect(client.CircuitBreakerState(broker)).To(Equal(brokerclient.CircuitBreakerClosed))
+		})
+
+		It("is reset when the broker is evicted", func() {
+			statuses = []int{http.StatusInternalServerError, http.StatusInternalServerError}
+			send(http.MethodPut)
+			send(http.MethodPut)
+
+			client.Evict(broker.ID)
+			Expect(client.CircuitBreakerState(broker)).To(Equal(brokerclient.CircuitBreakerClosed))
+		})
+
+		It("does not count requests canceled by the caller", func() {
+			broker.Resilience.CircuitBreakerThreshold = 1
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			request, err := http.NewRequest(http.MethodPut, server.URL, nil)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = client.Do(request.WithContext(ctx), broker)
+			Expect(err).To(HaveOccurred())
+			Expect(client.CircuitBreakerState(broker)).To(Equal(brokerclient.CircuitBreakerClosed))
+		})
+
+		It("is neither fed nor consulted by catalog probes", func() {
+			statuses = []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError}
+			for i := 0; i < 3; i++ {
+				Expect(client.ProbeCatalog(context.Background(), broker)).To(HaveOccurred())
+			}
+			Expect(client.CircuitBreakerState(broker)).To(Equal(brokerclient.CircuitBreakerClosed))
+
+			statuses = []int{http.StatusInternalServerError, http.StatusInternalServerError}
+			send(http.MethodPut)
+			send(http.MethodPut)
+			Expect(client.CircuitBreakerState(broker)).To(Equal(brokerclient.CircuitBreakerOpen))
+			Expect(client.ProbeCatalog(context.Background(), broker)).ToNot(HaveOccurred())
+			Expect(requests).To(Equal(6))
+		})
+	})
+})
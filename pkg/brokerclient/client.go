@@ -0,0 +1,394 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package brokerclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
+)
+
+const (
+	// APIVersionHeader is the header carrying the OSB API version of the requests towards the brokers
+	APIVersionHeader = "X-Broker-API-Version"
+
+	// APIVersion is the OSB API version used for the requests towards brokers whose API version has not been detected
+	APIVersion = "2.14"
+
+	catalogPath = "/v2/catalog"
+
+	// retryBackoff is the time to wait before the first retry of a failed request. It grows with each retry.
+	retryBackoff = 100 * time.Millisecond
+)
+
+// Client sends authenticated requests to service brokers
+type Client struct {
+	transports      *Transports
+	tokenSource     *TokenSource
+	circuitBreakers *circuitBreakers
+}
+
+// NewClient returns a client which uses the provided transports and token source to reach the brokers
+func NewClient(transports *Transports, tokenSource *TokenSource) *Client {
+	return &Client{
+		transports:  transports,
+		tokenSource: tokenSource,
+		circuitBreakers: &circuitBreakers{
+			breakers: make(map[string]*circuitBreaker),
+		},
+	}
+}
+
+// Transport returns the transport that should be used for calls towards the broker
+func (c *Client) Transport(broker *types.Broker) (*http.Transport, error) {
+	return c.transports.Transport(broker)
+}
+
+// Evict releases the resources held for the broker with the specified id
+func (c *Client) Evict(brokerID string) {
+	c.transports.Evict(brokerID)
+	c.transports.Evict(previousCredentialsBrokerID(brokerID))
+	c.circuitBreakers.evict(brokerID)
+}
+
+// CircuitBreakerState returns the state of the circuit breaker of the broker or an empty string if the broker has
+// no circuit breaker configured
+func (c *Client) CircuitBreakerState(broker *types.Broker) string {
+	breaker := c.circuitBreakers.get(broker)
+	if breaker == nil {
+		return ""
+	}
+	return breaker.state(broker, time.Now())
+}
+
+// Authenticate sets the authorization of the request according to the broker credentials. Requests towards brokers
+// which rely only on TLS client certificates are sent without authorization header.
+func (c *Client) Authenticate(request *http.Request, broker *types.Broker) error {
+	return c.authenticate(request, broker.Credentials)
+}
+
+func (c *Client) authenticate(request *http.Request, credentials *types.Credentials) error {
+	switch {
+	case credentials == nil:
+		request.Header.Del("Authorization")
+	case credentials.OAuth2 != nil:
+		token, err := c.tokenSource.Token(request.Context(), credentials.OAuth2)
+		if err != nil {
+			return err
+		}
+		request.Header.Set("Authorization", "Bearer "+token)
+	case credentials.Basic != nil:
+		request.SetBasicAuth(credentials.Basic.Username, credentials.Basic.Password)
+	default:
+		request.Header.Del("Authorization")
+	}
+	return nil
+}
+
+// RoundTripper returns a round tripper which authenticates the requests towards the broker. Requests whose cached
+// OAuth2 access token is rejected with 401 are retried once with a new token. While the grace period of a credentials
+// rotation lasts, requests rejected with 401 are retried with the previous broker credentials.
+// GET requests which fail are retried as configured in the broker resilience settings. If the circuit breaker of
+// the broker is open, the requests are rejected with a CircuitOpenError without being sent.
+func (c *Client) RoundTripper(broker *types.Broker) (http.RoundTripper, error) {
+	transport, err := c.transports.Transport(broker)
+	if err != nil {
+		return nil, err
+	}
+	return &brokerRoundTripper{
+		client:    c,
+		broker:    broker,
+		transport: transport,
+	}, nil
+}
+
+// Do authenticates the request and sends it to the broker using the broker transport
+func (c *Client) Do(request *http.Request, broker *types.Broker) (*http.Response, error) {
+	roundTripper, err := c.RoundTripper(broker)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{
+		Transport: roundTripper,
+	}
+	return client.Do(request)
+}
+
+// CatalogResponse is a catalog fetched from a broker together with the validators of the catalog
+type CatalogResponse struct {
+	// Catalog is the raw catalog. It is empty if the catalog was not modified.
+	Catalog []byte
+	// ETag is the entity tag of the catalog as returned by the broker
+	ETag string
+	// LastModified is the last modification time of the catalog as returned by the broker
+	LastModified string
+	// NotModified is true if the broker confirmed that the catalog has not changed since it was last fetched
+	NotModified bool
+	// APIVersion is the highest OSB API version accepted by the broker
+	APIVersion string
+}
+
+// GetCatalog fetches the raw catalog of the broker using the API version of the broker
+func (c *Client) GetCatalog(ctx context.Context, broker *types.Broker) ([]byte, error) {
+	response, err := c.getCatalog(ctx, broker, BrokerAPIVersion(broker), "", "")
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, util.HandleResponseError(response)
+	}
+	catalog, err := util.BodyToBytes(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read catalog response: %s", err)
+	}
+	return catalog, nil
+}
+
+// GetCatalogIfModified fetches the raw catalog of the broker unless the broker confirms that the catalog has not
+// changed since it was returned with the provided etag and last modification time. Empty validators are not sent.
+// The API version is negotiated starting from the highest supported one until the broker stops rejecting it with
+// 412 Precondition Failed.
+func (c *Client) GetCatalogIfModified(ctx context.Context, broker *types.Broker, etag, lastModified string) (*CatalogResponse, error) {
+	for _, version := range APIVersions {
+		response, err := c.getCatalog(ctx, broker, version, etag, lastModified)
+		if err != nil {
+			return nil, err
+		}
+		if response.StatusCode == http.StatusPreconditionFailed {
+			response.Body.Close()
+			log.C(ctx).Debugf("Broker %s rejected OSB API version %s", broker.Name, version)
+			continue
+		}
+
+		catalogResponse := &CatalogResponse{
+			ETag:         response.Header.Get("ETag"),
+			LastModified: response.Header.Get("Last-Modified"),
+			APIVersion:   version,
+		}
+		if response.StatusCode == http.StatusNotModified && (etag != "" || lastModified != "") {
+			response.Body.Close()
+			catalogResponse.NotModified = true
+			return catalogResponse, nil
+		}
+		if response.StatusCode != http.StatusOK {
+			return nil, util.HandleResponseError(response)
+		}
+		if catalogResponse.Catalog, err = util.BodyToBytes(response.Body); err != nil {
+			return nil, fmt.Errorf("could not read catalog response: %s", err)
+		}
+		return catalogResponse, nil
+	}
+	return nil, fmt.Errorf("broker %s does not support any of the OSB API versions %s", broker.Name, strings.Join(APIVersions, ", "))
+}
+
+// ProbeCatalog checks whether the broker serves its catalog. Probes bypass the circuit breaker of the broker and are
+// not retried, so they are neither rejected by an open circuit breaker nor counted towards opening it.
+func (c *Client) ProbeCatalog(ctx context.Context, broker *types.Broker) error {
+	transport, err := c.transports.Transport(broker)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{
+		Transport: &probeRoundTripper{
+			brokerRoundTripper: &brokerRoundTripper{
+				client:    c,
+				broker:    broker,
+				transport: transport,
+			},
+		},
+	}
+	doRequest := func(request *http.Request) (*http.Response, error) {
+		request.Header.Set(APIVersionHeader, BrokerAPIVersion(broker))
+		return client.Do(request)
+	}
+	url := strings.TrimSuffix(broker.BrokerURL, "/") + catalogPath
+	response, err := util.SendRequest(ctx, doRequest, http.MethodGet, url, nil, nil)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		return util.HandleResponseError(response)
+	}
+	return response.Body.Close()
+}
+
+func (c *Client) getCatalog(ctx context.Context, broker *types.Broker, version, etag, lastModified string) (*http.Response, error) {
+	doRequest := func(request *http.Request) (*http.Response, error) {
+		request.Header.Set(APIVersionHeader, version)
+		if etag != "" {
+			request.Header.Set("If-None-Match", etag)
+		}
+		if lastModified != "" {
+			request.Header.Set("If-Modified-Since", lastModified)
+		}
+		return c.Do(request, broker)
+	}
+	url := strings.TrimSuffix(broker.BrokerURL, "/") + catalogPath
+	return util.SendRequest(ctx, doRequest, http.MethodGet, url, nil, nil)
+}
+
+type brokerRoundTripper struct {
+	client    *Client
+	broker    *types.Broker
+	transport http.RoundTripper
+}
+
+func (rt *brokerRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	breaker := rt.client.circuitBreakers.get(rt.broker)
+	if breaker == nil {
+		return rt.roundTripWithRetries(request)
+	}
+	if err := breaker.allow(rt.broker, time.Now()); err != nil {
+		return nil, err
+	}
+	response, err := rt.roundTripWithRetries(request)
+	if err != nil && request.Context().Err() != nil {
+		// requests canceled by the caller say nothing about the broker
+		breaker.release()
+		return response, err
+	}
+	failed := err != nil || response.StatusCode >= http.StatusInternalServerError
+	breaker.record(rt.broker, failed, time.Now())
+	if failed && breaker.state(rt.broker, time.Now()) == CircuitBreakerOpen {
+		log.C(request.Context()).Warnf("Opened circuit breaker of broker %s", rt.broker.Name)
+	}
+	return response, err
+}
+
+// probeRoundTripper authenticates the requests towards the broker like brokerRoundTripper but sends them only once
+// and without consulting the circuit breaker of the broker
+type probeRoundTripper struct {
+	*brokerRoundTripper
+}
+
+func (rt *probeRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	return rt.roundTrip(request)
+}
+
+// roundTripWithRetries retries GET requests which fail to reach the broker or are rejected because the broker is
+// temporarily unavailable. Other requests are not idempotent and are sent only once.
+func (rt *brokerRoundTripper) roundTripWithRetries(request *http.Request) (*http.Response, error) {
+	retries := 0
+	if rt.broker.Resilience != nil && request.Method == http.MethodGet {
+		retries = rt.broker.Resilience.Retries
+	}
+	for attempt := 0; ; attempt++ {
+		response, err := rt.roundTrip(request)
+		if attempt == retries || !retriable(response, err) {
+			return response, err
+		}
+		if err != nil {
+			log.C(request.Context()).WithError(err).Debugf("Retrying GET request to broker %s", rt.broker.Name)
+		} else {
+			log.C(request.Context()).Debugf("Retrying GET request to broker %s which responded with status %d", rt.broker.Name, response.StatusCode)
+			response.Body.Close()
+		}
+		select {
+		case <-request.Context().Done():
+			return nil, request.Context().Err()
+		case <-time.After(time.Duration(attempt+1) * retryBackoff):
+		}
+	}
+}
+
+func retriable(response *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch response.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func (rt *brokerRoundTripper) roundTrip(request *http.Request) (*http.Response, error) {
+	previousCredentials := rt.broker.ActivePreviousCredentials()
+	var body []byte
+	if (previousCredentials != nil || usesOAuth2(rt.broker.Credentials)) && request.Body != nil {
+		// the body is kept so that the request can be replayed with a new token or the previous credentials
+		var err error
+		if body, err = ioutil.ReadAll(request.Body); err != nil {
+			return nil, err
+		}
+		request.Body.Close()
+	}
+
+	response, err := rt.sendWithTokenRenewal(request, rt.broker.Credentials, rt.transport, body)
+	if err != nil || response.StatusCode != http.StatusUnauthorized || previousCredentials == nil {
+		return response, err
+	}
+	response.Body.Close()
+
+	log.C(request.Context()).Infof("Broker %s rejected its current credentials. Retrying with the credentials replaced during the last rotation", rt.broker.Name)
+	previousBroker := *rt.broker
+	previousBroker.ID = previousCredentialsBrokerID(rt.broker.ID)
+	previousBroker.Credentials = previousCredentials
+	previousTransport, err := rt.client.transports.Transport(&previousBroker)
+	if err != nil {
+		return nil, err
+	}
+	return rt.sendWithTokenRenewal(request, previousCredentials, previousTransport, body)
+}
+
+// sendWithTokenRenewal sends the request and, if the broker rejects the cached OAuth2 access token, evicts it from
+// the cache and sends the request once more with a new token
+func (rt *brokerRoundTripper) sendWithTokenRenewal(request *http.Request, credentials *types.Credentials, transport http.RoundTripper, body []byte) (*http.Response, error) {
+	response, err := rt.send(request, credentials, transport, body)
+	if err != nil || response.StatusCode != http.StatusUnauthorized || !usesOAuth2(credentials) {
+		return response, err
+	}
+	response.Body.Close()
+
+	log.C(request.Context()).Infof("Broker %s rejected its access token. Retrying with a new access token", rt.broker.Name)
+	rt.client.tokenSource.Evict(credentials.OAuth2)
+	return rt.send(request, credentials, transport, body)
+}
+
+func (rt *brokerRoundTripper) send(request *http.Request, credentials *types.Credentials, transport http.RoundTripper, body []byte) (*http.Response, error) {
+	authenticatedRequest := request.WithContext(request.Context())
+	authenticatedRequest.Header = make(http.Header, len(request.Header))
+	for key, values := range request.Header {
+		authenticatedRequest.Header[key] = append([]string(nil), values...)
+	}
+	if body != nil {
+		authenticatedRequest.Body = ioutil.NopCloser(bytes.NewReader(body))
+		authenticatedRequest.ContentLength = int64(len(body))
+	}
+	if err := rt.client.authenticate(authenticatedRequest, credentials); err != nil {
+		return nil, err
+	}
+	return transport.RoundTrip(authenticatedRequest)
+}
+
+func usesOAuth2(credentials *types.Credentials) bool {
+	return credentials != nil && credentials.OAuth2 != nil
+}
+
+// previousCredentialsBrokerID returns the id under which the transport for the previous credentials of the broker
+// is cached since it may use another client certificate
+func previousCredentialsBrokerID(brokerID string) string {
+	return brokerID + "/previous-credentials"
+}
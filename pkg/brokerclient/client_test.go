@@ -0,0 +1,269 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package brokerclient_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/brokerclient"
+	"github.com/Peripli/service-manager/pkg/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Client", func() {
+	var (
+		server *httptest.Server
+		client *brokerclient.Client
+		broker *types.Broker
+		status int
+	)
+
+	BeforeEach(func() {
+		status = http.StatusOK
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Method).To(Equal(http.MethodGet))
+			Expect(r.URL.Path).To(Equal("/v2/catalog"))
+			if r.Header.Get(brokerclient.APIVersionHeader) != brokerclient.APIVersion {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			username, password, ok := r.BasicAuth()
+			Expect(ok).To(BeTrue())
+			Expect(username).To(Equal("admin"))
+			if password != "admin" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			w.Write([]byte(`{"services":[]}`))
+		}))
+		broker = &types.Broker{
+			ID:        "broker-id",
+			Name:      "broker",
+			BrokerURL: server.URL + "/",
+			Credentials: &types.Credentials{
+				Basic: &types.Basic{
+					Username: "admin",
+					Password: "admin",
+				},
+			},
+		}
+		client = brokerclient.NewClient(brokerclient.NewTransports(false), brokerclient.NewTokenSource(http.DefaultClient.Do))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("GetCatalog", func() {
+		Context("when the broker returns its catalog", func() {
+			It("returns the raw catalog", func() {
+				catalog, err := client.GetCatalog(context.Background(), broker)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(catalog)).To(Equal(`{"services":[]}`))
+			})
+		})
+
+		Context("when the broker rejects the credentials", func() {
+			BeforeEach(func() {
+				broker.Credentials.Basic.Password = "rotated"
+			})
+
+			It("returns an error", func() {
+				_, err := client.GetCatalog(context.Background(), broker)
+				Expect(err).To(HaveOccurred())
+			})
+
+			Context("when the previous credentials are still in their grace period", func() {
+				BeforeEach(func() {
+					broker.PreviousCredentials = &types.Credentials{
+						Basic: &types.Basic{
+							Username: "admin",
+							Password: "admin",
+						},
+					}
+					broker.PreviousCredentialsExpireAt = time.Now().Add(time.Hour)
+				})
+
+				It("retries with the previous credentials", func() {
+					catalog, err := client.GetCatalog(context.Background(), broker)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(string(catalog)).To(Equal(`{"services":[]}`))
+				})
+			})
+
+			Context("when the grace period of the previous credentials has expired", func() {
+				BeforeEach(func() {
+					broker.PreviousCredentials = &types.Credentials{
+						Basic: &types.Basic{
+							Username: "admin",
+							Password: "admin",
+						},
+					}
+					broker.PreviousCredentialsExpireAt = time.Now().Add(-time.Hour)
+				})
+
+				It("returns an error", func() {
+					_, err := client.GetCatalog(context.Background(), broker)
+					Expect(err).To(HaveOccurred())
+				})
+			})
+		})
+
+		Context("when the broker responds with an error", func() {
+			BeforeEach(func() {
+				status = http.StatusInternalServerError
+			})
+
+			It("returns an error", func() {
+				_, err := client.GetCatalog(context.Background(), broker)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("when the API version of the broker is not supported by it", func() {
+			BeforeEach(func() {
+				broker.APIVersion = "2.11"
+			})
+
+			It("returns an error", func() {
+				_, err := client.GetCatalog(context.Background(), broker)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("GetCatalogIfModified", func() {
+		Context("when no validators are provided", func() {
+			It("returns the catalog together with its validators", func() {
+				response, err := client.GetCatalogIfModified(context.Background(), broker, "", "")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(response.NotModified).To(BeFalse())
+				Expect(string(response.Catalog)).To(Equal(`{"services":[]}`))
+				Expect(response.ETag).To(Equal(`"v1"`))
+				Expect(response.LastModified).To(Equal("Mon, 02 Jan 2006 15:04:05 GMT"))
+			})
+
+			It("negotiates the highest API version accepted by the broker", func() {
+				response, err := client.GetCatalogIfModified(context.Background(), broker, "", "")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(response.APIVersion).To(Equal(brokerclient.APIVersion))
+			})
+		})
+
+		Context("when the catalog has not changed", func() {
+			It("reports that the catalog is not modified", func() {
+				response, err := client.GetCatalogIfModified(context.Background(), broker, `"v1"`, "")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(response.NotModified).To(BeTrue())
+				Expect(response.Catalog).To(BeEmpty())
+			})
+		})
+
+		Context("when the catalog has changed", func() {
+			It("returns the new catalog", func() {
+				response, err := client.GetCatalogIfModified(context.Background(), broker, `"v0"`, "")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(response.NotModified).To(BeFalse())
+				Expect(string(response.Catalog)).To(Equal(`{"services":[]}`))
+			})
+		})
+	})
+})
+
+var _ = Describe("Client with OAuth2 credentials", func() {
+	var (
+		server        *httptest.Server
+		client        *brokerclient.Client
+		broker        *types.Broker
+		issuedTokens  int
+		acceptedToken string
+	)
+
+	BeforeEach(func() {
+		issuedTokens = 0
+		acceptedToken = "token-1"
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/oauth/token" {
+				issuedTokens++
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"bearer","expires_in":3600}`, issuedTokens)
+				return
+			}
+			if r.Header.Get("Authorization") != "Bearer "+acceptedToken {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"services":[]}`))
+		}))
+		broker = &types.Broker{
+			ID:        "broker-id",
+			Name:      "broker",
+			BrokerURL: server.URL,
+			Credentials: &types.Credentials{
+				OAuth2: &types.OAuth2{
+					TokenURL:     server.URL + "/oauth/token",
+					ClientID:     "client",
+					ClientSecret: "secret",
+				},
+			},
+		}
+		client = brokerclient.NewClient(brokerclient.NewTransports(false), brokerclient.NewTokenSource(http.DefaultClient.Do))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("reuses the cached access token", func() {
+		_, err := client.GetCatalog(context.Background(), broker)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = client.GetCatalog(context.Background(), broker)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(issuedTokens).To(Equal(1))
+	})
+
+	It("retries with a new access token when the broker rejects the cached one", func() {
+		_, err := client.GetCatalog(context.Background(), broker)
+		Expect(err).ToNot(HaveOccurred())
+
+		acceptedToken = "token-2"
+		_, err = client.GetCatalog(context.Background(), broker)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(issuedTokens).To(Equal(2))
+	})
+
+	It("retries only once", func() {
+		acceptedToken = "revoked"
+		_, err := client.GetCatalog(context.Background(), broker)
+		Expect(err).To(HaveOccurred())
+		Expect(issuedTokens).To(Equal(2))
+	})
+})
@@ -0,0 +1,144 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+// Package brokerclient contains logic for authenticating the calls the Service Manager makes towards service brokers
+package brokerclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
+)
+
+// expiryDelta is the period before the actual token expiration after which a cached token is considered expired
+const expiryDelta = 10 * time.Second
+
+type token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+
+	expiry time.Time
+}
+
+func (t *token) valid() bool {
+	return t.AccessToken != "" && (t.expiry.IsZero() || time.Now().Add(expiryDelta).Before(t.expiry))
+}
+
+// TokenSource obtains access tokens from OAuth2 token endpoints using the client credentials grant
+// and caches them until shortly before they expire
+type TokenSource struct {
+	doRequest util.DoRequestFunc
+
+	mutex  sync.Mutex
+	tokens map[string]*token
+}
+
+// NewTokenSource returns a TokenSource which uses the provided function to send the token requests
+func NewTokenSource(doRequest util.DoRequestFunc) *TokenSource {
+	return &TokenSource{
+		doRequest: doRequest,
+		tokens:    make(map[string]*token),
+	}
+}
+
+// Token returns a valid access token for the specified OAuth2 client credentials. A cached token is
+// returned if one is available, otherwise a new token is requested from the token endpoint.
+func (ts *TokenSource) Token(ctx context.Context, credentials *types.OAuth2) (string, error) {
+	key := cacheKey(credentials)
+
+	ts.mutex.Lock()
+	cached, found := ts.tokens[key]
+	ts.mutex.Unlock()
+	if found && cached.valid() {
+		return cached.AccessToken, nil
+	}
+
+	t, err := ts.requestToken(ctx, credentials)
+	if err != nil {
+		return "", err
+	}
+
+	ts.mutex.Lock()
+	ts.tokens[key] = t
+	ts.mutex.Unlock()
+
+	return t.AccessToken, nil
+}
+
+// Evict removes the cached access token for the specified OAuth2 client credentials, so that the next call to Token
+// requests a new one. It is used when the broker rejects a token before its expiry, e.g. because it was revoked.
+func (ts *TokenSource) Evict(credentials *types.OAuth2) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	delete(ts.tokens, cacheKey(credentials))
+}
+
+func (ts *TokenSource) requestToken(ctx context.Context, credentials *types.OAuth2) (*token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if len(credentials.Scopes) != 0 {
+		form.Set("scope", strings.Join(credentials.Scopes, " "))
+	}
+
+	request, err := http.NewRequest(http.MethodPost, credentials.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	request = request.WithContext(ctx)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	request.SetBasicAuth(url.QueryEscape(credentials.ClientID), url.QueryEscape(credentials.ClientSecret))
+
+	log.C(ctx).Debugf("Requesting access token for client %s from %s", credentials.ClientID, credentials.TokenURL)
+	response, err := ts.doRequest(request)
+	if err != nil {
+		return nil, fmt.Errorf("could not obtain access token from %s: %s", credentials.TokenURL, err)
+	}
+	body, err := util.BodyToBytes(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read access token response from %s: %s", credentials.TokenURL, err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not obtain access token from %s: status %d: %s", credentials.TokenURL, response.StatusCode, body)
+	}
+
+	t := &token{}
+	if err := json.Unmarshal(body, t); err != nil {
+		return nil, fmt.Errorf("could not parse access token response from %s: %s", credentials.TokenURL, err)
+	}
+	if t.AccessToken == "" {
+		return nil, fmt.Errorf("access token response from %s does not contain an access token", credentials.TokenURL)
+	}
+	if t.ExpiresIn > 0 {
+		t.expiry = time.Now().Add(time.Duration(t.ExpiresIn) * time.Second)
+	}
+	return t, nil
+}
+
+func cacheKey(credentials *types.OAuth2) string {
+	return strings.Join([]string{credentials.TokenURL, credentials.ClientID, credentials.ClientSecret, strings.Join(credentials.Scopes, " ")}, "|")
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package brokerclient_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Peripli/service-manager/pkg/brokerclient"
+	"github.com/Peripli/service-manager/pkg/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestBrokerClient(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Broker Client Suite")
+}
+
+var _ = Describe("TokenSource", func() {
+	var (
+		server       *httptest.Server
+		tokenSource  *brokerclient.TokenSource
+		credentials  *types.OAuth2
+		requestCount int
+		status       int
+		responseBody string
+	)
+
+	BeforeEach(func() {
+		requestCount = 0
+		status = http.StatusOK
+		responseBody = `{"access_token":"token","token_type":"bearer","expires_in":3600}`
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestCount++
+			Expect(r.Method).To(Equal(http.MethodPost))
+			Expect(r.ParseForm()).To(Succeed())
+			Expect(r.PostForm.Get("grant_type")).To(Equal("client_credentials"))
+			Expect(r.PostForm.Get("scope")).To(Equal("read write"))
+			username, password, ok := r.BasicAuth()
+			Expect(ok).To(BeTrue())
+			Expect(username).To(Equal("client"))
+			Expect(password).To(Equal("secret"))
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			w.Write([]byte(responseBody))
+		}))
+		credentials = &types.OAuth2{
+			TokenURL:     server.URL,
+			ClientID:     "client",
+			ClientSecret: "secret",
+			Scopes:       []string{"read", "write"},
+		}
+		tokenSource = brokerclient.NewTokenSource(http.DefaultClient.Do)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Context("when the token endpoint returns a token", func() {
+		It("returns the access token", func() {
+			token, err := tokenSource.Token(context.Background(), credentials)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(token).To(Equal("token"))
+		})
+
+		It("caches the token until it expires", func() {
+			for i := 0; i < 3; i++ {
+				_, err := tokenSource.Token(context.Background(), credentials)
+				Expect(err).ToNot(HaveOccurred())
+			}
+			Expect(requestCount).To(Equal(1))
+		})
+	})
+
+	Context("when the token is about to expire", func() {
+		BeforeEach(func() {
+			responseBody = `{"access_token":"token","token_type":"bearer","expires_in":1}`
+		})
+
+		It("requests a new token", func() {
+			for i := 0; i < 2; i++ {
+				_, err := tokenSource.Token(context.Background(), credentials)
+				Expect(err).ToNot(HaveOccurred())
+			}
+			Expect(requestCount).To(Equal(2))
+		})
+	})
+
+	Context("when the token endpoint returns an error", func() {
+		BeforeEach(func() {
+			status = http.StatusUnauthorized
+			responseBody = `{"error":"unauthorized"}`
+		})
+
+		It("returns an error", func() {
+			_, err := tokenSource.Token(context.Background(), credentials)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("401"))
+		})
+	})
+
+	Context("when the token endpoint response contains no access token", func() {
+		BeforeEach(func() {
+			responseBody = `{}`
+		})
+
+		It("returns an error", func() {
+			_, err := tokenSource.Token(context.Background(), credentials)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,171 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package brokerclient
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util"
+)
+
+// ClientTLSConfig returns a TLS configuration presenting the client certificate from the broker credentials.
+// If the credentials contain no client certificate, nil is returned.
+func ClientTLSConfig(credentials *types.Credentials) (*tls.Config, error) {
+	if credentials == nil || credentials.TLS == nil {
+		return nil, nil
+	}
+	certificate, err := tls.X509KeyPair([]byte(credentials.TLS.Certificate), []byte(credentials.TLS.Key))
+	if err != nil {
+		return nil, fmt.Errorf("could not load broker client certificate: %s", err)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+	}, nil
+}
+
+type cachedTransport struct {
+	fingerprint [sha256.Size]byte
+	transport   *http.Transport
+}
+
+// Transports builds the transports used for calls towards service brokers. Each broker gets its own transport
+// configured with the broker TLS trust settings, client certificate and timeouts. The transports are cached and
+// reused until the TLS configuration or the timeouts of the broker change.
+type Transports struct {
+	skipSSLValidation bool
+
+	mutex      sync.Mutex
+	transports map[string]*cachedTransport
+}
+
+// NewTransports returns a new transports cache. If skipSSLValidation is true, the certificates of all brokers
+// are not verified regardless of the broker TLS trust settings.
+func NewTransports(skipSSLValidation bool) *Transports {
+	return &Transports{
+		skipSSLValidation: skipSSLValidation,
+		transports:        make(map[string]*cachedTransport),
+	}
+}
+
+// Transport returns the transport that should be used for calls towards the specified broker
+func (t *Transports) Transport(broker *types.Broker) (*http.Transport, error) {
+	fingerprint := t.fingerprint(broker)
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	cached, found := t.transports[broker.ID]
+	if found && cached.fingerprint == fingerprint {
+		return cached.transport, nil
+	}
+
+	tlsConfig, err := t.tlsConfig(broker)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		cached.transport.CloseIdleConnections()
+	}
+	transport := util.NewTransport(tlsConfig)
+	if resilience := broker.Resilience; resilience != nil {
+		if resilience.ConnectTimeout > 0 {
+			transport.DialContext = (&net.Dialer{
+				Timeout:   resilience.ConnectTimeoutDuration(),
+				KeepAlive: 30 * time.Second,
+				DualStack: true,
+			}).DialContext
+		}
+		transport.ResponseHeaderTimeout = resilience.ResponseTimeoutDuration()
+	}
+	t.transports[broker.ID] = &cachedTransport{
+		fingerprint: fingerprint,
+		transport:   transport,
+	}
+	return transport, nil
+}
+
+// Evict removes the cached transport of the broker with the specified id and closes its idle connections
+func (t *Transports) Evict(brokerID string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if cached, found := t.transports[brokerID]; found {
+		cached.transport.CloseIdleConnections()
+		delete(t.transports, brokerID)
+	}
+}
+
+func (t *Transports) tlsConfig(broker *types.Broker) (*tls.Config, error) {
+	tlsConfig, err := ClientTLSConfig(broker.Credentials)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	}
+	tlsConfig.InsecureSkipVerify = t.skipSSLValidation
+
+	trust := broker.TLS
+	if trust == nil {
+		return tlsConfig, nil
+	}
+	tlsConfig.InsecureSkipVerify = tlsConfig.InsecureSkipVerify || trust.SkipSSLValidation
+	tlsConfig.ServerName = trust.ServerName
+	if trust.CACertificates != "" {
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil {
+			rootCAs = x509.NewCertPool()
+		}
+		if !rootCAs.AppendCertsFromPEM([]byte(trust.CACertificates)) {
+			return nil, errors.New("could not load broker ca certificates")
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+	return tlsConfig, nil
+}
+
+// fingerprint returns a hash of all settings that affect the transport of the broker
+func (t *Transports) fingerprint(broker *types.Broker) [sha256.Size]byte {
+	values := []string{strconv.FormatBool(t.skipSSLValidation)}
+	if broker.TLS != nil {
+		values = append(values, broker.TLS.CACertificates, broker.TLS.ServerName, strconv.FormatBool(broker.TLS.SkipSSLValidation))
+	}
+	if broker.Credentials != nil && broker.Credentials.TLS != nil {
+		values = append(values, broker.Credentials.TLS.Certificate, broker.Credentials.TLS.Key)
+	}
+	if broker.Resilience != nil {
+		values = append(values, strconv.Itoa(broker.Resilience.ConnectTimeout), strconv.Itoa(broker.Resilience.ResponseTimeout))
+	}
+	hash := sha256.New()
+	for _, value := range values {
+		hash.Write([]byte(strconv.Itoa(len(value))))
+		hash.Write([]byte(value))
+	}
+	var fingerprint [sha256.Size]byte
+	copy(fingerprint[:], hash.Sum(nil))
+	return fingerprint
+}
@@ -0,0 +1,141 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package brokerclient_test
+
+import (
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/brokerclient"
+	"github.com/Peripli/service-manager/pkg/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Transports", func() {
+	var (
+		transports *brokerclient.Transports
+		broker     *types.Broker
+	)
+
+	BeforeEach(func() {
+		transports = brokerclient.NewTransports(false)
+		broker = &types.Broker{
+			ID: "broker-id",
+			Credentials: &types.Credentials{
+				Basic: &types.Basic{
+					Username: "username",
+					Password: "password",
+				},
+			},
+		}
+	})
+
+	It("reuses the transport of a broker", func() {
+		first, err := transports.Transport(broker)
+		Expect(err).ToNot(HaveOccurred())
+		second, err := transports.Transport(broker)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(second).To(BeIdenticalTo(first))
+	})
+
+	It("builds separate transports for different brokers", func() {
+		first, err := transports.Transport(broker)
+		Expect(err).ToNot(HaveOccurred())
+		second, err := transports.Transport(&types.Broker{ID: "other-broker-id"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(second).ToNot(BeIdenticalTo(first))
+	})
+
+	It("builds a new transport when the broker tls trust settings change", func() {
+		first, err := transports.Transport(broker)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(first.TLSClientConfig.InsecureSkipVerify).To(BeFalse())
+
+		broker.TLS = &types.TLSTrust{
+			ServerName:        "broker.example.com",
+			SkipSSLValidation: true,
+		}
+		second, err := transports.Transport(broker)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(second).ToNot(BeIdenticalTo(first))
+		Expect(second.TLSClientConfig.InsecureSkipVerify).To(BeTrue())
+		Expect(second.TLSClientConfig.ServerName).To(Equal("broker.example.com"))
+	})
+
+	It("builds a new transport after the broker transport is evicted", func() {
+		first, err := transports.Transport(broker)
+		Expect(err).ToNot(HaveOccurred())
+		transports.Evict(broker.ID)
+		second, err := transports.Transport(broker)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(second).ToNot(BeIdenticalTo(first))
+	})
+
+	It("builds a new transport with the broker timeouts when they change", func() {
+		first, err := transports.Transport(broker)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(first.ResponseHeaderTimeout).To(BeZero())
+
+		broker.Resilience = &types.Resilience{
+			ConnectTimeout:  5,
+			ResponseTimeout: 10,
+		}
+		second, err := transports.Transport(broker)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(second).ToNot(BeIdenticalTo(first))
+		Expect(second.ResponseHeaderTimeout).To(Equal(10 * time.Second))
+	})
+
+	Context("when skip ssl validation is globally enabled", func() {
+		BeforeEach(func() {
+			transports = brokerclient.NewTransports(true)
+		})
+
+		It("does not verify the broker certificates", func() {
+			transport, err := transports.Transport(broker)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(transport.TLSClientConfig.InsecureSkipVerify).To(BeTrue())
+		})
+	})
+
+	Context("when the broker ca certificates are invalid", func() {
+		BeforeEach(func() {
+			broker.TLS = &types.TLSTrust{
+				CACertificates: "invalid",
+			}
+		})
+
+		It("returns an error", func() {
+			_, err := transports.Transport(broker)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when the broker client certificate is invalid", func() {
+		BeforeEach(func() {
+			broker.Credentials.TLS = &types.TLS{
+				Certificate: "invalid",
+				Key:         "invalid",
+			}
+		})
+
+		It("returns an error", func() {
+			_, err := transports.Transport(broker)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,83 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package brokerclient
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Peripli/service-manager/pkg/types"
+)
+
+// APIVersions are the OSB API versions the Service Manager can negotiate with the brokers ordered from the highest
+// to the lowest
+var APIVersions = []string{"2.15", "2.14", "2.13", "2.12", "2.11"}
+
+// BrokerAPIVersion returns the OSB API version that should be used for requests towards the broker. Brokers whose
+// API version has not been detected yet are called with the default API version.
+func BrokerAPIVersion(broker *types.Broker) string {
+	if broker.APIVersion != "" {
+		return broker.APIVersion
+	}
+	return APIVersion
+}
+
+// CompareAPIVersions compares two OSB API versions of the form major.minor. The result is 0 if v1 == v2, -1 if v1 < v2
+// and 1 if v1 > v2. Versions which cannot be parsed are lower than all valid versions.
+func CompareAPIVersions(v1, v2 string) int {
+	major1, minor1, ok1 := parseAPIVersion(v1)
+	major2, minor2, ok2 := parseAPIVersion(v2)
+	switch {
+	case !ok1 && !ok2:
+		return 0
+	case !ok1:
+		return -1
+	case !ok2:
+		return 1
+	case major1 != major2:
+		return compareInts(major1, major2)
+	default:
+		return compareInts(minor1, minor2)
+	}
+}
+
+func parseAPIVersion(version string) (int, int, bool) {
+	parts := strings.Split(version, ".")
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
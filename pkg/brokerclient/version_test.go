@@ -0,0 +1,53 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package brokerclient_test
+
+import (
+	"github.com/Peripli/service-manager/pkg/brokerclient"
+	"github.com/Peripli/service-manager/pkg/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("API versions", func() {
+	Describe("CompareAPIVersions", func() {
+		It("compares the minor versions if the major versions are equal", func() {
+			Expect(brokerclient.CompareAPIVersions("2.14", "2.14")).To(Equal(0))
+			Expect(brokerclient.CompareAPIVersions("2.9", "2.14")).To(Equal(-1))
+			Expect(brokerclient.CompareAPIVersions("2.15", "2.14")).To(Equal(1))
+		})
+
+		It("compares the major versions", func() {
+			Expect(brokerclient.CompareAPIVersions("3.0", "2.15")).To(Equal(1))
+		})
+
+		It("treats invalid versions as lower than valid ones", func() {
+			Expect(brokerclient.CompareAPIVersions("latest", "2.11")).To(Equal(-1))
+			Expect(brokerclient.CompareAPIVersions("", "latest")).To(Equal(0))
+		})
+	})
+
+	Describe("BrokerAPIVersion", func() {
+		It("returns the detected API version of the broker", func() {
+			Expect(brokerclient.BrokerAPIVersion(&types.Broker{APIVersion: "2.12"})).To(Equal("2.12"))
+		})
+
+		It("returns the default API version if the API version of the broker is not detected", func() {
+			Expect(brokerclient.BrokerAPIVersion(&types.Broker{})).To(Equal(brokerclient.APIVersion))
+		})
+	})
+})
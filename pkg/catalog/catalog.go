@@ -0,0 +1,60 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+// Package catalog contains the broker catalog model and the extension points that allow plugins
+// to transform broker catalogs before they are stored in Service Manager
+package catalog
+
+import (
+	"encoding/json"
+)
+
+// Catalog is the catalog as published by the broker on /v2/catalog
+type Catalog struct {
+	Services []*Service `json:"services"`
+}
+
+// Service is a service offering as published in the broker catalog
+type Service struct {
+	ID                   string                 `json:"id"`
+	Name                 string                 `json:"name"`
+	Description          string                 `json:"description"`
+	Bindable             bool                   `json:"bindable"`
+	InstancesRetrievable bool                   `json:"instances_retrievable"`
+	BindingsRetrievable  bool                   `json:"bindings_retrievable"`
+	PlanUpdatable        bool                   `json:"plan_updateable"`
+	AllowContextUpdates  bool                   `json:"allow_context_updates"`
+	Tags                 []string               `json:"tags,omitempty"`
+	Requires             []string               `json:"requires,omitempty"`
+	Metadata             map[string]interface{} `json:"metadata,omitempty"`
+	DashboardClient      json.RawMessage        `json:"dashboard_client,omitempty"`
+	Plans                []*Plan                `json:"plans"`
+}
+
+// Plan is a service plan as published in the broker catalog
+type Plan struct {
+	ID                     string                 `json:"id"`
+	Name                   string                 `json:"name"`
+	Description            string                 `json:"description"`
+	Free                   *bool                  `json:"free,omitempty"`
+	Bindable               *bool                  `json:"bindable,omitempty"`
+	PlanUpdatable          *bool                  `json:"plan_updateable,omitempty"`
+	BindingsRetrievable    *bool                  `json:"bindings_retrievable,omitempty"`
+	MaximumPollingDuration int                    `json:"maximum_polling_duration,omitempty"`
+	Metadata               map[string]interface{} `json:"metadata,omitempty"`
+	Schemas                map[string]interface{} `json:"schemas,omitempty"`
+	MaintenanceInfo        json.RawMessage        `json:"maintenance_info,omitempty"`
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package catalog
+
+import (
+	"context"
+
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/pkg/types"
+	"github.com/Peripli/service-manager/pkg/util/slice"
+	"github.com/Peripli/service-manager/pkg/web"
+)
+
+// Request contains the broker that is being registered or updated and its catalog
+type Request struct {
+	// Broker is the broker whose catalog is transformed. It should not be modified.
+	Broker *types.Broker
+
+	// Catalog is the broker catalog. Transformers may modify it or replace it before invoking the next Handler.
+	Catalog *Catalog
+}
+
+// Handler processes a broker catalog
+type Handler interface {
+	// Handle processes the catalog in the Request
+	Handle(ctx context.Context, req *Request) error
+}
+
+// HandlerFunc is an adapter that allows to use regular functions as Handler interface implementations.
+type HandlerFunc func(ctx context.Context, req *Request) error
+
+// Handle allows HandlerFunc to act as a Handler
+func (hf HandlerFunc) Handle(ctx context.Context, req *Request) error {
+	return hf(ctx, req)
+}
+
+// Transformer should be implemented by plugins that need to filter, rename, enrich or reject the service offerings
+// and plans of a broker catalog after it is fetched from the broker and before it is stored in Service Manager.
+// The implementation should invoke next's Handle for the catalog to be stored. Returning an error rejects the
+// catalog and the error is propagated to the client the same way as errors returned by filters.
+type Transformer interface {
+	web.Named
+
+	TransformCatalog(ctx context.Context, req *Request, next Handler) error
+}
+
+// Transformers represents an ordered slice of Transformer elements
+type Transformers []Transformer
+
+// Register appends the specified transformers
+func (ts *Transformers) Register(transformers ...Transformer) {
+	ts.validate(transformers...)
+	*ts = append(*ts, transformers...)
+}
+
+// RegisterBefore registers the specified transformers before the one with the given name
+func (ts *Transformers) RegisterBefore(beforeTransformerName string, transformers ...Transformer) {
+	for _, transformer := range transformers {
+		log.D().Debugf("Registering catalog transformer %s before %s", transformer.Name(), beforeTransformerName)
+		ts.validate(transformer)
+		ts.insert(ts.position(beforeTransformerName), transformer)
+	}
+}
+
+// RegisterAfter registers the specified transformers after the one with the given name
+func (ts *Transformers) RegisterAfter(afterTransformerName string, transformers ...Transformer) {
+	position := ts.position(afterTransformerName)
+	for i, transformer := range transformers {
+		log.D().Debugf("Registering catalog transformer %s after %s", transformer.Name(), afterTransformerName)
+		ts.validate(transformer)
+		ts.insert(position+1+i, transformer)
+	}
+}
+
+// Chain builds a Handler that runs the transformers in order and invokes the provided handler last
+func (ts Transformers) Chain(handler Handler) Handler {
+	for i := len(ts) - 1; i >= 0; i-- {
+		transformer := ts[i]
+		next := handler
+		handler = HandlerFunc(func(ctx context.Context, req *Request) error {
+			return transformer.TransformCatalog(ctx, req, next)
+		})
+	}
+	return handler
+}
+
+func (ts *Transformers) validate(transformers ...Transformer) {
+	names := ts.names()
+	for _, transformer := range transformers {
+		name := transformer.Name()
+		if name == "" {
+			log.D().Panicf("Catalog transformers cannot have empty names")
+		}
+		if slice.StringsAnyEquals(names, name) {
+			log.D().Panicf("Catalog transformer %s is already registered", name)
+		}
+		names = append(names, name)
+	}
+}
+
+func (ts *Transformers) insert(position int, transformer Transformer) {
+	*ts = append(*ts, nil)
+	copy((*ts)[position+1:], (*ts)[position:])
+	(*ts)[position] = transformer
+}
+
+func (ts *Transformers) position(name string) int {
+	for i, transformer := range *ts {
+		if transformer.Name() == name {
+			return i
+		}
+	}
+	log.D().Panicf("Catalog transformer with name %s is not found", name)
+	return -1
+}
+
+func (ts *Transformers) names() []string {
+	names := make([]string, 0, len(*ts))
+	for _, transformer := range *ts {
+		names = append(names, transformer.Name())
+	}
+	return names
+}
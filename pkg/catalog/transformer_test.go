@@ -0,0 +1,158 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package catalog_test
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Peripli/service-manager/pkg/catalog"
+	"github.com/Peripli/service-manager/pkg/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type transformer struct {
+	name      string
+	transform func(ctx context.Context, req *catalog.Request, next catalog.Handler) error
+}
+
+func (t *transformer) Name() string {
+	return t.name
+}
+
+func (t *transformer) TransformCatalog(ctx context.Context, req *catalog.Request, next catalog.Handler) error {
+	return t.transform(ctx, req, next)
+}
+
+func recordingTransformer(name string, calls *[]string) catalog.Transformer {
+	return &transformer{
+		name: name,
+		transform: func(ctx context.Context, req *catalog.Request, next catalog.Handler) error {
+			*calls = append(*calls, name)
+			return next.Handle(ctx, req)
+		},
+	}
+}
+
+var _ = Describe("Transformers", func() {
+	var (
+		transformers catalog.Transformers
+		calls        []string
+		request      *catalog.Request
+		handled      *catalog.Catalog
+		handler      catalog.Handler
+	)
+
+	BeforeEach(func() {
+		transformers = catalog.Transformers{}
+		calls = nil
+		handled = nil
+		request = &catalog.Request{
+			Broker: &types.Broker{Name: "broker"},
+			Catalog: &catalog.Catalog{
+				Services: []*catalog.Service{
+					{ID: "service-id", Name: "service", Plans: []*catalog.Plan{{ID: "plan-id", Name: "plan"}}},
+				},
+			},
+		}
+		handler = catalog.HandlerFunc(func(ctx context.Context, req *catalog.Request) error {
+			handled = req.Catalog
+			return nil
+		})
+	})
+
+	Describe("Chain", func() {
+		It("invokes the handler when there are no transformers", func() {
+			Expect(transformers.Chain(handler).Handle(context.TODO(), request)).To(Succeed())
+			Expect(handled).To(Equal(request.Catalog))
+		})
+
+		It("invokes the transformers in order of registration", func() {
+			transformers.Register(recordingTransformer("first", &calls), recordingTransformer("second", &calls))
+
+			Expect(transformers.Chain(handler).Handle(context.TODO(), request)).To(Succeed())
+			Expect(calls).To(Equal([]string{"first", "second"}))
+			Expect(handled).ToNot(BeNil())
+		})
+
+		It("allows transformers to modify the catalog", func() {
+			transformers.Register(&transformer{
+				name: "rename",
+				transform: func(ctx context.Context, req *catalog.Request, next catalog.Handler) error {
+					req.Catalog.Services[0].Name = "renamed"
+					req.Catalog.Services[0].Plans = nil
+					return next.Handle(ctx, req)
+				},
+			})
+
+			Expect(transformers.Chain(handler).Handle(context.TODO(), request)).To(Succeed())
+			Expect(handled.Services[0].Name).To(Equal("renamed"))
+			Expect(handled.Services[0].Plans).To(BeEmpty())
+		})
+
+		It("stops the chain and propagates the error when a transformer rejects the catalog", func() {
+			rejectErr := errors.New("rejected")
+			transformers.Register(&transformer{
+				name: "reject",
+				transform: func(ctx context.Context, req *catalog.Request, next catalog.Handler) error {
+					return rejectErr
+				},
+			}, recordingTransformer("after", &calls))
+
+			Expect(transformers.Chain(handler).Handle(context.TODO(), request)).To(Equal(rejectErr))
+			Expect(calls).To(BeEmpty())
+			Expect(handled).To(BeNil())
+		})
+	})
+
+	Describe("Register", func() {
+		It("panics when a transformer with the same name is already registered", func() {
+			transformers.Register(recordingTransformer("first", &calls))
+			Expect(func() {
+				transformers.Register(recordingTransformer("first", &calls))
+			}).To(Panic())
+		})
+
+		It("panics when a transformer has an empty name", func() {
+			Expect(func() {
+				transformers.Register(recordingTransformer("", &calls))
+			}).To(Panic())
+		})
+	})
+
+	Describe("RegisterBefore and RegisterAfter", func() {
+		BeforeEach(func() {
+			transformers.Register(recordingTransformer("first", &calls), recordingTransformer("last", &calls))
+		})
+
+		It("registers the transformers at the relevant positions", func() {
+			transformers.RegisterBefore("last", recordingTransformer("before-last", &calls))
+			transformers.RegisterAfter("first", recordingTransformer("after-first-1", &calls), recordingTransformer("after-first-2", &calls))
+
+			Expect(transformers.Chain(handler).Handle(context.TODO(), request)).To(Succeed())
+			Expect(calls).To(Equal([]string{"first", "after-first-1", "after-first-2", "before-last", "last"}))
+		})
+
+		It("panics when the referenced transformer is not registered", func() {
+			Expect(func() {
+				transformers.RegisterAfter("missing", recordingTransformer("new", &calls))
+			}).To(Panic())
+		})
+	})
+})
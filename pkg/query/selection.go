@@ -22,6 +22,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Peripli/service-manager/pkg/util"
 
@@ -36,9 +37,11 @@ const (
 	EqualsOperator Operator = "="
 	// NotEqualsOperator takes two operands and tests if they are not equal
 	NotEqualsOperator Operator = "!="
-	// GreaterThanOperator takes two operands and tests if the left is greater than the right
+	// GreaterThanOperator takes two operands and tests if the left is greater than the right. The right operand
+	// must be a number or an RFC3339 timestamp
 	GreaterThanOperator Operator = "gt"
-	// LessThanOperator takes two operands and tests if the left is lesser than the right
+	// LessThanOperator takes two operands and tests if the left is lesser than the right. The right operand
+	// must be a number or an RFC3339 timestamp
 	LessThanOperator Operator = "lt"
 	// InOperator takes two operands and tests if the left is contained in the right
 	InOperator Operator = "in"
@@ -58,7 +61,7 @@ func (op Operator) IsNullable() bool {
 	return op == EqualsOrNilOperator
 }
 
-// IsNumeric returns true if the operator works only with numeric operands
+// IsNumeric returns true if the operator works only with numeric or timestamp operands
 func (op Operator) IsNumeric() bool {
 	return op == LessThanOperator || op == GreaterThanOperator
 }
@@ -122,8 +125,8 @@ func (c Criterion) Validate() error {
 	if c.Operator.IsNullable() && c.Type != FieldQuery {
 		return &util.UnsupportedQueryError{Message: "nullable operations are supported only for field queries"}
 	}
-	if c.Operator.IsNumeric() && !isNumeric(c.RightOp[0]) {
-		return &util.UnsupportedQueryError{Message: fmt.Sprintf("%s is numeric operator, but the right operand %s is not numeric", c.Operator, c.RightOp[0])}
+	if c.Operator.IsNumeric() && !isNumeric(c.RightOp[0]) && !IsTimestamp(c.RightOp[0]) {
+		return &util.UnsupportedQueryError{Message: fmt.Sprintf("%s is numeric operator, but the right operand %s is neither numeric nor an RFC3339 timestamp", c.Operator, c.RightOp[0])}
 	}
 	if strings.ContainsRune(c.LeftOp, Separator) {
 		parts := strings.FieldsFunc(c.LeftOp, func(r rune) bool {
@@ -330,3 +333,9 @@ func isNumeric(str string) bool {
 	_, err = strconv.ParseFloat(str, 64)
 	return err == nil
 }
+
+// IsTimestamp returns true if the operand is an RFC3339 timestamp
+func IsTimestamp(str string) bool {
+	_, err := time.Parse(time.RFC3339, str)
+	return err == nil
+}
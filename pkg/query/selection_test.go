@@ -72,6 +72,10 @@ var _ = Describe("Selection", func() {
 				_, err := AddCriteria(ctx, ByField(LessThanOperator, "leftOp", "5"))
 				Expect(err).ToNot(HaveOccurred())
 			})
+			Specify("With timestamp right operand", func() {
+				_, err := AddCriteria(ctx, ByField(GreaterThanOperator, "leftOp", "2018-10-18T10:00:00.123Z"))
+				Expect(err).ToNot(HaveOccurred())
+			})
 			for _, op := range operators {
 				Specify("With valid operator parameters", func() {
 					_, err := AddCriteria(ctx, ByField(op, "leftOp", "rightop"))
@@ -32,6 +32,7 @@ import (
 	"github.com/Peripli/service-manager/pkg/util"
 	"github.com/Peripli/service-manager/pkg/web"
 	goidc "github.com/coreos/go-oidc"
+	"golang.org/x/oauth2"
 )
 
 type claims struct {
@@ -54,7 +55,8 @@ type OIDCOptions struct {
 	// ClientID is the id of the oauth client used to verify the tokens
 	ClientID string
 
-	// ReadConfigurationFunc is the function used to call the token issuer. If one is not provided, http.DefaultClient.Do will be used
+	// ReadConfigurationFunc is the function used to call the token issuer. If one is not provided, the client from
+	// the context (see oidc.ClientContext) or http.DefaultClient will be used
 	ReadConfigurationFunc util.DoRequestFunc
 }
 
@@ -154,6 +156,8 @@ func getOpenIDConfig(ctx context.Context, options *OIDCOptions) (*http.Response,
 	var readConfigFunc util.DoRequestFunc
 	if options.ReadConfigurationFunc != nil {
 		readConfigFunc = options.ReadConfigurationFunc
+	} else if client, ok := ctx.Value(oauth2.HTTPClient).(*http.Client); ok {
+		readConfigFunc = client.Do
 	} else {
 		readConfigFunc = http.DefaultClient.Do
 	}
@@ -54,9 +54,14 @@ func (raf *requiredAuthnFilter) FilterMatchers() []web.FilterMatcher {
 					web.BrokersURL+"/**",
 					web.PlatformsURL+"/**",
 					web.OSBURL+"/**",
+					web.AggregatedOSBURL+"/**",
 					web.ServiceOfferingsURL+"/**",
 					web.ServicePlansURL+"/**",
 					web.VisibilitiesURL+"/**",
+					web.CatalogOverridesURL+"/**",
+					web.ServiceInstancesURL+"/**",
+					web.ServiceBindingsURL+"/**",
+					web.OperationsURL+"/**",
 				),
 			},
 		},
@@ -19,14 +19,14 @@ package sm
 import (
 	"context"
 	"crypto/rand"
-	"crypto/tls"
 	"fmt"
-	"net/http"
 	"time"
 
 	"github.com/Peripli/service-manager/api"
+	"github.com/Peripli/service-manager/api/broker"
 	"github.com/Peripli/service-manager/api/healthcheck"
 	"github.com/Peripli/service-manager/config"
+	"github.com/Peripli/service-manager/pkg/catalog"
 	"github.com/Peripli/service-manager/pkg/log"
 	"github.com/Peripli/service-manager/pkg/security"
 	"github.com/Peripli/service-manager/pkg/server"
@@ -87,8 +87,6 @@ func New(ctx context.Context, cancel context.CancelFunc, env env.Environment) *S
 		panic(fmt.Sprintf("error validating configuration: %s", err))
 	}
 
-	http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: cfg.API.SkipSSLValidation}
-
 	// setup logging
 	ctx = log.Configure(ctx, cfg.Log)
 
@@ -142,6 +140,18 @@ func (smb *ServiceManagerBuilder) Build() *ServiceManager {
 	}
 }
 
+// CatalogTransformers returns the transformers that are invoked on broker catalogs before they are stored.
+// Additional transformers can be registered using the relevant methods of the result.
+func (smb *ServiceManagerBuilder) CatalogTransformers() *catalog.Transformers {
+	for _, controller := range smb.Controllers {
+		if brokerController, ok := controller.(*broker.Controller); ok {
+			return &brokerController.CatalogTransformers
+		}
+	}
+	log.D().Panic("broker controller is not registered")
+	return nil
+}
+
 func (smb *ServiceManagerBuilder) installHealth() {
 	if len(smb.HealthIndicators()) > 0 {
 		smb.RegisterControllers(healthcheck.NewController(smb.HealthIndicators(), smb.HealthAggregationPolicy()))
@@ -18,7 +18,9 @@
 package types
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"errors"
@@ -26,6 +28,43 @@ import (
 	"github.com/Peripli/service-manager/pkg/util"
 )
 
+const (
+	// CatalogValidationStrict rejects broker catalogs which do not conform to the OSB specification
+	CatalogValidationStrict = "strict"
+
+	// CatalogValidationLenient only logs warnings for broker catalogs which do not conform to the OSB specification
+	CatalogValidationLenient = "lenient"
+
+	// ParametersValidationEnabled rejects OSB requests whose parameters do not conform to the JSON schemas of the plan
+	ParametersValidationEnabled = "enabled"
+
+	// ParametersValidationDisabled forwards the parameters of OSB requests to the broker without validating them
+	ParametersValidationDisabled = "disabled"
+
+	// PlatformContextEnabled adds the id and type of the Service Manager platform to the context of OSB requests
+	PlatformContextEnabled = "enabled"
+
+	// PlatformContextDisabled forwards the context of OSB requests to the broker as provided by the platform
+	PlatformContextDisabled = "disabled"
+
+	// BrokerStateEnabled is the state of brokers which receive all OSB requests
+	BrokerStateEnabled = "enabled"
+
+	// BrokerStateDisabled is the state of brokers which receive no OSB requests and whose catalog is hidden
+	BrokerStateDisabled = "disabled"
+
+	// BrokerStateMaintenance is the state of brokers which receive only OSB requests that do not create or
+	// modify service instances and bindings
+	BrokerStateMaintenance = "maintenance"
+
+	// BrokerTypeExternal is the type of brokers whose catalog and OSB operations are provided by an external service broker
+	BrokerTypeExternal = "external"
+
+	// BrokerTypeVirtual is the type of brokers which are defined entirely inside Service Manager. Their catalog is
+	// provided during registration and their OSB operations are answered by Service Manager itself.
+	BrokerTypeVirtual = "virtual"
+)
+
 // Brokers struct
 type Brokers struct {
 	Brokers []*Broker `json:"service_brokers"`
@@ -40,6 +79,35 @@ type Broker struct {
 	UpdatedAt   time.Time    `json:"updated_at"`
 	BrokerURL   string       `json:"broker_url"`
 	Credentials *Credentials `json:"credentials,omitempty" structs:"-"`
+	TLS         *TLSTrust    `json:"tls,omitempty" structs:"-"`
+	Resilience  *Resilience  `json:"resilience,omitempty" structs:"-"`
+
+	// PreviousCredentials are the credentials replaced during the last credentials rotation. They are still
+	// tried until PreviousCredentialsExpireAt if the broker rejects the current credentials.
+	PreviousCredentials         *Credentials `json:"-" structs:"-"`
+	PreviousCredentialsExpireAt time.Time    `json:"-" structs:"-"`
+
+	CatalogValidation    string `json:"catalog_validation,omitempty"`
+	ParametersValidation string `json:"parameters_validation,omitempty"`
+	PlatformContext      string `json:"platform_context,omitempty"`
+	State                string `json:"state,omitempty"`
+	Type                 string `json:"type,omitempty"`
+
+	// APIVersion is the highest OSB API version supported by the broker as detected during registration
+	APIVersion string `json:"api_version,omitempty"`
+
+	// Catalog is the OSB catalog of a virtual broker as provided during registration
+	Catalog json.RawMessage `json:"catalog,omitempty" structs:"-"`
+
+	// BindingCredentials are the static credentials returned by a virtual broker for each binding
+	BindingCredentials json.RawMessage `json:"binding_credentials,omitempty" structs:"-"`
+
+	// CatalogETag and CatalogLastModified are the validators returned by the broker with the last stored catalog.
+	// They are used for conditional catalog requests.
+	CatalogETag         string `json:"-" structs:"-"`
+	CatalogLastModified string `json:"-" structs:"-"`
+	// CatalogHash is the hash of the last stored catalog
+	CatalogHash string `json:"-" structs:"-"`
 
 	Services []*ServiceOffering `json:"services,omitempty" structs:"-"`
 
@@ -51,14 +119,51 @@ func (b *Broker) Validate() error {
 	if b.Name == "" {
 		return errors.New("missing broker name")
 	}
-	if b.BrokerURL == "" {
+	if b.Type != "" && b.Type != BrokerTypeExternal && b.Type != BrokerTypeVirtual {
+		return fmt.Errorf("unsupported broker type %s", b.Type)
+	}
+	if b.Type != BrokerTypeVirtual && b.BrokerURL == "" {
 		return errors.New("missing broker url")
 	}
+	if b.Type != BrokerTypeVirtual && (len(b.Catalog) != 0 || len(b.BindingCredentials) != 0) {
+		return errors.New("catalog and binding credentials can be provided only for virtual brokers")
+	}
 
 	if err := b.Labels.Validate(); err != nil {
 		return err
 	}
 
+	if b.CatalogValidation != "" && b.CatalogValidation != CatalogValidationStrict && b.CatalogValidation != CatalogValidationLenient {
+		return fmt.Errorf("unsupported catalog validation %s", b.CatalogValidation)
+	}
+
+	if b.ParametersValidation != "" && b.ParametersValidation != ParametersValidationEnabled && b.ParametersValidation != ParametersValidationDisabled {
+		return fmt.Errorf("unsupported parameters validation %s", b.ParametersValidation)
+	}
+
+	if b.PlatformContext != "" && b.PlatformContext != PlatformContextEnabled && b.PlatformContext != PlatformContextDisabled {
+		return fmt.Errorf("unsupported platform context %s", b.PlatformContext)
+	}
+
+	if b.State != "" && b.State != BrokerStateEnabled && b.State != BrokerStateDisabled && b.State != BrokerStateMaintenance {
+		return fmt.Errorf("unsupported broker state %s", b.State)
+	}
+
+	if b.TLS != nil {
+		if err := b.TLS.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if b.Resilience != nil {
+		if err := b.Resilience.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if b.Type == BrokerTypeVirtual {
+		return nil
+	}
 	if b.Credentials == nil {
 		return errors.New("missing credentials")
 	}
@@ -66,6 +171,43 @@ func (b *Broker) Validate() error {
 
 }
 
+// TransformSecrets transforms the secrets of the current and previous broker credentials and the binding credentials
+// of virtual brokers using the transformation function
+func (b *Broker) TransformSecrets(ctx context.Context, transform TransformFunc) error {
+	if b.Credentials != nil {
+		if err := b.Credentials.TransformSecrets(ctx, transform); err != nil {
+			return err
+		}
+	}
+	if b.PreviousCredentials != nil {
+		if err := b.PreviousCredentials.TransformSecrets(ctx, transform); err != nil {
+			return err
+		}
+	}
+	if len(b.BindingCredentials) != 0 {
+		transformed, err := transform(ctx, b.BindingCredentials)
+		if err != nil {
+			return err
+		}
+		b.BindingCredentials = transformed
+	}
+	return nil
+}
+
+// IsVirtual returns true if the broker is defined entirely inside Service Manager
+func (b *Broker) IsVirtual() bool {
+	return b.Type == BrokerTypeVirtual
+}
+
+// ActivePreviousCredentials returns the credentials replaced during the last credentials rotation or nil if their
+// grace period has expired
+func (b *Broker) ActivePreviousCredentials() *Credentials {
+	if b.PreviousCredentials == nil || !time.Now().Before(b.PreviousCredentialsExpireAt) {
+		return nil
+	}
+	return b.PreviousCredentials
+}
+
 // MarshalJSON override json serialization for http response
 func (b *Broker) MarshalJSON() ([]byte, error) {
 	type B Broker
@@ -0,0 +1,203 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/util"
+)
+
+// CatalogOverrides struct
+type CatalogOverrides struct {
+	CatalogOverrides []*CatalogOverride `json:"catalog_overrides"`
+}
+
+// CatalogOverride is a local adjustment of a service offering or a service plan that is stored separately
+// from the broker catalog and is therefore preserved when the broker catalog is resynced
+type CatalogOverride struct {
+	ID                string          `json:"id"`
+	ServiceOfferingID string          `json:"service_offering_id,omitempty"`
+	ServicePlanID     string          `json:"service_plan_id,omitempty"`
+	Description       string          `json:"description,omitempty"`
+	Hidden            bool            `json:"hidden"`
+	Metadata          json.RawMessage `json:"metadata,omitempty"`
+	CreatedAt         time.Time       `json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+}
+
+// Validate implements InputValidator and verifies all mandatory fields are populated
+func (co *CatalogOverride) Validate() error {
+	if co.ServiceOfferingID == "" && co.ServicePlanID == "" {
+		return errors.New("missing catalog override service offering id or service plan id")
+	}
+	if co.ServiceOfferingID != "" && co.ServicePlanID != "" {
+		return errors.New("catalog override must target either a service offering or a service plan but not both")
+	}
+	if util.HasRFC3986ReservedSymbols(co.ID) {
+		return fmt.Errorf("%s contains invalid character(s)", co.ID)
+	}
+	if len(co.Metadata) != 0 {
+		metadata := make(map[string]json.RawMessage)
+		if err := json.Unmarshal(co.Metadata, &metadata); err != nil {
+			return errors.New("catalog override metadata must be a JSON object")
+		}
+	}
+	return nil
+}
+
+// MarshalJSON override json serialization for http response
+func (co *CatalogOverride) MarshalJSON() ([]byte, error) {
+	type CO CatalogOverride
+	toMarshal := struct {
+		*CO
+		CreatedAt *string `json:"created_at,omitempty"`
+		UpdatedAt *string `json:"updated_at,omitempty"`
+	}{
+		CO: (*CO)(co),
+	}
+	if !co.CreatedAt.IsZero() {
+		str := util.ToRFCFormat(co.CreatedAt)
+		toMarshal.CreatedAt = &str
+	}
+	if !co.UpdatedAt.IsZero() {
+		str := util.ToRFCFormat(co.UpdatedAt)
+		toMarshal.UpdatedAt = &str
+	}
+	return json.Marshal(toMarshal)
+}
+
+// ApplyToServiceOffering merges the override into the provided service offering
+func (co *CatalogOverride) ApplyToServiceOffering(serviceOffering *ServiceOffering) error {
+	if co.Description != "" {
+		serviceOffering.Description = co.Description
+	}
+	metadata, err := mergeMetadata(serviceOffering.Metadata, co.Metadata)
+	if err != nil {
+		return err
+	}
+	serviceOffering.Metadata = metadata
+	return nil
+}
+
+// ApplyToServicePlan merges the override into the provided service plan
+func (co *CatalogOverride) ApplyToServicePlan(servicePlan *ServicePlan) error {
+	if co.Description != "" {
+		servicePlan.Description = co.Description
+	}
+	metadata, err := mergeMetadata(servicePlan.Metadata, co.Metadata)
+	if err != nil {
+		return err
+	}
+	servicePlan.Metadata = metadata
+	return nil
+}
+
+// ApplyToServiceOfferings merges the overrides into the provided service offerings and their plans.
+// Hidden service offerings and plans are removed only if removeHidden is true.
+func (cos *CatalogOverrides) ApplyToServiceOfferings(serviceOfferings []*ServiceOffering, removeHidden bool) ([]*ServiceOffering, error) {
+	offeringOverrides, planOverrides := cos.index()
+	result := make([]*ServiceOffering, 0, len(serviceOfferings))
+	for _, serviceOffering := range serviceOfferings {
+		if override, found := offeringOverrides[serviceOffering.ID]; found {
+			if override.Hidden && removeHidden {
+				continue
+			}
+			if err := override.ApplyToServiceOffering(serviceOffering); err != nil {
+				return nil, err
+			}
+		}
+		plans, err := cos.applyToServicePlans(planOverrides, serviceOffering.Plans, removeHidden)
+		if err != nil {
+			return nil, err
+		}
+		if serviceOffering.Plans != nil {
+			serviceOffering.Plans = plans
+		}
+		result = append(result, serviceOffering)
+	}
+	return result, nil
+}
+
+// ApplyToServicePlans merges the overrides into the provided service plans
+func (cos *CatalogOverrides) ApplyToServicePlans(servicePlans []*ServicePlan) error {
+	_, planOverrides := cos.index()
+	_, err := cos.applyToServicePlans(planOverrides, servicePlans, false)
+	return err
+}
+
+func (cos *CatalogOverrides) applyToServicePlans(planOverrides map[string]*CatalogOverride, servicePlans []*ServicePlan, removeHidden bool) ([]*ServicePlan, error) {
+	result := make([]*ServicePlan, 0, len(servicePlans))
+	for _, servicePlan := range servicePlans {
+		if override, found := planOverrides[servicePlan.ID]; found {
+			if override.Hidden && removeHidden {
+				continue
+			}
+			if err := override.ApplyToServicePlan(servicePlan); err != nil {
+				return nil, err
+			}
+		}
+		result = append(result, servicePlan)
+	}
+	return result, nil
+}
+
+func (cos *CatalogOverrides) index() (map[string]*CatalogOverride, map[string]*CatalogOverride) {
+	offeringOverrides := make(map[string]*CatalogOverride)
+	planOverrides := make(map[string]*CatalogOverride)
+	for _, override := range cos.CatalogOverrides {
+		if override.ServiceOfferingID != "" {
+			offeringOverrides[override.ServiceOfferingID] = override
+		}
+		if override.ServicePlanID != "" {
+			planOverrides[override.ServicePlanID] = override
+		}
+	}
+	return offeringOverrides, planOverrides
+}
+
+// mergeMetadata merges the top level keys of the override metadata into the original metadata.
+// Keys with null values in the override are removed.
+func mergeMetadata(original, override json.RawMessage) (json.RawMessage, error) {
+	if len(override) == 0 {
+		return original, nil
+	}
+	merged := make(map[string]json.RawMessage)
+	if len(original) != 0 {
+		if err := json.Unmarshal(original, &merged); err != nil {
+			return nil, fmt.Errorf("could not unmarshal metadata: %s", err)
+		}
+		if merged == nil {
+			merged = make(map[string]json.RawMessage)
+		}
+	}
+	overrides := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(override, &overrides); err != nil {
+		return nil, fmt.Errorf("could not unmarshal metadata override: %s", err)
+	}
+	for key, value := range overrides {
+		if string(value) == "null" {
+			delete(merged, key)
+			continue
+		}
+		merged[key] = value
+	}
+	return json.Marshal(merged)
+}
@@ -17,10 +17,13 @@
 package types
 
 import (
+	"context"
 	"crypto/rand"
+	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 )
 
 // Basic basic credentials
@@ -29,10 +32,55 @@ type Basic struct {
 	Password string `json:"password,omitempty"`
 }
 
+// OAuth2 client credentials used to obtain an access token from an OAuth2 token endpoint
+type OAuth2 struct {
+	TokenURL     string   `json:"token_url,omitempty"`
+	ClientID     string   `json:"client_id,omitempty"`
+	ClientSecret string   `json:"client_secret,omitempty"`
+	Scopes       []string `json:"scopes,omitempty"`
+}
+
+// TLS client certificate and private key in PEM format used for mutual TLS authentication
+type TLS struct {
+	Certificate string `json:"certificate,omitempty"`
+	Key         string `json:"key,omitempty"`
+}
+
 // Credentials credentials
 type Credentials struct {
-	Basic   *Basic `json:"basic,omitempty"`
-	Details []byte `json:"-"`
+	Basic   *Basic  `json:"basic,omitempty"`
+	OAuth2  *OAuth2 `json:"oauth2,omitempty"`
+	TLS     *TLS    `json:"tls,omitempty"`
+	Details []byte  `json:"-"`
+}
+
+// TransformFunc transforms a secret value (for example encrypts or decrypts it)
+type TransformFunc func(ctx context.Context, secret []byte) ([]byte, error)
+
+// TransformSecrets applies the transformation to all secret values in the credentials - the basic password,
+// the OAuth2 client secret and the TLS private key. Empty values are left untouched.
+func (c *Credentials) TransformSecrets(ctx context.Context, transform TransformFunc) error {
+	secrets := make([]*string, 0, 3)
+	if c.Basic != nil {
+		secrets = append(secrets, &c.Basic.Password)
+	}
+	if c.OAuth2 != nil {
+		secrets = append(secrets, &c.OAuth2.ClientSecret)
+	}
+	if c.TLS != nil {
+		secrets = append(secrets, &c.TLS.Key)
+	}
+	for _, secret := range secrets {
+		if *secret == "" {
+			continue
+		}
+		transformed, err := transform(ctx, []byte(*secret))
+		if err != nil {
+			return err
+		}
+		*secret = string(transformed)
+	}
+	return nil
 }
 
 func (c *Credentials) MarshalJSON() ([]byte, error) {
@@ -46,14 +94,38 @@ func (c *Credentials) MarshalJSON() ([]byte, error) {
 
 // Validate implements InputValidator and verifies all mandatory fields are populated
 func (c *Credentials) Validate() error {
-	if c.Basic == nil {
+	if c.Basic == nil && c.OAuth2 == nil && c.TLS == nil {
 		return errors.New("missing broker credentials")
 	}
-	if c.Basic.Username == "" {
-		return errors.New("missing broker username")
+	if c.Basic != nil {
+		if c.Basic.Username == "" {
+			return errors.New("missing broker username")
+		}
+		if c.Basic.Password == "" {
+			return errors.New("missing broker password")
+		}
+	}
+	if c.OAuth2 != nil {
+		if c.OAuth2.TokenURL == "" {
+			return errors.New("missing broker oauth2 token url")
+		}
+		if c.OAuth2.ClientID == "" {
+			return errors.New("missing broker oauth2 client id")
+		}
+		if c.OAuth2.ClientSecret == "" {
+			return errors.New("missing broker oauth2 client secret")
+		}
 	}
-	if c.Basic.Password == "" {
-		return errors.New("missing broker password")
+	if c.TLS != nil {
+		if c.TLS.Certificate == "" {
+			return errors.New("missing broker tls certificate")
+		}
+		if c.TLS.Key == "" {
+			return errors.New("missing broker tls key")
+		}
+		if _, err := tls.X509KeyPair([]byte(c.TLS.Certificate), []byte(c.TLS.Key)); err != nil {
+			return fmt.Errorf("invalid broker tls certificate or key: %s", err)
+		}
 	}
 	return nil
 }
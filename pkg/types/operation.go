@@ -0,0 +1,102 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package types
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/util"
+)
+
+// The operation states match the states of the OSB last operation responses
+const (
+	// OperationStateInProgress is the state of the asynchronous operations which have not finished yet
+	OperationStateInProgress = "in progress"
+
+	// OperationStateSucceeded is the state of the asynchronous operations which finished successfully
+	OperationStateSucceeded = "succeeded"
+
+	// OperationStateFailed is the state of the asynchronous operations which failed or did not finish in time
+	OperationStateFailed = "failed"
+
+	// ServiceInstanceResourceType is the resource type of the operations on service instances
+	ServiceInstanceResourceType = "service_instance"
+
+	// ServiceBindingResourceType is the resource type of the operations on service bindings
+	ServiceBindingResourceType = "service_binding"
+)
+
+// Operations struct
+type Operations struct {
+	Operations []*Operation `json:"operations"`
+}
+
+// Operation is an asynchronous OSB operation on a service instance or binding accepted by a broker
+type Operation struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	State string `json:"state"`
+
+	ResourceType string `json:"resource_type"`
+	ResourceID   string `json:"resource_id"`
+	// ServiceInstanceID is the id of the service instance of the service binding operations
+	ServiceInstanceID string `json:"service_instance_id,omitempty"`
+	BrokerID          string `json:"broker_id"`
+
+	// Token is the operation value returned by the broker which identifies the operation in the last operation requests
+	Token       string `json:"operation,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	// Deadline is the time after which the operation is considered failed if it has not finished.
+	// Operations without a deadline are polled until they finish.
+	Deadline time.Time `json:"deadline"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// InProgress returns true if the operation has not finished yet
+func (o *Operation) InProgress() bool {
+	return o.State == OperationStateInProgress
+}
+
+// MarshalJSON override json serialization for http response
+func (o *Operation) MarshalJSON() ([]byte, error) {
+	type O Operation
+	toMarshal := struct {
+		*O
+		Deadline  *string `json:"deadline,omitempty"`
+		CreatedAt *string `json:"created_at,omitempty"`
+		UpdatedAt *string `json:"updated_at,omitempty"`
+	}{
+		O: (*O)(o),
+	}
+	if !o.Deadline.IsZero() {
+		str := util.ToRFCFormat(o.Deadline)
+		toMarshal.Deadline = &str
+	}
+	if !o.CreatedAt.IsZero() {
+		str := util.ToRFCFormat(o.CreatedAt)
+		toMarshal.CreatedAt = &str
+	}
+	if !o.UpdatedAt.IsZero() {
+		str := util.ToRFCFormat(o.UpdatedAt)
+		toMarshal.UpdatedAt = &str
+	}
+	return json.Marshal(toMarshal)
+}
@@ -0,0 +1,44 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package types
+
+import "time"
+
+// OrphanMitigation is a pending compensating deprovision or unbind request for a service instance or binding which
+// may have been left orphaned at the broker by a failed provision or bind request
+type OrphanMitigation struct {
+	ID           string `json:"id"`
+	BrokerID     string `json:"broker_id"`
+	ResourceType string `json:"resource_type"`
+	// Path is the OSB path of the service instance or binding relative to the broker URL
+	Path      string `json:"path"`
+	ServiceID string `json:"service_id"`
+	PlanID    string `json:"plan_id"`
+
+	Attempts      int       `json:"attempts"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	LastError     string    `json:"last_error,omitempty"`
+
+	// DeletionAccepted is set when the broker accepts the compensating request asynchronously. The orphan mitigation
+	// is kept until the last operation of the service instance or binding reports that the deletion has finished.
+	DeletionAccepted bool `json:"deletion_accepted"`
+	// Operation is the operation value returned by the broker with the accepted compensating request
+	Operation string `json:"operation,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -17,6 +17,7 @@
 package types
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"time"
@@ -24,6 +25,7 @@ import (
 	"errors"
 
 	"github.com/Peripli/service-manager/pkg/util"
+	"github.com/Peripli/service-manager/pkg/web"
 )
 
 // Platform platform struct
@@ -37,6 +39,29 @@ type Platform struct {
 	Credentials *Credentials `json:"credentials,omitempty"`
 }
 
+// PlatformFromContext returns the platform which sent the request or nil if the request was not sent by a platform
+func PlatformFromContext(ctx context.Context) *Platform {
+	user, ok := web.UserFromContext(ctx)
+	if !ok || user.Data == nil {
+		return nil
+	}
+	platform := &Platform{}
+	if err := user.Data.Data(platform); err != nil || platform.ID == "" {
+		return nil
+	}
+	return platform
+}
+
+// PlatformIDFromContext returns the id of the platform which sent the request or an empty string if the request
+// was not sent by a platform
+func PlatformIDFromContext(ctx context.Context) string {
+	platform := PlatformFromContext(ctx)
+	if platform == nil {
+		return ""
+	}
+	return platform.ID
+}
+
 // MarshalJSON override json serialization for http response
 func (p *Platform) MarshalJSON() ([]byte, error) {
 	type P Platform
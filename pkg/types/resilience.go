@@ -0,0 +1,65 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package types
+
+import (
+	"errors"
+	"time"
+)
+
+// Resilience configures how the Service Manager protects itself from slow and failing service brokers. The timeouts
+// are in seconds and zero values keep the defaults of the Service Manager.
+type Resilience struct {
+	// ConnectTimeout is the time to wait for a connection to the broker to be established
+	ConnectTimeout int `json:"connect_timeout,omitempty"`
+	// ResponseTimeout is the time to wait for the response headers of the broker after the request is sent
+	ResponseTimeout int `json:"response_timeout,omitempty"`
+	// Retries is the number of times failed GET requests towards the broker are retried
+	Retries int `json:"retries,omitempty"`
+	// CircuitBreakerThreshold is the number of consecutive failed requests after which the requests towards the
+	// broker are rejected without being sent; 0 disables the circuit breaker
+	CircuitBreakerThreshold int `json:"circuit_breaker_threshold,omitempty"`
+	// CircuitBreakerTimeout is the time for which the requests are rejected before a trial request is sent to the broker
+	CircuitBreakerTimeout int `json:"circuit_breaker_timeout,omitempty"`
+}
+
+// Validate implements InputValidator and verifies that the settings are not negative and that the circuit breaker,
+// if enabled, has a timeout
+func (r *Resilience) Validate() error {
+	if r.ConnectTimeout < 0 || r.ResponseTimeout < 0 || r.Retries < 0 || r.CircuitBreakerThreshold < 0 || r.CircuitBreakerTimeout < 0 {
+		return errors.New("broker resilience settings must not be negative")
+	}
+	if r.CircuitBreakerThreshold > 0 && r.CircuitBreakerTimeout == 0 {
+		return errors.New("broker circuit breaker timeout must be positive")
+	}
+	return nil
+}
+
+// ConnectTimeoutDuration returns the connect timeout as a duration
+func (r *Resilience) ConnectTimeoutDuration() time.Duration {
+	return time.Duration(r.ConnectTimeout) * time.Second
+}
+
+// ResponseTimeoutDuration returns the response timeout as a duration
+func (r *Resilience) ResponseTimeoutDuration() time.Duration {
+	return time.Duration(r.ResponseTimeout) * time.Second
+}
+
+// CircuitBreakerTimeoutDuration returns the circuit breaker timeout as a duration
+func (r *Resilience) CircuitBreakerTimeoutDuration() time.Duration {
+	return time.Duration(r.CircuitBreakerTimeout) * time.Second
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package types
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/util"
+)
+
+// ServiceBindings struct
+type ServiceBindings struct {
+	ServiceBindings []*ServiceBinding `json:"service_bindings"`
+}
+
+// ServiceBinding is a service binding created through the OSB API of the Service Manager
+type ServiceBinding struct {
+	ID                string          `json:"id"`
+	ServiceInstanceID string          `json:"service_instance_id"`
+	BrokerID          string          `json:"broker_id"`
+	PlatformID        string          `json:"platform_id,omitempty"`
+	Context           json.RawMessage `json:"context,omitempty"`
+	// Parameters are the configuration parameters of the service binding as provided by the platform
+	Parameters json.RawMessage `json:"parameters,omitempty"`
+	// Credentials are the credentials returned by the broker. They are stored encrypted and are never exposed by the
+	// Service Manager API.
+	Credentials json.RawMessage `json:"-"`
+
+	// Ready is false until the asynchronous creation of the service binding succeeds
+	Ready bool `json:"ready"`
+	// PendingOperation is the type of the asynchronous operation which is in progress for the service binding
+	PendingOperation string `json:"pending_operation,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TransformSecrets transforms the credentials of the service binding using the transformation function
+func (sb *ServiceBinding) TransformSecrets(ctx context.Context, transform TransformFunc) error {
+	if len(sb.Credentials) == 0 {
+		return nil
+	}
+	transformed, err := transform(ctx, sb.Credentials)
+	if err != nil {
+		return err
+	}
+	sb.Credentials = transformed
+	return nil
+}
+
+// MarshalJSON override json serialization for http response
+func (sb *ServiceBinding) MarshalJSON() ([]byte, error) {
+	type SB ServiceBinding
+	toMarshal := struct {
+		*SB
+		CreatedAt *string `json:"created_at,omitempty"`
+		UpdatedAt *string `json:"updated_at,omitempty"`
+	}{
+		SB: (*SB)(sb),
+	}
+	if !sb.CreatedAt.IsZero() {
+		str := util.ToRFCFormat(sb.CreatedAt)
+		toMarshal.CreatedAt = &str
+	}
+	if !sb.UpdatedAt.IsZero() {
+		str := util.ToRFCFormat(sb.UpdatedAt)
+		toMarshal.UpdatedAt = &str
+	}
+	return json.Marshal(toMarshal)
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package types
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/util"
+)
+
+const (
+	// OperationTypeCreate is the type of the asynchronous provision and bind operations
+	OperationTypeCreate = "create"
+
+	// OperationTypeUpdate is the type of the asynchronous service instance update operations
+	OperationTypeUpdate = "update"
+
+	// OperationTypeDelete is the type of the asynchronous deprovision and unbind operations
+	OperationTypeDelete = "delete"
+)
+
+// ServiceInstances struct
+type ServiceInstances struct {
+	ServiceInstances []*ServiceInstance `json:"service_instances"`
+}
+
+// ServiceInstance is a service instance provisioned through the OSB API of the Service Manager
+type ServiceInstance struct {
+	ID            string          `json:"id"`
+	BrokerID      string          `json:"broker_id"`
+	PlatformID    string          `json:"platform_id,omitempty"`
+	ServicePlanID string          `json:"service_plan_id"`
+	Context       json.RawMessage `json:"context,omitempty"`
+	// Parameters are the configuration parameters of the service instance as provided by the platform
+	Parameters json.RawMessage `json:"parameters,omitempty"`
+	// DashboardURL is the URL of the web-based management user interface returned by the broker
+	DashboardURL string `json:"dashboard_url,omitempty"`
+
+	// Ready is false until the asynchronous provisioning of the service instance succeeds
+	Ready bool `json:"ready"`
+	// PendingOperation is the type of the asynchronous operation which is in progress for the service instance
+	PendingOperation string `json:"pending_operation,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// MarshalJSON override json serialization for http response
+func (si *ServiceInstance) MarshalJSON() ([]byte, error) {
+	type SI ServiceInstance
+	toMarshal := struct {
+		*SI
+		CreatedAt *string `json:"created_at,omitempty"`
+		UpdatedAt *string `json:"updated_at,omitempty"`
+	}{
+		SI: (*SI)(si),
+	}
+	if !si.CreatedAt.IsZero() {
+		str := util.ToRFCFormat(si.CreatedAt)
+		toMarshal.CreatedAt = &str
+	}
+	if !si.UpdatedAt.IsZero() {
+		str := util.ToRFCFormat(si.UpdatedAt)
+		toMarshal.UpdatedAt = &str
+	}
+	return json.Marshal(toMarshal)
+}
@@ -42,12 +42,14 @@ type ServiceOffering struct {
 	InstancesRetrievable bool   `json:"instances_retrievable"`
 	BindingsRetrievable  bool   `json:"bindings_retrievable"`
 	PlanUpdatable        bool   `json:"plan_updateable"`
+	AllowContextUpdates  bool   `json:"allow_context_updates"`
 	CatalogID            string `json:"catalog_id"`
 	CatalogName          string `json:"catalog_name"`
 
-	Tags     json.RawMessage `json:"tags,omitempty"`
-	Requires json.RawMessage `json:"requires,omitempty"`
-	Metadata json.RawMessage `json:"metadata,omitempty"`
+	Tags            json.RawMessage `json:"tags,omitempty"`
+	Requires        json.RawMessage `json:"requires,omitempty"`
+	Metadata        json.RawMessage `json:"metadata,omitempty"`
+	DashboardClient json.RawMessage `json:"dashboard_client,omitempty"`
 
 	BrokerID string         `json:"broker_id"`
 	Plans    []*ServicePlan `json:"plans"`
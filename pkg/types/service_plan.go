@@ -37,24 +37,82 @@ type ServicePlan struct {
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 
-	CatalogID     string `json:"catalog_id"`
-	CatalogName   string `json:"catalog_name"`
-	Free          bool   `json:"free"`
-	Bindable      bool   `json:"bindable"`
-	PlanUpdatable bool   `json:"plan_updateable"`
+	CatalogID   string `json:"catalog_id"`
+	CatalogName string `json:"catalog_name"`
 
-	Metadata json.RawMessage `json:"metadata,omitempty"`
-	Schemas  json.RawMessage `json:"schemas,omitempty"`
+	// Free, Bindable, PlanUpdatable and BindingsRetrievable are nil if the broker does not specify them for the plan.
+	// Use IsFree, IsBindable, IsPlanUpdatable and IsBindingsRetrievable to get their effective values.
+	Free                   *bool `json:"free,omitempty"`
+	Bindable               *bool `json:"bindable,omitempty"`
+	PlanUpdatable          *bool `json:"plan_updateable,omitempty"`
+	BindingsRetrievable    *bool `json:"bindings_retrievable,omitempty"`
+	MaximumPollingDuration int   `json:"maximum_polling_duration,omitempty"`
+
+	Metadata        json.RawMessage `json:"metadata,omitempty"`
+	Schemas         json.RawMessage `json:"schemas,omitempty"`
+	MaintenanceInfo json.RawMessage `json:"maintenance_info,omitempty"`
+
+	// MaintenanceUpdatedAt is the time the maintenance version of the plan was last changed by the broker
+	MaintenanceUpdatedAt time.Time `json:"maintenance_updated_at"`
 
 	ServiceOfferingID string `json:"service_offering_id"`
 }
 
+// MaintenanceInfo is the maintenance information of a service plan as defined in OSB API 2.15
+type MaintenanceInfo struct {
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// IsFree returns whether the plan is free. Plans which do not specify it are free.
+func (sp *ServicePlan) IsFree() bool {
+	return boolValue(sp.Free, true)
+}
+
+// IsBindable returns whether the plan is bindable. Plans which do not specify it inherit it from their service offering.
+func (sp *ServicePlan) IsBindable(serviceOffering *ServiceOffering) bool {
+	return boolValue(sp.Bindable, serviceOffering.Bindable)
+}
+
+// IsPlanUpdatable returns whether the plan of instances of the plan can be updated. Plans which do not specify it
+// inherit it from their service offering.
+func (sp *ServicePlan) IsPlanUpdatable(serviceOffering *ServiceOffering) bool {
+	return boolValue(sp.PlanUpdatable, serviceOffering.PlanUpdatable)
+}
+
+// IsBindingsRetrievable returns whether the bindings of the plan can be fetched from the broker. Plans which do not
+// specify it inherit it from their service offering.
+func (sp *ServicePlan) IsBindingsRetrievable(serviceOffering *ServiceOffering) bool {
+	return boolValue(sp.BindingsRetrievable, serviceOffering.BindingsRetrievable)
+}
+
+func boolValue(value *bool, defaultValue bool) bool {
+	if value == nil {
+		return defaultValue
+	}
+	return *value
+}
+
+// MaintenanceVersion returns the maintenance version of the service plan or empty string if the plan
+// has no maintenance info
+func (sp *ServicePlan) MaintenanceVersion() string {
+	if len(sp.MaintenanceInfo) == 0 {
+		return ""
+	}
+	maintenanceInfo := &MaintenanceInfo{}
+	if err := json.Unmarshal(sp.MaintenanceInfo, maintenanceInfo); err != nil {
+		return ""
+	}
+	return maintenanceInfo.Version
+}
+
 // MarshalJSON override json serialization for http response
 func (sp *ServicePlan) MarshalJSON() ([]byte, error) {
 	type SP ServicePlan
 	toMarshal := struct {
-		CreatedAt *string `json:"created_at,omitempty"`
-		UpdatedAt *string `json:"updated_at,omitempty"`
+		CreatedAt            *string `json:"created_at,omitempty"`
+		UpdatedAt            *string `json:"updated_at,omitempty"`
+		MaintenanceUpdatedAt *string `json:"maintenance_updated_at,omitempty"`
 		*SP
 	}{
 		SP: (*SP)(sp),
@@ -68,6 +126,10 @@ func (sp *ServicePlan) MarshalJSON() ([]byte, error) {
 		str := util.ToRFCFormat(sp.UpdatedAt)
 		toMarshal.UpdatedAt = &str
 	}
+	if !sp.MaintenanceUpdatedAt.IsZero() {
+		str := util.ToRFCFormat(sp.MaintenanceUpdatedAt)
+		toMarshal.MaintenanceUpdatedAt = &str
+	}
 	return json.Marshal(toMarshal)
 }
 
@@ -0,0 +1,37 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package types
+
+import (
+	"crypto/x509"
+	"errors"
+)
+
+// TLSTrust configures how the Service Manager verifies the TLS certificate presented by a service broker
+type TLSTrust struct {
+	CACertificates    string `json:"ca_certificates,omitempty"`
+	ServerName        string `json:"server_name,omitempty"`
+	SkipSSLValidation bool   `json:"skip_ssl_validation,omitempty"`
+}
+
+// Validate implements InputValidator and verifies that the CA certificates, if provided, are valid PEM certificates
+func (t *TLSTrust) Validate() error {
+	if t.CACertificates != "" && !x509.NewCertPool().AppendCertsFromPEM([]byte(t.CACertificates)) {
+		return errors.New("invalid broker tls ca certificates")
+	}
+	return nil
+}
@@ -26,9 +26,10 @@ import (
 
 // HTTPError is an error type that provides error details that Service Manager error handlers would propagate to the client
 type HTTPError struct {
-	ErrorType   string `json:"error,omitempty"`
-	Description string `json:"description,omitempty"`
-	StatusCode  int    `json:"-"`
+	ErrorType   string      `json:"error,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Details     interface{} `json:"details,omitempty"`
+	StatusCode  int         `json:"-"`
 }
 
 // Error HTTPError should implement error
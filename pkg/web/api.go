@@ -48,8 +48,8 @@ type pluginSegment struct {
 }
 
 // newPluginSegment creates a plugin segment with the specified Middleware function and name matching the
-// specified path and method
-func newPluginSegment(name, method, pathPattern string, f Middleware) *pluginSegment {
+// specified method and OSB path both on the OSB API of each broker and on the aggregated OSB API
+func newPluginSegment(name, method, osbPathPattern string, f Middleware) *pluginSegment {
 	return &pluginSegment{
 		NameValue: name,
 		PluginOp:  f,
@@ -57,15 +57,16 @@ func newPluginSegment(name, method, pathPattern string, f Middleware) *pluginSeg
 			{
 				Matchers: []Matcher{
 					Methods(method),
-					Path(pathPattern),
+					Path(OSBURL+"/*"+osbPathPattern, AggregatedOSBURL+osbPathPattern),
 				},
 			},
 		},
 	}
 }
 
+// Run runs the plugin operation. Plugins always receive buffered responses.
 func (dp *pluginSegment) Run(request *Request, next Handler) (*Response, error) {
-	return dp.PluginOp.Run(request, next)
+	return dp.PluginOp.Run(request, bufferingHandler(next))
 }
 
 func (dp *pluginSegment) Name() string {
@@ -204,47 +205,47 @@ func (api *API) decomposePlugin(plug Plugin) []Filter {
 	filters := make([]Filter, 0)
 
 	if p, ok := plug.(CatalogFetcher); ok {
-		filter := newPluginSegment(plug.Name()+":FetchCatalog", http.MethodGet, "/v1/osb/*/v2/catalog/*", MiddlewareFunc(p.FetchCatalog))
+		filter := newPluginSegment(plug.Name()+":FetchCatalog", http.MethodGet, "/v2/catalog/*", MiddlewareFunc(p.FetchCatalog))
 		filters = append(filters, filter)
 	}
 	if p, ok := plug.(ServiceFetcher); ok {
-		filter := newPluginSegment(plug.Name()+":FetchService", http.MethodGet, "/v1/osb/*/v2/service_instances/*", MiddlewareFunc(p.FetchService))
+		filter := newPluginSegment(plug.Name()+":FetchService", http.MethodGet, "/v2/service_instances/*", MiddlewareFunc(p.FetchService))
 		filters = append(filters, filter)
 	}
 	if p, ok := plug.(Provisioner); ok {
-		filter := newPluginSegment(plug.Name()+":Provision", http.MethodPut, "/v1/osb/*/v2/service_instances/*", MiddlewareFunc(p.Provision))
+		filter := newPluginSegment(plug.Name()+":Provision", http.MethodPut, "/v2/service_instances/*", MiddlewareFunc(p.Provision))
 		filters = append(filters, filter)
 	}
 	if p, ok := plug.(ServiceUpdater); ok {
-		filter := newPluginSegment(plug.Name()+":UpdateService", http.MethodPatch, "/v1/osb/*/v2/service_instances/*", MiddlewareFunc(p.UpdateService))
+		filter := newPluginSegment(plug.Name()+":UpdateService", http.MethodPatch, "/v2/service_instances/*", MiddlewareFunc(p.UpdateService))
 		filters = append(filters, filter)
 	}
 	if p, ok := plug.(Deprovisioner); ok {
-		filter := newPluginSegment(plug.Name()+":Deprovision", http.MethodDelete, "/v1/osb/*/v2/service_instances/*", MiddlewareFunc(p.Deprovision))
+		filter := newPluginSegment(plug.Name()+":Deprovision", http.MethodDelete, "/v2/service_instances/*", MiddlewareFunc(p.Deprovision))
 		filters = append(filters, filter)
 	}
 	if p, ok := plug.(BindingFetcher); ok {
-		filter := newPluginSegment(plug.Name()+":FetchBinding", http.MethodGet, "/v1/osb/*/v2/service_instances/*/service_bindings/*", MiddlewareFunc(p.FetchBinding))
+		filter := newPluginSegment(plug.Name()+":FetchBinding", http.MethodGet, "/v2/service_instances/*/service_bindings/*", MiddlewareFunc(p.FetchBinding))
 		filters = append(filters, filter)
 	}
 	if p, ok := plug.(Binder); ok {
-		filter := newPluginSegment(plug.Name()+":Bind", http.MethodPut, "/v1/osb/*/v2/service_instances/*/service_bindings/*", MiddlewareFunc(p.Bind))
+		filter := newPluginSegment(plug.Name()+":Bind", http.MethodPut, "/v2/service_instances/*/service_bindings/*", MiddlewareFunc(p.Bind))
 		filters = append(filters, filter)
 	}
 	if p, ok := plug.(Unbinder); ok {
-		filter := newPluginSegment(plug.Name()+":Unbind", http.MethodDelete, "/v1/osb/*/v2/service_instances/*/service_bindings/*", MiddlewareFunc(p.Unbind))
+		filter := newPluginSegment(plug.Name()+":Unbind", http.MethodDelete, "/v2/service_instances/*/service_bindings/*", MiddlewareFunc(p.Unbind))
 		filters = append(filters, filter)
 	}
 	if p, ok := plug.(InstancePoller); ok {
-		filter := newPluginSegment(plug.Name()+":PollInstance", http.MethodGet, "/v1/osb/*/v2/service_instances/*/last_operation", MiddlewareFunc(p.PollInstance))
+		filter := newPluginSegment(plug.Name()+":PollInstance", http.MethodGet, "/v2/service_instances/*/last_operation", MiddlewareFunc(p.PollInstance))
 		filters = append(filters, filter)
 	}
 	if p, ok := plug.(BindingPoller); ok {
-		filter := newPluginSegment(plug.Name()+":PollBinding", http.MethodGet, "/v1/osb/*/v2/service_instances/*/service_bindings/*/last_operation", MiddlewareFunc(p.PollBinding))
+		filter := newPluginSegment(plug.Name()+":PollBinding", http.MethodGet, "/v2/service_instances/*/service_bindings/*/last_operation", MiddlewareFunc(p.PollBinding))
 		filters = append(filters, filter)
 	}
 	if p, ok := plug.(CredentialsAdapter); ok {
-		filter := newPluginSegment(plug.Name()+":AdaptCredentials", http.MethodPost, "/v1/osb/*/v2/service_instances/*/service_bindings/*/adapt_credentials", MiddlewareFunc(p.AdaptCredentials))
+		filter := newPluginSegment(plug.Name()+":AdaptCredentials", http.MethodPost, "/v2/service_instances/*/service_bindings/*/adapt_credentials", MiddlewareFunc(p.AdaptCredentials))
 		filters = append(filters, filter)
 	}
 
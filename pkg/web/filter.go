@@ -18,6 +18,7 @@ package web
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/Peripli/service-manager/pkg/log"
@@ -36,7 +37,9 @@ type Request struct {
 	Body []byte
 }
 
-// Response defines the attributes of the HTTP response that will be sent to the client
+// Response defines the attributes of the HTTP response that will be sent to the client. Responses created with
+// NewStreamedResponse are copied to the client without being buffered in memory and their Body is empty until
+// they are buffered.
 type Response struct {
 	// StatusCode is the HTTP status code
 	StatusCode int
@@ -46,6 +49,9 @@ type Response struct {
 
 	// Body is the response body (usually JSON)
 	Body []byte
+
+	// stream is the response body which is not read yet
+	stream io.ReadCloser
 }
 
 // Named is an interface that objects that need to be identified by a particular name should implement.
@@ -145,7 +151,14 @@ func (fs Filters) Chain(h Handler) Handler {
 			logger := log.C(r.Context())
 			logger.WithFields(params).Debug("Entering Filter: ", fs[i].Name())
 
-			resp, err := fs[i].Run(r, wrappedFilters[i+1])
+			var nextResponses []*Response
+			next := HandlerFunc(func(r *Request) (*Response, error) {
+				resp, err := wrappedFilters[i+1].Handle(r)
+				nextResponses = append(nextResponses, resp)
+				return resp, err
+			})
+			resp, err := fs[i].Run(r, next)
+			closeDiscardedStreams(nextResponses, resp, err)
 
 			params["err"] = err
 			if resp != nil {
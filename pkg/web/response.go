@@ -0,0 +1,116 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package web
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/Peripli/service-manager/pkg/log"
+)
+
+// NewStreamedResponse returns a response whose body is copied to the client as it is read. The body is closed once
+// it is sent to the client or buffered, or when a filter discards the response.
+func NewStreamedResponse(statusCode int, header http.Header, body io.ReadCloser) *Response {
+	return &Response{
+		StatusCode: statusCode,
+		Header:     header,
+		stream:     body,
+	}
+}
+
+// Streamed returns true if the body of the response is streamed and has not been buffered yet
+func (r *Response) Streamed() bool {
+	return r.stream != nil
+}
+
+// Stream returns the body of a streamed response or nil if the body of the response is buffered
+func (r *Response) Stream() io.ReadCloser {
+	return r.stream
+}
+
+// Buffer reads the body of a streamed response into Body so that it can be inspected and modified.
+// Buffering responses which are not streamed has no effect.
+func (r *Response) Buffer() error {
+	if r.stream == nil {
+		return nil
+	}
+	stream := r.stream
+	r.stream = nil
+	defer stream.Close()
+
+	body, err := ioutil.ReadAll(stream)
+	if err != nil {
+		return err
+	}
+	r.Body = body
+	if r.Header != nil {
+		// the length of the buffered body may change if the body is modified
+		r.Header.Del("Content-Length")
+	}
+	return nil
+}
+
+// closeDiscardedStreams closes the streamed bodies of the responses returned to a filter which are not part of the
+// response of the filter, e.g. because the filter replaced or dropped them or returned an error. The streamed body
+// of the response of the filter is closed once it is sent to the client.
+func closeDiscardedStreams(nextResponses []*Response, resp *Response, err error) {
+	for _, nextResponse := range nextResponses {
+		if nextResponse == nil || nextResponse.stream == nil {
+			continue
+		}
+		if err == nil && resp != nil && resp.stream == nextResponse.stream {
+			continue
+		}
+		if closeErr := nextResponse.stream.Close(); closeErr != nil {
+			log.D().WithError(closeErr).Error("Could not close discarded response body")
+		}
+		nextResponse.stream = nil
+	}
+}
+
+// BufferResponses returns a filter which runs the specified filter with buffered responses. Filters which inspect
+// or modify the response bodies should be registered wrapped with BufferResponses as the responses of some handlers,
+// such as the OSB proxy, are streamed.
+func BufferResponses(filter Filter) Filter {
+	return &bufferingFilter{
+		Filter: filter,
+	}
+}
+
+type bufferingFilter struct {
+	Filter
+}
+
+func (bf *bufferingFilter) Run(req *Request, next Handler) (*Response, error) {
+	return bf.Filter.Run(req, bufferingHandler(next))
+}
+
+// bufferingHandler returns a handler which buffers the responses of the specified handler
+func bufferingHandler(next Handler) Handler {
+	return HandlerFunc(func(req *Request) (*Response, error) {
+		resp, err := next.Handle(req)
+		if err != nil || resp == nil {
+			return resp, err
+		}
+		if err := resp.Buffer(); err != nil {
+			return nil, err
+		}
+		return resp, nil
+	})
+}
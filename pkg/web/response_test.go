@@ -0,0 +1,193 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package web_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/Peripli/service-manager/pkg/web"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type closeRecorder struct {
+	*strings.Reader
+	closed bool
+}
+
+func (cr *closeRecorder) Close() error {
+	cr.closed = true
+	return nil
+}
+
+var _ = Describe("Response", func() {
+	var (
+		body     *closeRecorder
+		response *web.Response
+	)
+
+	BeforeEach(func() {
+		body = &closeRecorder{Reader: strings.NewReader(`{"key":"value"}`)}
+		header := http.Header{}
+		header.Set("Content-Length", "15")
+		response = web.NewStreamedResponse(http.StatusOK, header, body)
+	})
+
+	Describe("NewStreamedResponse", func() {
+		It("does not read the body", func() {
+			Expect(response.Streamed()).To(BeTrue())
+			Expect(response.Body).To(BeEmpty())
+			Expect(response.Stream()).To(Equal(body))
+		})
+	})
+
+	Describe("Buffer", func() {
+		It("reads and closes the streamed body", func() {
+			Expect(response.Buffer()).To(Succeed())
+
+			Expect(string(response.Body)).To(Equal(`{"key":"value"}`))
+			Expect(response.Streamed()).To(BeFalse())
+			Expect(response.Stream()).To(BeNil())
+			Expect(body.closed).To(BeTrue())
+			Expect(response.Header.Get("Content-Length")).To(BeEmpty())
+		})
+
+		It("does not change buffered responses", func() {
+			buffered := &web.Response{StatusCode: http.StatusOK, Body: []byte("body")}
+
+			Expect(buffered.Buffer()).To(Succeed())
+			Expect(string(buffered.Body)).To(Equal("body"))
+		})
+	})
+
+	Describe("BufferResponses", func() {
+		var next web.Handler
+
+		BeforeEach(func() {
+			next = web.HandlerFunc(func(req *web.Request) (*web.Response, error) {
+				return response, nil
+			})
+		})
+
+		It("keeps the name and the matchers of the filter", func() {
+			filter := web.BufferResponses(testFilter{name: "test"})
+
+			Expect(filter.Name()).To(Equal("test"))
+			Expect(filter.FilterMatchers()).To(BeEmpty())
+		})
+
+		It("buffers the responses returned to the filter", func() {
+			var inspectedBody []byte
+			filter := web.BufferResponses(&inspectingFilter{
+				testFilter: testFilter{name: "inspecting"},
+				inspect: func(resp *web.Response) {
+					inspectedBody = resp.Body
+				},
+			})
+
+			resp, err := filter.Run(&web.Request{}, next)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Streamed()).To(BeFalse())
+			Expect(string(inspectedBody)).To(Equal(`{"key":"value"}`))
+		})
+
+		It("does not buffer the responses returned to other filters", func() {
+			resp, err := testFilter{name: "test"}.Run(&web.Request{}, next)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Streamed()).To(BeTrue())
+
+			content, err := ioutil.ReadAll(resp.Stream())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(content)).To(Equal(`{"key":"value"}`))
+		})
+	})
+
+	Describe("Filters chain", func() {
+		var handler web.Handler
+
+		BeforeEach(func() {
+			handler = web.HandlerFunc(func(req *web.Request) (*web.Response, error) {
+				return response, nil
+			})
+		})
+
+		chain := func(run func(request *web.Request, next web.Handler) (*web.Response, error)) web.Handler {
+			return web.Filters{&runFilter{testFilter: testFilter{name: "test"}, run: run}}.Chain(handler)
+		}
+
+		It("does not close the streamed body which is passed on", func() {
+			resp, err := chain(func(request *web.Request, next web.Handler) (*web.Response, error) {
+				return next.Handle(request)
+			}).Handle(&web.Request{Request: httptest.NewRequest(http.MethodGet, "/", nil)})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Stream()).To(Equal(body))
+			Expect(body.closed).To(BeFalse())
+		})
+
+		It("closes the streamed body of a replaced response", func() {
+			resp, err := chain(func(request *web.Request, next web.Handler) (*web.Response, error) {
+				if _, err := next.Handle(request); err != nil {
+					return nil, err
+				}
+				return &web.Response{StatusCode: http.StatusOK, Body: []byte("replaced")}, nil
+			}).Handle(&web.Request{Request: httptest.NewRequest(http.MethodGet, "/", nil)})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(resp.Body)).To(Equal("replaced"))
+			Expect(body.closed).To(BeTrue())
+		})
+
+		It("closes the streamed body if the filter returns an error", func() {
+			_, err := chain(func(request *web.Request, next web.Handler) (*web.Response, error) {
+				if _, err := next.Handle(request); err != nil {
+					return nil, err
+				}
+				return nil, errors.New("filter error")
+			}).Handle(&web.Request{Request: httptest.NewRequest(http.MethodGet, "/", nil)})
+
+			Expect(err).To(HaveOccurred())
+			Expect(body.closed).To(BeTrue())
+		})
+	})
+})
+
+type runFilter struct {
+	testFilter
+	run func(request *web.Request, next web.Handler) (*web.Response, error)
+}
+
+func (f *runFilter) Run(request *web.Request, next web.Handler) (*web.Response, error) {
+	return f.run(request, next)
+}
+
+type inspectingFilter struct {
+	testFilter
+	inspect func(resp *web.Response)
+}
+
+func (f *inspectingFilter) Run(request *web.Request, next web.Handler) (*web.Response, error) {
+	resp, err := next.Handle(request)
+	if err == nil {
+		f.inspect(resp)
+	}
+	return resp, err
+}
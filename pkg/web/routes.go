@@ -15,12 +15,27 @@ const (
 	// VisibilitiesURL is the URL path to manage visibilities
 	VisibilitiesURL = "/" + apiVersion + "/visibilities"
 
+	// CatalogOverridesURL is the URL path to manage local overrides of the service offerings and plans
+	CatalogOverridesURL = "/" + apiVersion + "/catalog_overrides"
+
+	// ServiceInstancesURL is the URL path to fetch the service instances managed through the OSB API
+	ServiceInstancesURL = "/" + apiVersion + "/service_instances"
+
+	// ServiceBindingsURL is the URL path to fetch the service bindings managed through the OSB API
+	ServiceBindingsURL = "/" + apiVersion + "/service_bindings"
+
+	// OperationsURL is the URL path to fetch the asynchronous operations accepted by the brokers
+	OperationsURL = "/" + apiVersion + "/operations"
+
 	// PlatformsURL is the URL path to manage platforms
 	PlatformsURL = "/" + apiVersion + "/platforms"
 
 	// OSBURL is the OSB API base URL path
 	OSBURL = "/" + apiVersion + "/osb"
 
+	// AggregatedOSBURL is the base URL path of the OSB API which aggregates all service brokers
+	AggregatedOSBURL = "/" + apiVersion + "/aggregated_osb"
+
 	// MonitorHealthURL is the path of the healthcheck endpoint
 	MonitorHealthURL = "/" + apiVersion + "/monitor/health"
 
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"path"
 	"runtime"
+	"time"
 
 	"github.com/Peripli/service-manager/pkg/query"
 
@@ -100,6 +101,21 @@ type Warehouse interface {
 	// Visibility provides access to visibilities db operations
 	Visibility() Visibility
 
+	// CatalogOverride provides access to catalog overrides db operations
+	CatalogOverride() CatalogOverride
+
+	// ServiceInstance provides access to service instance db operations
+	ServiceInstance() ServiceInstance
+
+	// ServiceBinding provides access to service binding db operations
+	ServiceBinding() ServiceBinding
+
+	// Operation provides access to asynchronous operation db operations
+	Operation() Operation
+
+	// OrphanMitigation provides access to pending orphan mitigation db operations
+	OrphanMitigation() OrphanMitigation
+
 	// Platform provides access to platform db operations
 	Platform() Platform
 
@@ -220,6 +236,100 @@ type Visibility interface {
 	Update(ctx context.Context, visibility *types.Visibility, labelChanges ...*query.LabelChange) error
 }
 
+// CatalogOverride interface for CatalogOverride db operations
+type CatalogOverride interface {
+	// Create stores a catalog override in SM DB
+	Create(ctx context.Context, catalogOverride *types.CatalogOverride) (string, error)
+
+	// Get retrieves a catalog override using the provided id from SM DB
+	Get(ctx context.Context, id string) (*types.CatalogOverride, error)
+
+	// List retrieves all catalog overrides from SM DB
+	List(ctx context.Context, criteria ...query.Criterion) ([]*types.CatalogOverride, error)
+
+	// Delete deletes a catalog override from SM DB
+	Delete(ctx context.Context, criteria ...query.Criterion) error
+
+	// Update updates a catalog override from SM DB
+	Update(ctx context.Context, catalogOverride *types.CatalogOverride) error
+}
+
+// ServiceInstance interface for ServiceInstance db operations
+type ServiceInstance interface {
+	// Create stores a service instance in SM DB
+	Create(ctx context.Context, serviceInstance *types.ServiceInstance) (string, error)
+
+	// Get retrieves a service instance using the provided id from SM DB
+	Get(ctx context.Context, id string) (*types.ServiceInstance, error)
+
+	// List retrieves all service instances from SM DB
+	List(ctx context.Context, criteria ...query.Criterion) ([]*types.ServiceInstance, error)
+
+	// Delete deletes a service instance from SM DB
+	Delete(ctx context.Context, criteria ...query.Criterion) error
+
+	// Update updates a service instance from SM DB
+	Update(ctx context.Context, serviceInstance *types.ServiceInstance) error
+}
+
+// ServiceBinding interface for ServiceBinding db operations
+type ServiceBinding interface {
+	// Create stores a service binding in SM DB
+	Create(ctx context.Context, serviceBinding *types.ServiceBinding) (string, error)
+
+	// Get retrieves a service binding using the provided id from SM DB
+	Get(ctx context.Context, id string) (*types.ServiceBinding, error)
+
+	// List retrieves all service bindings from SM DB
+	List(ctx context.Context, criteria ...query.Criterion) ([]*types.ServiceBinding, error)
+
+	// Delete deletes a service binding from SM DB
+	Delete(ctx context.Context, criteria ...query.Criterion) error
+
+	// Update updates a service binding from SM DB
+	Update(ctx context.Context, serviceBinding *types.ServiceBinding) error
+}
+
+// Operation interface for Operation db operations
+type Operation interface {
+	// Create stores an operation in SM DB
+	Create(ctx context.Context, operation *types.Operation) (string, error)
+
+	// Get retrieves an operation using the provided id from SM DB
+	Get(ctx context.Context, id string) (*types.Operation, error)
+
+	// List retrieves all operations from SM DB
+	List(ctx context.Context, criteria ...query.Criterion) ([]*types.Operation, error)
+
+	// Delete deletes an operation from SM DB
+	Delete(ctx context.Context, criteria ...query.Criterion) error
+
+	// Update updates an operation from SM DB
+	Update(ctx context.Context, operation *types.Operation) error
+}
+
+// OrphanMitigation interface for OrphanMitigation db operations
+type OrphanMitigation interface {
+	// Create stores an orphan mitigation in SM DB
+	Create(ctx context.Context, orphanMitigation *types.OrphanMitigation) (string, error)
+
+	// Get retrieves an orphan mitigation using the provided id from SM DB
+	Get(ctx context.Context, id string) (*types.OrphanMitigation, error)
+
+	// List retrieves all orphan mitigations from SM DB
+	List(ctx context.Context, criteria ...query.Criterion) ([]*types.OrphanMitigation, error)
+
+	// Delete deletes an orphan mitigation from SM DB
+	Delete(ctx context.Context, criteria ...query.Criterion) error
+
+	// Update updates an orphan mitigation from SM DB
+	Update(ctx context.Context, orphanMitigation *types.OrphanMitigation) error
+
+	// Claim retrieves the orphan mitigations which are due at the specified time and postpones their next attempt by
+	// the lease, so that concurrent Service Manager instances do not process the same orphan mitigations
+	Claim(ctx context.Context, now time.Time, lease time.Duration) ([]*types.OrphanMitigation, error)
+}
+
 // Credentials interface for Credentials db operations
 //go:generate counterfeiter . Credentials
 type Credentials interface {
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/Peripli/service-manager/pkg/query"
 
@@ -275,3 +276,22 @@ func checkSQLNoRows(err error) error {
 func toNullString(s string) sql.NullString {
 	return sql.NullString{String: s, Valid: s != ""}
 }
+
+func toNullBool(b *bool) sql.NullBool {
+	if b == nil {
+		return sql.NullBool{}
+	}
+	return sql.NullBool{Bool: *b, Valid: true}
+}
+
+func getBoolPointer(b sql.NullBool) *bool {
+	if !b.Valid {
+		return nil
+	}
+	value := b.Bool
+	return &value
+}
+
+func toNullTime(t time.Time) pq.NullTime {
+	return pq.NullTime{Time: t, Valid: !t.IsZero()}
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/Peripli/service-manager/pkg/util"
 
@@ -142,6 +143,11 @@ func buildQueryWithParams(extContext sqlx.ExtContext, sqlQuery string, baseTable
 			rightOpBindVar, rightOpQueryValue := buildRightOp(option)
 			sqlOperation := translateOperationToSQLEquivalent(option.Operator)
 			clause := fmt.Sprintf("%s.%s::text %s %s", baseTableName, option.LeftOp, sqlOperation, rightOpBindVar)
+			if option.Operator.IsNumeric() && query.IsTimestamp(option.RightOp[0]) {
+				// timestamps are stored in UTC and have to be compared as such rather than by their text representation
+				clause = fmt.Sprintf("%s.%s %s %s::timestamp", baseTableName, option.LeftOp, sqlOperation, rightOpBindVar)
+				rightOpQueryValue = toUTCTimestamp(option.RightOp[0])
+			}
 			if option.Operator.IsNullable() {
 				clause = fmt.Sprintf("(%s OR %s.%s IS NULL)", clause, baseTableName, option.LeftOp)
 			}
@@ -188,6 +194,14 @@ func buildRightOp(criterion query.Criterion) (string, interface{}) {
 	return rightOpBindVar, rhs
 }
 
+func toUTCTimestamp(rfc3339Timestamp string) string {
+	timestamp, err := time.Parse(time.RFC3339, rfc3339Timestamp)
+	if err != nil {
+		return rfc3339Timestamp
+	}
+	return timestamp.UTC().Format("2006-01-02 15:04:05.999999")
+}
+
 func hasMultiVariateOp(criteria []query.Criterion) bool {
 	for _, opt := range criteria {
 		if opt.Operator.IsMultiVariate() {
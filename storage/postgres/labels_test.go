@@ -123,6 +123,18 @@ var _ = Describe("Postgres Translator", func() {
 				})
 			})
 
+			Context("Called with numeric operator and timestamp value", func() {
+				It("Should compare the field as UTC timestamp", func() {
+					criteria = []query.Criterion{
+						query.ByField(query.GreaterThanOperator, "created_at", "2018-10-18T12:30:00.5+02:00"),
+					}
+					actualQuery, actualQueryParams, err := buildQueryWithParams(extContext, baseQuery, baseTableName, labelableEntity, criteria)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(actualQuery).To(ContainSubstring(fmt.Sprintf(" WHERE %s.created_at > ?::timestamp;", baseTableName)))
+					Expect(actualQueryParams).To(Equal([]interface{}{"2018-10-18 10:30:00.5"}))
+				})
+			})
+
 		})
 	})
 })
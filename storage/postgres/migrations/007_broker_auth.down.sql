@@ -0,0 +1,13 @@
+BEGIN;
+
+ALTER TABLE brokers
+  DROP COLUMN IF EXISTS oauth_token_url,
+  DROP COLUMN IF EXISTS oauth_client_id,
+  DROP COLUMN IF EXISTS oauth_client_secret,
+  DROP COLUMN IF EXISTS oauth_scopes,
+  DROP COLUMN IF EXISTS tls_certificate,
+  DROP COLUMN IF EXISTS tls_key,
+  ALTER COLUMN username DROP DEFAULT,
+  ALTER COLUMN password DROP DEFAULT;
+
+COMMIT;
@@ -0,0 +1,13 @@
+BEGIN;
+
+ALTER TABLE brokers
+  ALTER COLUMN username SET DEFAULT '',
+  ALTER COLUMN password SET DEFAULT '',
+  ADD COLUMN oauth_token_url     varchar(500)  NOT NULL DEFAULT '',
+  ADD COLUMN oauth_client_id     varchar(255)  NOT NULL DEFAULT '',
+  ADD COLUMN oauth_client_secret bytea         NOT NULL DEFAULT '',
+  ADD COLUMN oauth_scopes        varchar(1000) NOT NULL DEFAULT '',
+  ADD COLUMN tls_certificate     text          NOT NULL DEFAULT '',
+  ADD COLUMN tls_key             bytea         NOT NULL DEFAULT '';
+
+COMMIT;
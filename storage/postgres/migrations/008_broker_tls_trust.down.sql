@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE brokers
+  DROP COLUMN IF EXISTS tls_ca_certificates,
+  DROP COLUMN IF EXISTS tls_server_name,
+  DROP COLUMN IF EXISTS tls_skip_ssl_validation;
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE brokers
+  ADD COLUMN tls_ca_certificates     text         NOT NULL DEFAULT '',
+  ADD COLUMN tls_server_name         varchar(255) NOT NULL DEFAULT '',
+  ADD COLUMN tls_skip_ssl_validation boolean      NOT NULL DEFAULT '0';
+
+COMMIT;
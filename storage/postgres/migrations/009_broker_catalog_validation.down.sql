@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE brokers
+  DROP COLUMN IF EXISTS catalog_validation;
+
+COMMIT;
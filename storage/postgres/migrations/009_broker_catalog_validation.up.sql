@@ -0,0 +1,10 @@
+BEGIN;
+
+-- brokers registered before the catalog validation was introduced keep working with lenient validation
+ALTER TABLE brokers
+  ADD COLUMN catalog_validation varchar(20) NOT NULL DEFAULT 'lenient';
+
+ALTER TABLE brokers
+  ALTER COLUMN catalog_validation SET DEFAULT 'strict';
+
+COMMIT;
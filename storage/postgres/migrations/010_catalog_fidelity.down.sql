@@ -0,0 +1,21 @@
+BEGIN;
+
+UPDATE service_plans SET free = '1' WHERE free IS NULL;
+UPDATE service_plans SET bindable = service_offerings.bindable
+  FROM service_offerings WHERE service_plans.service_offering_id = service_offerings.id AND service_plans.bindable IS NULL;
+UPDATE service_plans SET plan_updateable = service_offerings.plan_updateable
+  FROM service_offerings WHERE service_plans.service_offering_id = service_offerings.id AND service_plans.plan_updateable IS NULL;
+
+ALTER TABLE service_plans
+  ALTER COLUMN free SET NOT NULL,
+  ALTER COLUMN bindable SET NOT NULL,
+  ALTER COLUMN plan_updateable SET NOT NULL,
+  DROP COLUMN IF EXISTS maintenance_info,
+  DROP COLUMN IF EXISTS maximum_polling_duration,
+  DROP COLUMN IF EXISTS bindings_retrievable;
+
+ALTER TABLE service_offerings
+  DROP COLUMN IF EXISTS dashboard_client,
+  DROP COLUMN IF EXISTS allow_context_updates;
+
+COMMIT;
@@ -0,0 +1,16 @@
+BEGIN;
+
+ALTER TABLE service_offerings
+  ADD COLUMN allow_context_updates boolean NOT NULL DEFAULT '0',
+  ADD COLUMN dashboard_client json NOT NULL DEFAULT '{}';
+
+-- plans which do not specify the flags inherit them from their service offering when the catalog is read
+ALTER TABLE service_plans
+  ALTER COLUMN free DROP NOT NULL,
+  ALTER COLUMN bindable DROP NOT NULL,
+  ALTER COLUMN plan_updateable DROP NOT NULL,
+  ADD COLUMN bindings_retrievable boolean,
+  ADD COLUMN maximum_polling_duration integer NOT NULL DEFAULT 0,
+  ADD COLUMN maintenance_info json NOT NULL DEFAULT '{}';
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE service_plans
+  ADD COLUMN maintenance_updated_at timestamp;
+
+COMMIT;
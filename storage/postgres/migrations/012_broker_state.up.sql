@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE brokers
+  ADD COLUMN state varchar(20) NOT NULL DEFAULT 'enabled';
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE brokers
+  DROP COLUMN IF EXISTS previous_credentials_expire_at,
+  DROP COLUMN IF EXISTS previous_credentials;
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE brokers
+  ADD COLUMN previous_credentials bytea,
+  ADD COLUMN previous_credentials_expire_at timestamp;
+
+COMMIT;
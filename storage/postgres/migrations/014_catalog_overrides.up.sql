@@ -0,0 +1,17 @@
+BEGIN;
+
+CREATE TABLE catalog_overrides (
+   id varchar(100) PRIMARY KEY,
+   service_offering_id varchar(100) UNIQUE REFERENCES service_offerings(id) ON DELETE CASCADE,
+   service_plan_id varchar(100) UNIQUE REFERENCES service_plans(id) ON DELETE CASCADE,
+   description text,
+   hidden boolean NOT NULL DEFAULT '0',
+   metadata json NOT NULL DEFAULT '{}',
+
+   created_at            timestamp                NOT NULL DEFAULT CURRENT_TIMESTAMP,
+   updated_at            timestamp                NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+   CHECK ((service_offering_id IS NULL) <> (service_plan_id IS NULL))
+);
+
+COMMIT;
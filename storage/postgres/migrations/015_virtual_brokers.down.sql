@@ -0,0 +1,12 @@
+BEGIN;
+
+DELETE FROM brokers WHERE type = 'virtual';
+
+DROP INDEX IF EXISTS unique_broker_url;
+ALTER TABLE brokers ADD CONSTRAINT unique_broker_url UNIQUE (broker_url);
+
+ALTER TABLE brokers
+  DROP COLUMN IF EXISTS type,
+  DROP COLUMN IF EXISTS binding_credentials;
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+ALTER TABLE brokers
+  ADD COLUMN type varchar(20) NOT NULL DEFAULT 'external',
+  ADD COLUMN binding_credentials bytea NULL;
+
+-- virtual brokers have no broker url
+ALTER TABLE brokers DROP CONSTRAINT IF EXISTS unique_broker_url;
+CREATE UNIQUE INDEX unique_broker_url ON brokers (broker_url) WHERE type = 'external';
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE brokers
+  DROP COLUMN IF EXISTS catalog_etag,
+  DROP COLUMN IF EXISTS catalog_last_modified,
+  DROP COLUMN IF EXISTS catalog_hash;
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE brokers
+  ADD COLUMN catalog_etag varchar(255) NOT NULL DEFAULT '',
+  ADD COLUMN catalog_last_modified varchar(255) NOT NULL DEFAULT '',
+  ADD COLUMN catalog_hash varchar(64) NOT NULL DEFAULT '';
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE brokers ADD COLUMN api_version varchar(10) NOT NULL DEFAULT '';
+
+COMMIT;
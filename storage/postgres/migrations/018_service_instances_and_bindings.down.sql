@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS service_bindings;
+DROP TABLE IF EXISTS service_instances;
+
+COMMIT;
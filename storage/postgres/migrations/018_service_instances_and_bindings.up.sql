@@ -0,0 +1,29 @@
+BEGIN;
+
+CREATE TABLE service_instances (
+   id varchar(100) PRIMARY KEY,
+   broker_id varchar(100) NOT NULL REFERENCES brokers(id) ON DELETE CASCADE,
+   platform_id varchar(100),
+   service_plan_id varchar(100) NOT NULL,
+   context json NOT NULL DEFAULT '{}',
+   ready boolean NOT NULL DEFAULT '0',
+   pending_operation varchar(20) NOT NULL DEFAULT '',
+
+   created_at            timestamp                NOT NULL DEFAULT CURRENT_TIMESTAMP,
+   updated_at            timestamp                NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE service_bindings (
+   id varchar(100) PRIMARY KEY,
+   service_instance_id varchar(100) NOT NULL REFERENCES service_instances(id) ON DELETE CASCADE,
+   broker_id varchar(100) NOT NULL REFERENCES brokers(id) ON DELETE CASCADE,
+   platform_id varchar(100),
+   context json NOT NULL DEFAULT '{}',
+   ready boolean NOT NULL DEFAULT '0',
+   pending_operation varchar(20) NOT NULL DEFAULT '',
+
+   created_at            timestamp                NOT NULL DEFAULT CURRENT_TIMESTAMP,
+   updated_at            timestamp                NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMIT;
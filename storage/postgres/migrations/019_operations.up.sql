@@ -0,0 +1,19 @@
+BEGIN;
+
+CREATE TABLE operations (
+   id varchar(100) PRIMARY KEY,
+   type varchar(20) NOT NULL,
+   state varchar(20) NOT NULL,
+   resource_type varchar(50) NOT NULL,
+   resource_id varchar(100) NOT NULL,
+   service_instance_id varchar(100),
+   broker_id varchar(100) NOT NULL REFERENCES brokers(id) ON DELETE CASCADE,
+   token text NOT NULL DEFAULT '',
+   description text NOT NULL DEFAULT '',
+   deadline timestamp,
+
+   created_at            timestamp                NOT NULL DEFAULT CURRENT_TIMESTAMP,
+   updated_at            timestamp                NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMIT;
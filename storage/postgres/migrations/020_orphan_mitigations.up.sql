@@ -0,0 +1,20 @@
+BEGIN;
+
+CREATE TABLE orphan_mitigations (
+   id varchar(100) PRIMARY KEY,
+   broker_id varchar(100) NOT NULL REFERENCES brokers(id) ON DELETE CASCADE,
+   resource_type varchar(50) NOT NULL,
+   path text NOT NULL,
+   service_id varchar(255) NOT NULL DEFAULT '',
+   plan_id varchar(255) NOT NULL DEFAULT '',
+   attempts integer NOT NULL DEFAULT 0,
+   next_attempt_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+   last_error text NOT NULL DEFAULT '',
+   deletion_accepted boolean NOT NULL DEFAULT false,
+   operation text NOT NULL DEFAULT '',
+
+   created_at            timestamp                NOT NULL DEFAULT CURRENT_TIMESTAMP,
+   updated_at            timestamp                NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE brokers DROP COLUMN IF EXISTS parameters_validation;
+
+COMMIT;
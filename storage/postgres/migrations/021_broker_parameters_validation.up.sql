@@ -0,0 +1,10 @@
+BEGIN;
+
+-- brokers registered before the parameters validation was introduced keep working without validation
+ALTER TABLE brokers
+  ADD COLUMN parameters_validation varchar(20) NOT NULL DEFAULT 'disabled';
+
+ALTER TABLE brokers
+  ALTER COLUMN parameters_validation SET DEFAULT 'enabled';
+
+COMMIT;
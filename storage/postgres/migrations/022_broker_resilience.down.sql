@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE brokers DROP COLUMN IF EXISTS connect_timeout;
+ALTER TABLE brokers DROP COLUMN IF EXISTS response_timeout;
+ALTER TABLE brokers DROP COLUMN IF EXISTS retries;
+ALTER TABLE brokers DROP COLUMN IF EXISTS circuit_breaker_threshold;
+ALTER TABLE brokers DROP COLUMN IF EXISTS circuit_breaker_timeout;
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE brokers ADD COLUMN connect_timeout integer NOT NULL DEFAULT 0;
+ALTER TABLE brokers ADD COLUMN response_timeout integer NOT NULL DEFAULT 0;
+ALTER TABLE brokers ADD COLUMN retries integer NOT NULL DEFAULT 0;
+ALTER TABLE brokers ADD COLUMN circuit_breaker_threshold integer NOT NULL DEFAULT 0;
+ALTER TABLE brokers ADD COLUMN circuit_breaker_timeout integer NOT NULL DEFAULT 0;
+
+COMMIT;
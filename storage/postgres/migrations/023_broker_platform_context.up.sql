@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE brokers ADD COLUMN platform_context varchar(20) NOT NULL DEFAULT 'disabled';
+
+COMMIT;
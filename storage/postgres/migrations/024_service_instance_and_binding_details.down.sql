@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE service_instances DROP COLUMN IF EXISTS parameters;
+ALTER TABLE service_instances DROP COLUMN IF EXISTS dashboard_url;
+
+ALTER TABLE service_bindings DROP COLUMN IF EXISTS parameters;
+ALTER TABLE service_bindings DROP COLUMN IF EXISTS credentials;
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE service_instances ADD COLUMN parameters json NOT NULL DEFAULT '{}';
+ALTER TABLE service_instances ADD COLUMN dashboard_url text NOT NULL DEFAULT '';
+
+ALTER TABLE service_bindings ADD COLUMN parameters json NOT NULL DEFAULT '{}';
+ALTER TABLE service_bindings ADD COLUMN credentials bytea NULL;
+
+COMMIT;
@@ -0,0 +1,68 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package postgres
+
+import (
+	"context"
+
+	"github.com/Peripli/service-manager/pkg/query"
+	"github.com/Peripli/service-manager/pkg/types"
+)
+
+type operationStorage struct {
+	db pgDB
+}
+
+func (ops *operationStorage) Create(ctx context.Context, operation *types.Operation) (string, error) {
+	o := &Operation{}
+	o.FromDTO(operation)
+	return create(ctx, ops.db, operationTable, o)
+}
+
+func (ops *operationStorage) Get(ctx context.Context, id string) (*types.Operation, error) {
+	o := &Operation{}
+	if err := get(ctx, ops.db, id, operationTable, o); err != nil {
+		return nil, err
+	}
+	return o.ToDTO(), nil
+}
+
+func (ops *operationStorage) List(ctx context.Context, criteria ...query.Criterion) ([]*types.Operation, error) {
+	var entities []Operation
+	if err := validateFieldQueryParams(Operation{}, criteria); err != nil {
+		return nil, err
+	}
+	err := listByFieldCriteria(ctx, ops.db, operationTable, &entities, criteria)
+	if err != nil || len(entities) == 0 {
+		return []*types.Operation{}, err
+	}
+	operations := make([]*types.Operation, 0, len(entities))
+	for _, entity := range entities {
+		operations = append(operations, entity.ToDTO())
+	}
+	return operations, nil
+}
+
+func (ops *operationStorage) Delete(ctx context.Context, criteria ...query.Criterion) error {
+	return deleteAllByFieldCriteria(ctx, ops.db, operationTable, Operation{}, criteria)
+}
+
+func (ops *operationStorage) Update(ctx context.Context, operation *types.Operation) error {
+	o := &Operation{}
+	o.FromDTO(operation)
+	return update(ctx, ops.db, operationTable, o)
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Peripli/service-manager/pkg/log"
+	"github.com/Peripli/service-manager/pkg/query"
+	"github.com/Peripli/service-manager/pkg/types"
+)
+
+type orphanMitigationStorage struct {
+	db pgDB
+}
+
+func (oms *orphanMitigationStorage) Create(ctx context.Context, orphanMitigation *types.OrphanMitigation) (string, error) {
+	om := &OrphanMitigation{}
+	om.FromDTO(orphanMitigation)
+	return create(ctx, oms.db, orphanMitigationTable, om)
+}
+
+func (oms *orphanMitigationStorage) Get(ctx context.Context, id string) (*types.OrphanMitigation, error) {
+	om := &OrphanMitigation{}
+	if err := get(ctx, oms.db, id, orphanMitigationTable, om); err != nil {
+		return nil, err
+	}
+	return om.ToDTO(), nil
+}
+
+func (oms *orphanMitigationStorage) List(ctx context.Context, criteria ...query.Criterion) ([]*types.OrphanMitigation, error) {
+	var entities []OrphanMitigation
+	if err := validateFieldQueryParams(OrphanMitigation{}, criteria); err != nil {
+		return nil, err
+	}
+	err := listByFieldCriteria(ctx, oms.db, orphanMitigationTable, &entities, criteria)
+	if err != nil || len(entities) == 0 {
+		return []*types.OrphanMitigation{}, err
+	}
+	orphanMitigations := make([]*types.OrphanMitigation, 0, len(entities))
+	for _, entity := range entities {
+		orphanMitigations = append(orphanMitigations, entity.ToDTO())
+	}
+	return orphanMitigations, nil
+}
+
+func (oms *orphanMitigationStorage) Delete(ctx context.Context, criteria ...query.Criterion) error {
+	return deleteAllByFieldCriteria(ctx, oms.db, orphanMitigationTable, OrphanMitigation{}, criteria)
+}
+
+func (oms *orphanMitigationStorage) Update(ctx context.Context, orphanMitigation *types.OrphanMitigation) error {
+	om := &OrphanMitigation{}
+	om.FromDTO(orphanMitigation)
+	return update(ctx, oms.db, orphanMitigationTable, om)
+}
+
+func (oms *orphanMitigationStorage) Claim(ctx context.Context, now time.Time, lease time.Duration) ([]*types.OrphanMitigation, error) {
+	// rows locked by the transactions of other instances are skipped instead of waited for
+	sqlQuery := fmt.Sprintf(`UPDATE %[1]s SET next_attempt_at = $1
+	WHERE id IN (SELECT id FROM %[1]s WHERE next_attempt_at <= $2 FOR UPDATE SKIP LOCKED)
+	RETURNING *;`, orphanMitigationTable)
+
+	log.C(ctx).Debugf("Executing query %s", sqlQuery)
+	var entities []OrphanMitigation
+	if err := oms.db.SelectContext(ctx, &entities, sqlQuery, now.Add(lease), now); err != nil {
+		return nil, err
+	}
+	orphanMitigations := make([]*types.OrphanMitigation, 0, len(entities))
+	for _, entity := range entities {
+		orphanMitigations = append(orphanMitigations, entity.ToDTO())
+	}
+	return orphanMitigations, nil
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package postgres
+
+import (
+	"context"
+
+	"github.com/Peripli/service-manager/pkg/query"
+	"github.com/Peripli/service-manager/pkg/types"
+)
+
+type serviceBindingStorage struct {
+	db pgDB
+}
+
+func (sbs *serviceBindingStorage) Create(ctx context.Context, serviceBinding *types.ServiceBinding) (string, error) {
+	sb := &ServiceBinding{}
+	sb.FromDTO(serviceBinding)
+	return create(ctx, sbs.db, serviceBindingTable, sb)
+}
+
+func (sbs *serviceBindingStorage) Get(ctx context.Context, id string) (*types.ServiceBinding, error) {
+	sb := &ServiceBinding{}
+	if err := get(ctx, sbs.db, id, serviceBindingTable, sb); err != nil {
+		return nil, err
+	}
+	return sb.ToDTO(), nil
+}
+
+func (sbs *serviceBindingStorage) List(ctx context.Context, criteria ...query.Criterion) ([]*types.ServiceBinding, error) {
+	var entities []ServiceBinding
+	if err := validateFieldQueryParams(ServiceBinding{}, criteria); err != nil {
+		return nil, err
+	}
+	err := listByFieldCriteria(ctx, sbs.db, serviceBindingTable, &entities, criteria)
+	if err != nil || len(entities) == 0 {
+		return []*types.ServiceBinding{}, err
+	}
+	serviceBindings := make([]*types.ServiceBinding, 0, len(entities))
+	for _, entity := range entities {
+		serviceBindings = append(serviceBindings, entity.ToDTO())
+	}
+	return serviceBindings, nil
+}
+
+func (sbs *serviceBindingStorage) Delete(ctx context.Context, criteria ...query.Criterion) error {
+	return deleteAllByFieldCriteria(ctx, sbs.db, serviceBindingTable, ServiceBinding{}, criteria)
+}
+
+func (sbs *serviceBindingStorage) Update(ctx context.Context, serviceBinding *types.ServiceBinding) error {
+	sb := &ServiceBinding{}
+	sb.FromDTO(serviceBinding)
+	return update(ctx, sbs.db, serviceBindingTable, sb)
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package postgres
+
+import (
+	"context"
+
+	"github.com/Peripli/service-manager/pkg/query"
+	"github.com/Peripli/service-manager/pkg/types"
+)
+
+type serviceInstanceStorage struct {
+	db pgDB
+}
+
+func (sis *serviceInstanceStorage) Create(ctx context.Context, serviceInstance *types.ServiceInstance) (string, error) {
+	si := &ServiceInstance{}
+	si.FromDTO(serviceInstance)
+	return create(ctx, sis.db, serviceInstanceTable, si)
+}
+
+func (sis *serviceInstanceStorage) Get(ctx context.Context, id string) (*types.ServiceInstance, error) {
+	si := &ServiceInstance{}
+	if err := get(ctx, sis.db, id, serviceInstanceTable, si); err != nil {
+		return nil, err
+	}
+	return si.ToDTO(), nil
+}
+
+func (sis *serviceInstanceStorage) List(ctx context.Context, criteria ...query.Criterion) ([]*types.ServiceInstance, error) {
+	var entities []ServiceInstance
+	if err := validateFieldQueryParams(ServiceInstance{}, criteria); err != nil {
+		return nil, err
+	}
+	err := listByFieldCriteria(ctx, sis.db, serviceInstanceTable, &entities, criteria)
+	if err != nil || len(entities) == 0 {
+		return []*types.ServiceInstance{}, err
+	}
+	serviceInstances := make([]*types.ServiceInstance, 0, len(entities))
+	for _, entity := range entities {
+		serviceInstances = append(serviceInstances, entity.ToDTO())
+	}
+	return serviceInstances, nil
+}
+
+func (sis *serviceInstanceStorage) Delete(ctx context.Context, criteria ...query.Criterion) error {
+	return deleteAllByFieldCriteria(ctx, sis.db, serviceInstanceTable, ServiceInstance{}, criteria)
+}
+
+func (sis *serviceInstanceStorage) Update(ctx context.Context, serviceInstance *types.ServiceInstance) error {
+	si := &ServiceInstance{}
+	si.FromDTO(serviceInstance)
+	return update(ctx, sis.db, serviceInstanceTable, si)
+}
@@ -71,10 +71,14 @@ func (sos *serviceOfferingStorage) ListWithServicePlansByBrokerID(ctx context.Co
 		%[2]s.free "%[2]s.free",
 		%[2]s.bindable "%[2]s.bindable",
 		%[2]s.plan_updateable "%[2]s.plan_updateable",
+		%[2]s.bindings_retrievable "%[2]s.bindings_retrievable",
+		%[2]s.maximum_polling_duration "%[2]s.maximum_polling_duration",
 		%[2]s.catalog_id "%[2]s.catalog_id",
 		%[2]s.catalog_name "%[2]s.catalog_name",
 		%[2]s.metadata "%[2]s.metadata",
 		%[2]s.schemas "%[2]s.schemas",
+		%[2]s.maintenance_info "%[2]s.maintenance_info",
+		%[2]s.maintenance_updated_at "%[2]s.maintenance_updated_at",
 		%[2]s.service_offering_id "%[2]s.service_offering_id"
 	FROM %[1]s 
 	JOIN %[2]s ON %[1]s.id = %[2]s.service_offering_id
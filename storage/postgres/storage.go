@@ -63,6 +63,31 @@ func (ts *transactionalWarehouse) Visibility() storage.Visibility {
 	return &visibilityStorage{db: ts.tx}
 }
 
+func (ts *transactionalWarehouse) CatalogOverride() storage.CatalogOverride {
+	ts.checkOpen()
+	return &catalogOverrideStorage{db: ts.tx}
+}
+
+func (ts *transactionalWarehouse) ServiceInstance() storage.ServiceInstance {
+	ts.checkOpen()
+	return &serviceInstanceStorage{db: ts.tx}
+}
+
+func (ts *transactionalWarehouse) ServiceBinding() storage.ServiceBinding {
+	ts.checkOpen()
+	return &serviceBindingStorage{db: ts.tx}
+}
+
+func (ts *transactionalWarehouse) Operation() storage.Operation {
+	ts.checkOpen()
+	return &operationStorage{db: ts.tx}
+}
+
+func (ts *transactionalWarehouse) OrphanMitigation() storage.OrphanMitigation {
+	ts.checkOpen()
+	return &orphanMitigationStorage{db: ts.tx}
+}
+
 func (ts *transactionalWarehouse) Security() storage.Security {
 	ts.checkOpen()
 	return &securityStorage{db: ts.tx}
@@ -150,6 +175,26 @@ func (ps *postgresStorage) Visibility() storage.Visibility {
 	return &visibilityStorage{ps.db}
 }
 
+func (ps *postgresStorage) CatalogOverride() storage.CatalogOverride {
+	return &catalogOverrideStorage{ps.db}
+}
+
+func (ps *postgresStorage) ServiceInstance() storage.ServiceInstance {
+	return &serviceInstanceStorage{ps.db}
+}
+
+func (ps *postgresStorage) ServiceBinding() storage.ServiceBinding {
+	return &serviceBindingStorage{ps.db}
+}
+
+func (ps *postgresStorage) Operation() storage.Operation {
+	return &operationStorage{ps.db}
+}
+
+func (ps *postgresStorage) OrphanMitigation() storage.OrphanMitigation {
+	return &orphanMitigationStorage{ps.db}
+}
+
 func (ps *postgresStorage) Security() storage.Security {
 	ps.checkOpen()
 	return &securityStorage{ps.db, ps.encryptionKey, false, &sync.Mutex{}}
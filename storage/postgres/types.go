@@ -20,6 +20,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/Peripli/service-manager/pkg/util/slice"
@@ -28,6 +29,7 @@ import (
 
 	"github.com/Peripli/service-manager/pkg/types"
 	sqlxtypes "github.com/jmoiron/sqlx/types"
+	"github.com/lib/pq"
 )
 
 const (
@@ -51,6 +53,21 @@ const (
 
 	// visibilityLabelsTable db table for visibilities table
 	visibilityLabelsTable = "visibility_labels"
+
+	// catalogOverrideTable db table for catalog overrides
+	catalogOverrideTable = "catalog_overrides"
+
+	// serviceInstanceTable db table for service instances
+	serviceInstanceTable = "service_instances"
+
+	// serviceBindingTable db table for service bindings
+	serviceBindingTable = "service_bindings"
+
+	// operationTable db table for asynchronous operations
+	operationTable = "operations"
+
+	// orphanMitigationTable db table for pending orphan mitigations
+	orphanMitigationTable = "orphan_mitigations"
 )
 
 // Safe represents a secret entity
@@ -82,6 +99,96 @@ type Broker struct {
 	BrokerURL   string         `db:"broker_url"`
 	Username    string         `db:"username"`
 	Password    string         `db:"password"`
+
+	OAuthTokenURL     string `db:"oauth_token_url"`
+	OAuthClientID     string `db:"oauth_client_id"`
+	OAuthClientSecret string `db:"oauth_client_secret"`
+	OAuthScopes       string `db:"oauth_scopes"`
+
+	TLSCertificate string `db:"tls_certificate"`
+	TLSKey         string `db:"tls_key"`
+
+	TLSCACertificates    string `db:"tls_ca_certificates"`
+	TLSServerName        string `db:"tls_server_name"`
+	TLSSkipSSLValidation bool   `db:"tls_skip_ssl_validation"`
+
+	ConnectTimeout          int `db:"connect_timeout"`
+	ResponseTimeout         int `db:"response_timeout"`
+	Retries                 int `db:"retries"`
+	CircuitBreakerThreshold int `db:"circuit_breaker_threshold"`
+	CircuitBreakerTimeout   int `db:"circuit_breaker_timeout"`
+
+	CatalogValidation    string `db:"catalog_validation"`
+	ParametersValidation string `db:"parameters_validation"`
+	PlatformContext      string `db:"platform_context"`
+	State                string `db:"state"`
+	Type                 string `db:"type"`
+	APIVersion           string `db:"api_version"`
+
+	BindingCredentials []byte `db:"binding_credentials"`
+
+	CatalogETag         string `db:"catalog_etag"`
+	CatalogLastModified string `db:"catalog_last_modified"`
+	CatalogHash         string `db:"catalog_hash"`
+
+	PreviousCredentials         []byte      `db:"previous_credentials"`
+	PreviousCredentialsExpireAt pq.NullTime `db:"previous_credentials_expire_at"`
+}
+
+// brokerCredentials is the stored form of broker credentials kept during credentials rotation. The secrets are
+// already encrypted and are therefore serialized as binary data.
+type brokerCredentials struct {
+	Username          string   `json:"username,omitempty"`
+	Password          []byte   `json:"password,omitempty"`
+	OAuthTokenURL     string   `json:"oauth_token_url,omitempty"`
+	OAuthClientID     string   `json:"oauth_client_id,omitempty"`
+	OAuthClientSecret []byte   `json:"oauth_client_secret,omitempty"`
+	OAuthScopes       []string `json:"oauth_scopes,omitempty"`
+	TLSCertificate    string   `json:"tls_certificate,omitempty"`
+	TLSKey            []byte   `json:"tls_key,omitempty"`
+}
+
+func (bc *brokerCredentials) ToDTO() *types.Credentials {
+	credentials := &types.Credentials{}
+	if bc.Username != "" {
+		credentials.Basic = &types.Basic{
+			Username: bc.Username,
+			Password: string(bc.Password),
+		}
+	}
+	if bc.OAuthClientID != "" {
+		credentials.OAuth2 = &types.OAuth2{
+			TokenURL:     bc.OAuthTokenURL,
+			ClientID:     bc.OAuthClientID,
+			ClientSecret: string(bc.OAuthClientSecret),
+			Scopes:       bc.OAuthScopes,
+		}
+	}
+	if bc.TLSCertificate != "" {
+		credentials.TLS = &types.TLS{
+			Certificate: bc.TLSCertificate,
+			Key:         string(bc.TLSKey),
+		}
+	}
+	return credentials
+}
+
+func (bc *brokerCredentials) FromDTO(credentials *types.Credentials) {
+	*bc = brokerCredentials{}
+	if credentials.Basic != nil {
+		bc.Username = credentials.Basic.Username
+		bc.Password = []byte(credentials.Basic.Password)
+	}
+	if credentials.OAuth2 != nil {
+		bc.OAuthTokenURL = credentials.OAuth2.TokenURL
+		bc.OAuthClientID = credentials.OAuth2.ClientID
+		bc.OAuthClientSecret = []byte(credentials.OAuth2.ClientSecret)
+		bc.OAuthScopes = credentials.OAuth2.Scopes
+	}
+	if credentials.TLS != nil {
+		bc.TLSCertificate = credentials.TLS.Certificate
+		bc.TLSKey = []byte(credentials.TLS.Key)
+	}
 }
 
 type ServiceOffering struct {
@@ -95,12 +202,14 @@ type ServiceOffering struct {
 	InstancesRetrievable bool   `db:"instances_retrievable"`
 	BindingsRetrievable  bool   `db:"bindings_retrievable"`
 	PlanUpdatable        bool   `db:"plan_updateable"`
+	AllowContextUpdates  bool   `db:"allow_context_updates"`
 	CatalogID            string `db:"catalog_id"`
 	CatalogName          string `db:"catalog_name"`
 
-	Tags     sqlxtypes.JSONText `db:"tags"`
-	Requires sqlxtypes.JSONText `db:"requires"`
-	Metadata sqlxtypes.JSONText `db:"metadata"`
+	Tags            sqlxtypes.JSONText `db:"tags"`
+	Requires        sqlxtypes.JSONText `db:"requires"`
+	Metadata        sqlxtypes.JSONText `db:"metadata"`
+	DashboardClient sqlxtypes.JSONText `db:"dashboard_client"`
 
 	BrokerID string `db:"broker_id"`
 }
@@ -112,14 +221,19 @@ type ServicePlan struct {
 	CreatedAt   time.Time `db:"created_at"`
 	UpdatedAt   time.Time `db:"updated_at"`
 
-	Free          bool   `db:"free"`
-	Bindable      bool   `db:"bindable"`
-	PlanUpdatable bool   `db:"plan_updateable"`
-	CatalogID     string `db:"catalog_id"`
-	CatalogName   string `db:"catalog_name"`
+	Free                   sql.NullBool `db:"free"`
+	Bindable               sql.NullBool `db:"bindable"`
+	PlanUpdatable          sql.NullBool `db:"plan_updateable"`
+	BindingsRetrievable    sql.NullBool `db:"bindings_retrievable"`
+	MaximumPollingDuration int          `db:"maximum_polling_duration"`
+	CatalogID              string       `db:"catalog_id"`
+	CatalogName            string       `db:"catalog_name"`
 
-	Metadata sqlxtypes.JSONText `db:"metadata"`
-	Schemas  sqlxtypes.JSONText `db:"schemas"`
+	Metadata        sqlxtypes.JSONText `db:"metadata"`
+	Schemas         sqlxtypes.JSONText `db:"schemas"`
+	MaintenanceInfo sqlxtypes.JSONText `db:"maintenance_info"`
+
+	MaintenanceUpdatedAt pq.NullTime `db:"maintenance_updated_at"`
 
 	ServiceOfferingID string `db:"service_offering_id"`
 }
@@ -132,6 +246,76 @@ type Visibility struct {
 	UpdatedAt     time.Time      `db:"updated_at"`
 }
 
+type CatalogOverride struct {
+	ID                string             `db:"id"`
+	ServiceOfferingID sql.NullString     `db:"service_offering_id"`
+	ServicePlanID     sql.NullString     `db:"service_plan_id"`
+	Description       sql.NullString     `db:"description"`
+	Hidden            bool               `db:"hidden"`
+	Metadata          sqlxtypes.JSONText `db:"metadata"`
+	CreatedAt         time.Time          `db:"created_at"`
+	UpdatedAt         time.Time          `db:"updated_at"`
+}
+
+type ServiceInstance struct {
+	ID               string             `db:"id"`
+	BrokerID         string             `db:"broker_id"`
+	PlatformID       sql.NullString     `db:"platform_id"`
+	ServicePlanID    string             `db:"service_plan_id"`
+	Context          sqlxtypes.JSONText `db:"context"`
+	Parameters       sqlxtypes.JSONText `db:"parameters"`
+	DashboardURL     string             `db:"dashboard_url"`
+	Ready            bool               `db:"ready"`
+	PendingOperation string             `db:"pending_operation"`
+	CreatedAt        time.Time          `db:"created_at"`
+	UpdatedAt        time.Time          `db:"updated_at"`
+}
+
+type ServiceBinding struct {
+	ID                string             `db:"id"`
+	ServiceInstanceID string             `db:"service_instance_id"`
+	BrokerID          string             `db:"broker_id"`
+	PlatformID        sql.NullString     `db:"platform_id"`
+	Context           sqlxtypes.JSONText `db:"context"`
+	Parameters        sqlxtypes.JSONText `db:"parameters"`
+	Credentials       []byte             `db:"credentials"`
+	Ready             bool               `db:"ready"`
+	PendingOperation  string             `db:"pending_operation"`
+	CreatedAt         time.Time          `db:"created_at"`
+	UpdatedAt         time.Time          `db:"updated_at"`
+}
+
+type Operation struct {
+	ID                string         `db:"id"`
+	Type              string         `db:"type"`
+	State             string         `db:"state"`
+	ResourceType      string         `db:"resource_type"`
+	ResourceID        string         `db:"resource_id"`
+	ServiceInstanceID sql.NullString `db:"service_instance_id"`
+	BrokerID          string         `db:"broker_id"`
+	Token             string         `db:"token"`
+	Description       string         `db:"description"`
+	Deadline          pq.NullTime    `db:"deadline"`
+	CreatedAt         time.Time      `db:"created_at"`
+	UpdatedAt         time.Time      `db:"updated_at"`
+}
+
+type OrphanMitigation struct {
+	ID               string    `db:"id"`
+	BrokerID         string    `db:"broker_id"`
+	ResourceType     string    `db:"resource_type"`
+	Path             string    `db:"path"`
+	ServiceID        string    `db:"service_id"`
+	PlanID           string    `db:"plan_id"`
+	Attempts         int       `db:"attempts"`
+	NextAttemptAt    time.Time `db:"next_attempt_at"`
+	LastError        string    `db:"last_error"`
+	DeletionAccepted bool      `db:"deletion_accepted"`
+	Operation        string    `db:"operation"`
+	CreatedAt        time.Time `db:"created_at"`
+	UpdatedAt        time.Time `db:"updated_at"`
+}
+
 // Labelable is an interface that entities that support can be labelled should implement
 type Labelable interface {
 	Label() (labelTableName string, referenceColumnName string, primaryColumnName string)
@@ -277,13 +461,66 @@ func (b *Broker) ToDTO() *types.Broker {
 		CreatedAt:   b.CreatedAt,
 		UpdatedAt:   b.UpdatedAt,
 		BrokerURL:   b.BrokerURL,
-		Credentials: &types.Credentials{
-			Basic: &types.Basic{
-				Username: b.Username,
-				Password: b.Password,
-			},
-		},
-		Labels: make(map[string][]string),
+		Credentials: &types.Credentials{},
+		Labels:      make(map[string][]string),
+
+		CatalogValidation:    b.CatalogValidation,
+		ParametersValidation: b.ParametersValidation,
+		PlatformContext:      b.PlatformContext,
+		State:                b.State,
+		Type:                 b.Type,
+		APIVersion:           b.APIVersion,
+
+		CatalogETag:         b.CatalogETag,
+		CatalogLastModified: b.CatalogLastModified,
+		CatalogHash:         b.CatalogHash,
+	}
+	if len(b.BindingCredentials) != 0 {
+		broker.BindingCredentials = b.BindingCredentials
+	}
+	if b.Username != "" {
+		broker.Credentials.Basic = &types.Basic{
+			Username: b.Username,
+			Password: b.Password,
+		}
+	}
+	if b.OAuthClientID != "" {
+		broker.Credentials.OAuth2 = &types.OAuth2{
+			TokenURL:     b.OAuthTokenURL,
+			ClientID:     b.OAuthClientID,
+			ClientSecret: b.OAuthClientSecret,
+			Scopes:       strings.Fields(b.OAuthScopes),
+		}
+	}
+	if b.TLSCertificate != "" {
+		broker.Credentials.TLS = &types.TLS{
+			Certificate: b.TLSCertificate,
+			Key:         b.TLSKey,
+		}
+	}
+	if len(b.PreviousCredentials) != 0 {
+		previousCredentials := &brokerCredentials{}
+		if err := json.Unmarshal(b.PreviousCredentials, previousCredentials); err == nil {
+			broker.PreviousCredentials = previousCredentials.ToDTO()
+			broker.PreviousCredentialsExpireAt = b.PreviousCredentialsExpireAt.Time
+		}
+	}
+	if b.TLSCACertificates != "" || b.TLSServerName != "" || b.TLSSkipSSLValidation {
+		broker.TLS = &types.TLSTrust{
+			CACertificates:    b.TLSCACertificates,
+			ServerName:        b.TLSServerName,
+			SkipSSLValidation: b.TLSSkipSSLValidation,
+		}
+	}
+	resilience := types.Resilience{
+		ConnectTimeout:          b.ConnectTimeout,
+		ResponseTimeout:         b.ResponseTimeout,
+		Retries:                 b.Retries,
+		CircuitBreakerThreshold: b.CircuitBreakerThreshold,
+		CircuitBreakerTimeout:   b.CircuitBreakerTimeout,
+	}
+	if resilience != (types.Resilience{}) {
+		broker.Resilience = &resilience
 	}
 	return broker
 }
@@ -296,15 +533,76 @@ func (b *Broker) FromDTO(broker *types.Broker) {
 		BrokerURL:   broker.BrokerURL,
 		CreatedAt:   broker.CreatedAt,
 		UpdatedAt:   broker.UpdatedAt,
+
+		CatalogValidation:    broker.CatalogValidation,
+		ParametersValidation: broker.ParametersValidation,
+		PlatformContext:      broker.PlatformContext,
+		State:                broker.State,
+		Type:                 broker.Type,
+		APIVersion:           broker.APIVersion,
+
+		BindingCredentials: broker.BindingCredentials,
+
+		CatalogETag:         broker.CatalogETag,
+		CatalogLastModified: broker.CatalogLastModified,
+		CatalogHash:         broker.CatalogHash,
+	}
+	if b.CatalogValidation == "" {
+		b.CatalogValidation = types.CatalogValidationStrict
+	}
+	if b.ParametersValidation == "" {
+		b.ParametersValidation = types.ParametersValidationEnabled
+	}
+	if b.PlatformContext == "" {
+		b.PlatformContext = types.PlatformContextDisabled
+	}
+	if b.State == "" {
+		b.State = types.BrokerStateEnabled
+	}
+	if b.Type == "" {
+		b.Type = types.BrokerTypeExternal
 	}
 
 	if broker.Description != "" {
 		b.Description.Valid = true
 	}
-	if broker.Credentials != nil && broker.Credentials.Basic != nil {
+	if broker.TLS != nil {
+		b.TLSCACertificates = broker.TLS.CACertificates
+		b.TLSServerName = broker.TLS.ServerName
+		b.TLSSkipSSLValidation = broker.TLS.SkipSSLValidation
+	}
+	if broker.Resilience != nil {
+		b.ConnectTimeout = broker.Resilience.ConnectTimeout
+		b.ResponseTimeout = broker.Resilience.ResponseTimeout
+		b.Retries = broker.Resilience.Retries
+		b.CircuitBreakerThreshold = broker.Resilience.CircuitBreakerThreshold
+		b.CircuitBreakerTimeout = broker.Resilience.CircuitBreakerTimeout
+	}
+	if broker.PreviousCredentials != nil {
+		previousCredentials := &brokerCredentials{}
+		previousCredentials.FromDTO(broker.PreviousCredentials)
+		if previousCredentialsBytes, err := json.Marshal(previousCredentials); err == nil {
+			b.PreviousCredentials = previousCredentialsBytes
+			b.PreviousCredentialsExpireAt = toNullTime(broker.PreviousCredentialsExpireAt)
+		}
+	}
+	if broker.Credentials == nil {
+		return
+	}
+	if broker.Credentials.Basic != nil {
 		b.Username = broker.Credentials.Basic.Username
 		b.Password = broker.Credentials.Basic.Password
 	}
+	if broker.Credentials.OAuth2 != nil {
+		b.OAuthTokenURL = broker.Credentials.OAuth2.TokenURL
+		b.OAuthClientID = broker.Credentials.OAuth2.ClientID
+		b.OAuthClientSecret = broker.Credentials.OAuth2.ClientSecret
+		b.OAuthScopes = strings.Join(broker.Credentials.OAuth2.Scopes, " ")
+	}
+	if broker.Credentials.TLS != nil {
+		b.TLSCertificate = broker.Credentials.TLS.Certificate
+		b.TLSKey = broker.Credentials.TLS.Key
+	}
 }
 
 func (p *Platform) ToDTO() *types.Platform {
@@ -354,11 +652,13 @@ func (so *ServiceOffering) ToDTO() *types.ServiceOffering {
 		InstancesRetrievable: so.InstancesRetrievable,
 		BindingsRetrievable:  so.BindingsRetrievable,
 		PlanUpdatable:        so.PlanUpdatable,
+		AllowContextUpdates:  so.AllowContextUpdates,
 		CatalogID:            so.CatalogID,
 		CatalogName:          so.CatalogName,
 		Tags:                 getJSONRawMessage(so.Tags),
 		Requires:             getJSONRawMessage(so.Requires),
 		Metadata:             getJSONRawMessage(so.Metadata),
+		DashboardClient:      getJSONRawMessage(so.DashboardClient),
 		BrokerID:             so.BrokerID,
 	}
 }
@@ -374,48 +674,58 @@ func (so *ServiceOffering) FromDTO(offering *types.ServiceOffering) {
 		InstancesRetrievable: offering.InstancesRetrievable,
 		BindingsRetrievable:  offering.BindingsRetrievable,
 		PlanUpdatable:        offering.PlanUpdatable,
+		AllowContextUpdates:  offering.AllowContextUpdates,
 		CatalogID:            offering.CatalogID,
 		CatalogName:          offering.CatalogName,
 		Tags:                 getJSONText(offering.Tags),
 		Requires:             getJSONText(offering.Requires),
 		Metadata:             getJSONText(offering.Metadata),
+		DashboardClient:      getJSONText(offering.DashboardClient),
 		BrokerID:             offering.BrokerID,
 	}
 }
 
 func (sp *ServicePlan) ToDTO() *types.ServicePlan {
 	return &types.ServicePlan{
-		ID:                sp.ID,
-		Name:              sp.Name,
-		Description:       sp.Description,
-		CreatedAt:         sp.CreatedAt,
-		UpdatedAt:         sp.UpdatedAt,
-		CatalogID:         sp.CatalogID,
-		CatalogName:       sp.CatalogName,
-		Free:              sp.Free,
-		Bindable:          sp.Bindable,
-		PlanUpdatable:     sp.PlanUpdatable,
-		Metadata:          getJSONRawMessage(sp.Metadata),
-		Schemas:           getJSONRawMessage(sp.Schemas),
-		ServiceOfferingID: sp.ServiceOfferingID,
+		ID:                     sp.ID,
+		Name:                   sp.Name,
+		Description:            sp.Description,
+		CreatedAt:              sp.CreatedAt,
+		UpdatedAt:              sp.UpdatedAt,
+		CatalogID:              sp.CatalogID,
+		CatalogName:            sp.CatalogName,
+		Free:                   getBoolPointer(sp.Free),
+		Bindable:               getBoolPointer(sp.Bindable),
+		PlanUpdatable:          getBoolPointer(sp.PlanUpdatable),
+		BindingsRetrievable:    getBoolPointer(sp.BindingsRetrievable),
+		MaximumPollingDuration: sp.MaximumPollingDuration,
+		Metadata:               getJSONRawMessage(sp.Metadata),
+		Schemas:                getJSONRawMessage(sp.Schemas),
+		MaintenanceInfo:        getJSONRawMessage(sp.MaintenanceInfo),
+		MaintenanceUpdatedAt:   sp.MaintenanceUpdatedAt.Time,
+		ServiceOfferingID:      sp.ServiceOfferingID,
 	}
 }
 
 func (sp *ServicePlan) FromDTO(plan *types.ServicePlan) {
 	*sp = ServicePlan{
-		ID:                plan.ID,
-		Name:              plan.Name,
-		Description:       plan.Description,
-		CreatedAt:         plan.CreatedAt,
-		UpdatedAt:         plan.UpdatedAt,
-		Free:              plan.Free,
-		Bindable:          plan.Bindable,
-		PlanUpdatable:     plan.PlanUpdatable,
-		CatalogID:         plan.CatalogID,
-		CatalogName:       plan.CatalogName,
-		Metadata:          getJSONText(plan.Metadata),
-		Schemas:           getJSONText(plan.Schemas),
-		ServiceOfferingID: plan.ServiceOfferingID,
+		ID:                     plan.ID,
+		Name:                   plan.Name,
+		Description:            plan.Description,
+		CreatedAt:              plan.CreatedAt,
+		UpdatedAt:              plan.UpdatedAt,
+		Free:                   toNullBool(plan.Free),
+		Bindable:               toNullBool(plan.Bindable),
+		PlanUpdatable:          toNullBool(plan.PlanUpdatable),
+		BindingsRetrievable:    toNullBool(plan.BindingsRetrievable),
+		MaximumPollingDuration: plan.MaximumPollingDuration,
+		CatalogID:              plan.CatalogID,
+		CatalogName:            plan.CatalogName,
+		Metadata:               getJSONText(plan.Metadata),
+		Schemas:                getJSONText(plan.Schemas),
+		MaintenanceInfo:        getJSONText(plan.MaintenanceInfo),
+		MaintenanceUpdatedAt:   toNullTime(plan.MaintenanceUpdatedAt),
+		ServiceOfferingID:      plan.ServiceOfferingID,
 	}
 }
 
@@ -441,8 +751,168 @@ func (v *Visibility) FromDTO(visibility *types.Visibility) {
 	}
 }
 
+func (co *CatalogOverride) ToDTO() *types.CatalogOverride {
+	return &types.CatalogOverride{
+		ID:                co.ID,
+		ServiceOfferingID: co.ServiceOfferingID.String,
+		ServicePlanID:     co.ServicePlanID.String,
+		Description:       co.Description.String,
+		Hidden:            co.Hidden,
+		Metadata:          getJSONRawMessage(co.Metadata),
+		CreatedAt:         co.CreatedAt,
+		UpdatedAt:         co.UpdatedAt,
+	}
+}
+
+func (co *CatalogOverride) FromDTO(catalogOverride *types.CatalogOverride) {
+	*co = CatalogOverride{
+		ID:                catalogOverride.ID,
+		ServiceOfferingID: toNullString(catalogOverride.ServiceOfferingID),
+		ServicePlanID:     toNullString(catalogOverride.ServicePlanID),
+		Description:       toNullString(catalogOverride.Description),
+		Hidden:            catalogOverride.Hidden,
+		Metadata:          getJSONText(catalogOverride.Metadata),
+		CreatedAt:         catalogOverride.CreatedAt,
+		UpdatedAt:         catalogOverride.UpdatedAt,
+	}
+}
+
+func (si *ServiceInstance) ToDTO() *types.ServiceInstance {
+	return &types.ServiceInstance{
+		ID:               si.ID,
+		BrokerID:         si.BrokerID,
+		PlatformID:       si.PlatformID.String,
+		ServicePlanID:    si.ServicePlanID,
+		Context:          getJSONRawMessage(si.Context),
+		Parameters:       getJSONRawMessage(si.Parameters),
+		DashboardURL:     si.DashboardURL,
+		Ready:            si.Ready,
+		PendingOperation: si.PendingOperation,
+		CreatedAt:        si.CreatedAt,
+		UpdatedAt:        si.UpdatedAt,
+	}
+}
+
+func (si *ServiceInstance) FromDTO(serviceInstance *types.ServiceInstance) {
+	*si = ServiceInstance{
+		ID:               serviceInstance.ID,
+		BrokerID:         serviceInstance.BrokerID,
+		PlatformID:       toNullString(serviceInstance.PlatformID),
+		ServicePlanID:    serviceInstance.ServicePlanID,
+		Context:          getJSONText(serviceInstance.Context),
+		Parameters:       getJSONText(serviceInstance.Parameters),
+		DashboardURL:     serviceInstance.DashboardURL,
+		Ready:            serviceInstance.Ready,
+		PendingOperation: serviceInstance.PendingOperation,
+		CreatedAt:        serviceInstance.CreatedAt,
+		UpdatedAt:        serviceInstance.UpdatedAt,
+	}
+}
+
+func (sb *ServiceBinding) ToDTO() *types.ServiceBinding {
+	return &types.ServiceBinding{
+		ID:                sb.ID,
+		ServiceInstanceID: sb.ServiceInstanceID,
+		BrokerID:          sb.BrokerID,
+		PlatformID:        sb.PlatformID.String,
+		Context:           getJSONRawMessage(sb.Context),
+		Parameters:        getJSONRawMessage(sb.Parameters),
+		Credentials:       sb.Credentials,
+		Ready:             sb.Ready,
+		PendingOperation:  sb.PendingOperation,
+		CreatedAt:         sb.CreatedAt,
+		UpdatedAt:         sb.UpdatedAt,
+	}
+}
+
+func (sb *ServiceBinding) FromDTO(serviceBinding *types.ServiceBinding) {
+	*sb = ServiceBinding{
+		ID:                serviceBinding.ID,
+		ServiceInstanceID: serviceBinding.ServiceInstanceID,
+		BrokerID:          serviceBinding.BrokerID,
+		PlatformID:        toNullString(serviceBinding.PlatformID),
+		Context:           getJSONText(serviceBinding.Context),
+		Parameters:        getJSONText(serviceBinding.Parameters),
+		Credentials:       serviceBinding.Credentials,
+		Ready:             serviceBinding.Ready,
+		PendingOperation:  serviceBinding.PendingOperation,
+		CreatedAt:         serviceBinding.CreatedAt,
+		UpdatedAt:         serviceBinding.UpdatedAt,
+	}
+}
+
+func (o *Operation) ToDTO() *types.Operation {
+	return &types.Operation{
+		ID:                o.ID,
+		Type:              o.Type,
+		State:             o.State,
+		ResourceType:      o.ResourceType,
+		ResourceID:        o.ResourceID,
+		ServiceInstanceID: o.ServiceInstanceID.String,
+		BrokerID:          o.BrokerID,
+		Token:             o.Token,
+		Description:       o.Description,
+		Deadline:          o.Deadline.Time,
+		CreatedAt:         o.CreatedAt,
+		UpdatedAt:         o.UpdatedAt,
+	}
+}
+
+func (o *Operation) FromDTO(operation *types.Operation) {
+	*o = Operation{
+		ID:                operation.ID,
+		Type:              operation.Type,
+		State:             operation.State,
+		ResourceType:      operation.ResourceType,
+		ResourceID:        operation.ResourceID,
+		ServiceInstanceID: toNullString(operation.ServiceInstanceID),
+		BrokerID:          operation.BrokerID,
+		Token:             operation.Token,
+		Description:       operation.Description,
+		Deadline:          toNullTime(operation.Deadline),
+		CreatedAt:         operation.CreatedAt,
+		UpdatedAt:         operation.UpdatedAt,
+	}
+}
+
+func (om *OrphanMitigation) ToDTO() *types.OrphanMitigation {
+	return &types.OrphanMitigation{
+		ID:               om.ID,
+		BrokerID:         om.BrokerID,
+		ResourceType:     om.ResourceType,
+		Path:             om.Path,
+		ServiceID:        om.ServiceID,
+		PlanID:           om.PlanID,
+		Attempts:         om.Attempts,
+		NextAttemptAt:    om.NextAttemptAt,
+		LastError:        om.LastError,
+		DeletionAccepted: om.DeletionAccepted,
+		Operation:        om.Operation,
+		CreatedAt:        om.CreatedAt,
+		UpdatedAt:        om.UpdatedAt,
+	}
+}
+
+func (om *OrphanMitigation) FromDTO(orphanMitigation *types.OrphanMitigation) {
+	*om = OrphanMitigation{
+		ID:               orphanMitigation.ID,
+		BrokerID:         orphanMitigation.BrokerID,
+		ResourceType:     orphanMitigation.ResourceType,
+		Path:             orphanMitigation.Path,
+		ServiceID:        orphanMitigation.ServiceID,
+		PlanID:           orphanMitigation.PlanID,
+		Attempts:         orphanMitigation.Attempts,
+		NextAttemptAt:    orphanMitigation.NextAttemptAt,
+		LastError:        orphanMitigation.LastError,
+		DeletionAccepted: orphanMitigation.DeletionAccepted,
+		Operation:        orphanMitigation.Operation,
+		CreatedAt:        orphanMitigation.CreatedAt,
+		UpdatedAt:        orphanMitigation.UpdatedAt,
+	}
+}
+
 func getJSONText(item json.RawMessage) sqlxtypes.JSONText {
-	if len(item) == len("null") && string(item) == "null" {
+	if len(item) == 0 || len(item) == len("null") && string(item) == "null" {
 		return sqlxtypes.JSONText("{}")
 	}
 	return sqlxtypes.JSONText(item)
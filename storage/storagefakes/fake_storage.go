@@ -74,6 +74,51 @@ type FakeStorage struct {
 	visibilityReturnsOnCall map[int]struct {
 		result1 storage.Visibility
 	}
+	CatalogOverrideStub        func() storage.CatalogOverride
+	catalogOverrideMutex       sync.RWMutex
+	catalogOverrideArgsForCall []struct{}
+	catalogOverrideReturns     struct {
+		result1 storage.CatalogOverride
+	}
+	catalogOverrideReturnsOnCall map[int]struct {
+		result1 storage.CatalogOverride
+	}
+	ServiceInstanceStub        func() storage.ServiceInstance
+	serviceInstanceMutex       sync.RWMutex
+	serviceInstanceArgsForCall []struct{}
+	serviceInstanceReturns     struct {
+		result1 storage.ServiceInstance
+	}
+	serviceInstanceReturnsOnCall map[int]struct {
+		result1 storage.ServiceInstance
+	}
+	ServiceBindingStub        func() storage.ServiceBinding
+	serviceBindingMutex       sync.RWMutex
+	serviceBindingArgsForCall []struct{}
+	serviceBindingReturns     struct {
+		result1 storage.ServiceBinding
+	}
+	serviceBindingReturnsOnCall map[int]struct {
+		result1 storage.ServiceBinding
+	}
+	OperationStub        func() storage.Operation
+	operationMutex       sync.RWMutex
+	operationArgsForCall []struct{}
+	operationReturns     struct {
+		result1 storage.Operation
+	}
+	operationReturnsOnCall map[int]struct {
+		result1 storage.Operation
+	}
+	OrphanMitigationStub        func() storage.OrphanMitigation
+	orphanMitigationMutex       sync.RWMutex
+	orphanMitigationArgsForCall []struct{}
+	orphanMitigationReturns     struct {
+		result1 storage.OrphanMitigation
+	}
+	orphanMitigationReturnsOnCall map[int]struct {
+		result1 storage.OrphanMitigation
+	}
 	PlatformStub        func() storage.Platform
 	platformMutex       sync.RWMutex
 	platformArgsForCall []struct{}
@@ -405,6 +450,206 @@ func (fake *FakeStorage) VisibilityReturnsOnCall(i int, result1 storage.Visibili
 	}{result1}
 }
 
+func (fake *FakeStorage) CatalogOverride() storage.CatalogOverride {
+	fake.catalogOverrideMutex.Lock()
+	ret, specificReturn := fake.catalogOverrideReturnsOnCall[len(fake.catalogOverrideArgsForCall)]
+	fake.catalogOverrideArgsForCall = append(fake.catalogOverrideArgsForCall, struct{}{})
+	fake.recordInvocation("CatalogOverride", []interface{}{})
+	fake.catalogOverrideMutex.Unlock()
+	if fake.CatalogOverrideStub != nil {
+		return fake.CatalogOverrideStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.catalogOverrideReturns.result1
+}
+
+func (fake *FakeStorage) CatalogOverrideCallCount() int {
+	fake.catalogOverrideMutex.RLock()
+	defer fake.catalogOverrideMutex.RUnlock()
+	return len(fake.catalogOverrideArgsForCall)
+}
+
+func (fake *FakeStorage) CatalogOverrideReturns(result1 storage.CatalogOverride) {
+	fake.CatalogOverrideStub = nil
+	fake.catalogOverrideReturns = struct {
+		result1 storage.CatalogOverride
+	}{result1}
+}
+
+func (fake *FakeStorage) CatalogOverrideReturnsOnCall(i int, result1 storage.CatalogOverride) {
+	fake.CatalogOverrideStub = nil
+	if fake.catalogOverrideReturnsOnCall == nil {
+		fake.catalogOverrideReturnsOnCall = make(map[int]struct {
+			result1 storage.CatalogOverride
+		})
+	}
+	fake.catalogOverrideReturnsOnCall[i] = struct {
+		result1 storage.CatalogOverride
+	}{result1}
+}
+
+func (fake *FakeStorage) ServiceInstance() storage.ServiceInstance {
+	fake.serviceInstanceMutex.Lock()
+	ret, specificReturn := fake.serviceInstanceReturnsOnCall[len(fake.serviceInstanceArgsForCall)]
+	fake.serviceInstanceArgsForCall = append(fake.serviceInstanceArgsForCall, struct{}{})
+	fake.recordInvocation("ServiceInstance", []interface{}{})
+	fake.serviceInstanceMutex.Unlock()
+	if fake.ServiceInstanceStub != nil {
+		return fake.ServiceInstanceStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.serviceInstanceReturns.result1
+}
+
+func (fake *FakeStorage) ServiceInstanceCallCount() int {
+	fake.serviceInstanceMutex.RLock()
+	defer fake.serviceInstanceMutex.RUnlock()
+	return len(fake.serviceInstanceArgsForCall)
+}
+
+func (fake *FakeStorage) ServiceInstanceReturns(result1 storage.ServiceInstance) {
+	fake.ServiceInstanceStub = nil
+	fake.serviceInstanceReturns = struct {
+		result1 storage.ServiceInstance
+	}{result1}
+}
+
+func (fake *FakeStorage) ServiceInstanceReturnsOnCall(i int, result1 storage.ServiceInstance) {
+	fake.ServiceInstanceStub = nil
+	if fake.serviceInstanceReturnsOnCall == nil {
+		fake.serviceInstanceReturnsOnCall = make(map[int]struct {
+			result1 storage.ServiceInstance
+		})
+	}
+	fake.serviceInstanceReturnsOnCall[i] = struct {
+		result1 storage.ServiceInstance
+	}{result1}
+}
+
+func (fake *FakeStorage) ServiceBinding() storage.ServiceBinding {
+	fake.serviceBindingMutex.Lock()
+	ret, specificReturn := fake.serviceBindingReturnsOnCall[len(fake.serviceBindingArgsForCall)]
+	fake.serviceBindingArgsForCall = append(fake.serviceBindingArgsForCall, struct{}{})
+	fake.recordInvocation("ServiceBinding", []interface{}{})
+	fake.serviceBindingMutex.Unlock()
+	if fake.ServiceBindingStub != nil {
+		return fake.ServiceBindingStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.serviceBindingReturns.result1
+}
+
+func (fake *FakeStorage) ServiceBindingCallCount() int {
+	fake.serviceBindingMutex.RLock()
+	defer fake.serviceBindingMutex.RUnlock()
+	return len(fake.serviceBindingArgsForCall)
+}
+
+func (fake *FakeStorage) ServiceBindingReturns(result1 storage.ServiceBinding) {
+	fake.ServiceBindingStub = nil
+	fake.serviceBindingReturns = struct {
+		result1 storage.ServiceBinding
+	}{result1}
+}
+
+func (fake *FakeStorage) ServiceBindingReturnsOnCall(i int, result1 storage.ServiceBinding) {
+	fake.ServiceBindingStub = nil
+	if fake.serviceBindingReturnsOnCall == nil {
+		fake.serviceBindingReturnsOnCall = make(map[int]struct {
+			result1 storage.ServiceBinding
+		})
+	}
+	fake.serviceBindingReturnsOnCall[i] = struct {
+		result1 storage.ServiceBinding
+	}{result1}
+}
+
+func (fake *FakeStorage) Operation() storage.Operation {
+	fake.operationMutex.Lock()
+	ret, specificReturn := fake.operationReturnsOnCall[len(fake.operationArgsForCall)]
+	fake.operationArgsForCall = append(fake.operationArgsForCall, struct{}{})
+	fake.recordInvocation("Operation", []interface{}{})
+	fake.operationMutex.Unlock()
+	if fake.OperationStub != nil {
+		return fake.OperationStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.operationReturns.result1
+}
+
+func (fake *FakeStorage) OperationCallCount() int {
+	fake.operationMutex.RLock()
+	defer fake.operationMutex.RUnlock()
+	return len(fake.operationArgsForCall)
+}
+
+func (fake *FakeStorage) OperationReturns(result1 storage.Operation) {
+	fake.OperationStub = nil
+	fake.operationReturns = struct {
+		result1 storage.Operation
+	}{result1}
+}
+
+func (fake *FakeStorage) OperationReturnsOnCall(i int, result1 storage.Operation) {
+	fake.OperationStub = nil
+	if fake.operationReturnsOnCall == nil {
+		fake.operationReturnsOnCall = make(map[int]struct {
+			result1 storage.Operation
+		})
+	}
+	fake.operationReturnsOnCall[i] = struct {
+		result1 storage.Operation
+	}{result1}
+}
+
+func (fake *FakeStorage) OrphanMitigation() storage.OrphanMitigation {
+	fake.orphanMitigationMutex.Lock()
+	ret, specificReturn := fake.orphanMitigationReturnsOnCall[len(fake.orphanMitigationArgsForCall)]
+	fake.orphanMitigationArgsForCall = append(fake.orphanMitigationArgsForCall, struct{}{})
+	fake.recordInvocation("OrphanMitigation", []interface{}{})
+	fake.orphanMitigationMutex.Unlock()
+	if fake.OrphanMitigationStub != nil {
+		return fake.OrphanMitigationStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.orphanMitigationReturns.result1
+}
+
+func (fake *FakeStorage) OrphanMitigationCallCount() int {
+	fake.orphanMitigationMutex.RLock()
+	defer fake.orphanMitigationMutex.RUnlock()
+	return len(fake.orphanMitigationArgsForCall)
+}
+
+func (fake *FakeStorage) OrphanMitigationReturns(result1 storage.OrphanMitigation) {
+	fake.OrphanMitigationStub = nil
+	fake.orphanMitigationReturns = struct {
+		result1 storage.OrphanMitigation
+	}{result1}
+}
+
+func (fake *FakeStorage) OrphanMitigationReturnsOnCall(i int, result1 storage.OrphanMitigation) {
+	fake.OrphanMitigationStub = nil
+	if fake.orphanMitigationReturnsOnCall == nil {
+		fake.orphanMitigationReturnsOnCall = make(map[int]struct {
+			result1 storage.OrphanMitigation
+		})
+	}
+	fake.orphanMitigationReturnsOnCall[i] = struct {
+		result1 storage.OrphanMitigation
+	}{result1}
+}
+
 func (fake *FakeStorage) Platform() storage.Platform {
 	fake.platformMutex.Lock()
 	ret, specificReturn := fake.platformReturnsOnCall[len(fake.platformArgsForCall)]
@@ -591,6 +836,16 @@ func (fake *FakeStorage) Invocations() map[string][][]interface{} {
 	defer fake.servicePlanMutex.RUnlock()
 	fake.visibilityMutex.RLock()
 	defer fake.visibilityMutex.RUnlock()
+	fake.catalogOverrideMutex.RLock()
+	defer fake.catalogOverrideMutex.RUnlock()
+	fake.serviceInstanceMutex.RLock()
+	defer fake.serviceInstanceMutex.RUnlock()
+	fake.serviceBindingMutex.RLock()
+	defer fake.serviceBindingMutex.RUnlock()
+	fake.operationMutex.RLock()
+	defer fake.operationMutex.RUnlock()
+	fake.orphanMitigationMutex.RLock()
+	defer fake.orphanMitigationMutex.RUnlock()
 	fake.platformMutex.RLock()
 	defer fake.platformMutex.RUnlock()
 	fake.credentialsMutex.RLock()
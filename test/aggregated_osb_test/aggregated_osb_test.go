@@ -0,0 +1,313 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package aggregated_osb_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/tidwall/gjson"
+
+	"github.com/Peripli/service-manager/pkg/env"
+	"github.com/Peripli/service-manager/test/common"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestAggregatedOSB(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Aggregated OSB API Tests Suite")
+}
+
+var _ = Describe("Aggregated OSB API", func() {
+	const catalogURL = "/v1/aggregated_osb/v2/catalog"
+
+	var (
+		ctx *common.TestContext
+
+		brokerID1     string
+		brokerServer1 *common.BrokerServer
+		brokerID2     string
+		brokerServer2 *common.BrokerServer
+
+		instanceURL string
+	)
+
+	catalogIDs := func(brokerServer *common.BrokerServer) (string, string) {
+		catalog := string(brokerServer.Catalog)
+		return gjson.Get(catalog, "services.0.id").Str, gjson.Get(catalog, "services.0.plans.0.id").Str
+	}
+
+	provisionRequest := func(brokerServer *common.BrokerServer) common.Object {
+		serviceID, planID := catalogIDs(brokerServer)
+		return common.Object{
+			"service_id": serviceID,
+			"plan_id":    planID,
+		}
+	}
+
+	// publishPlans makes the plans of the broker visible to all platforms
+	publishPlans := func(brokerID string) {
+		serviceOfferingIDs := ctx.SMWithOAuth.GET("/v1/service_offerings").
+			WithQuery("fieldQuery", "broker_id = "+brokerID).
+			Expect().Status(http.StatusOK).
+			JSON().Path("$.service_offerings[*].id").Array().Iter()
+		for _, serviceOfferingID := range serviceOfferingIDs {
+			servicePlanIDs := ctx.SMWithOAuth.GET("/v1/service_plans").
+				WithQuery("fieldQuery", "service_offering_id = "+serviceOfferingID.String().Raw()).
+				Expect().Status(http.StatusOK).
+				JSON().Path("$.service_plans[*].id").Array().Iter()
+			for _, servicePlanID := range servicePlanIDs {
+				ctx.SMWithOAuth.POST("/v1/visibilities").
+					WithJSON(common.Object{"service_plan_id": servicePlanID.String().Raw()}).
+					Expect().Status(http.StatusCreated)
+			}
+		}
+	}
+
+	BeforeSuite(func() {
+		ctx = common.NewTestContextBuilder().WithEnvPostExtensions(func(e env.Environment, servers map[string]common.FakeServer) {
+			e.Set("api.enforce_visibilities", true)
+		}).Build()
+	})
+
+	AfterSuite(func() {
+		ctx.Cleanup()
+	})
+
+	BeforeEach(func() {
+		brokerID1, _, brokerServer1 = ctx.RegisterBroker()
+		brokerID2, _, brokerServer2 = ctx.RegisterBroker()
+		publishPlans(brokerID1)
+		publishPlans(brokerID2)
+
+		UUID, err := uuid.NewV4()
+		Expect(err).ToNot(HaveOccurred())
+		instanceURL = "/v1/aggregated_osb/v2/service_instances/" + UUID.String()
+	})
+
+	AfterEach(func() {
+		ctx.CleanupBroker(brokerID1)
+		ctx.CleanupBroker(brokerID2)
+	})
+
+	Describe("catalog", func() {
+		It("merges the catalogs of all brokers", func() {
+			serviceID1, planID1 := catalogIDs(brokerServer1)
+			serviceID2, planID2 := catalogIDs(brokerServer2)
+
+			catalog := ctx.SMWithBasic.GET(catalogURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				Expect().Status(http.StatusOK).JSON()
+			catalog.Path("$.services[*].id").Array().Contains(serviceID1, serviceID2)
+			catalog.Path("$.services[*].plans[*].id").Array().Contains(planID1, planID2)
+		})
+
+		Context("when the catalog ids of brokers collide", func() {
+			var collidingBrokerID string
+
+			BeforeEach(func() {
+				collidingBrokerID, _, _ = ctx.RegisterBrokerWithCatalog(brokerServer1.Catalog)
+				publishPlans(collidingBrokerID)
+			})
+
+			AfterEach(func() {
+				ctx.CleanupBroker(collidingBrokerID)
+			})
+
+			It("leaves out the colliding services", func() {
+				serviceID1, _ := catalogIDs(brokerServer1)
+				serviceID2, _ := catalogIDs(brokerServer2)
+
+				catalog := ctx.SMWithBasic.GET(catalogURL).
+					WithHeader("X-Broker-API-Version", "oidc_authn.13").
+					Expect().Status(http.StatusOK).JSON()
+				catalog.Path("$.services[*].id").Array().Contains(serviceID2).NotContains(serviceID1)
+			})
+
+			It("rejects requests for the colliding services", func() {
+				ctx.SMWithBasic.PUT(instanceURL).
+					WithHeader("X-Broker-API-Version", "oidc_authn.13").
+					WithJSON(provisionRequest(brokerServer1)).
+					Expect().Status(http.StatusConflict).
+					JSON().Object().Keys().Contains("error", "description")
+				Expect(brokerServer1.ServiceInstanceEndpointRequests).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("routing", func() {
+		It("forwards provision requests to the broker which offers the service", func() {
+			ctx.SMWithBasic.PUT(instanceURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				WithJSON(provisionRequest(brokerServer2)).
+				Expect().Status(http.StatusCreated)
+
+			Expect(brokerServer1.ServiceInstanceEndpointRequests).To(BeEmpty())
+			Expect(brokerServer2.ServiceInstanceEndpointRequests).To(HaveLen(1))
+		})
+
+		It("rejects requests for services which are not offered", func() {
+			ctx.SMWithBasic.PUT(instanceURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				WithJSON(common.Object{
+					"service_id": "unknown-service",
+					"plan_id":    "unknown-plan",
+				}).
+				Expect().Status(http.StatusBadRequest)
+		})
+
+		It("rejects requests for plans of other services", func() {
+			serviceID1, _ := catalogIDs(brokerServer1)
+			_, planID2 := catalogIDs(brokerServer2)
+			ctx.SMWithBasic.PUT(instanceURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				WithJSON(common.Object{
+					"service_id": serviceID1,
+					"plan_id":    planID2,
+				}).
+				Expect().Status(http.StatusBadRequest)
+		})
+
+		It("adds the platform context to the requests routed to brokers which enabled it", func() {
+			ctx.SMWithOAuth.PATCH("/v1/service_brokers/" + brokerID2).
+				WithJSON(common.Object{"platform_context": "enabled"}).
+				Expect().Status(http.StatusOK)
+
+			ctx.SMWithBasic.PUT(instanceURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				WithJSON(provisionRequest(brokerServer2)).
+				Expect().Status(http.StatusCreated)
+
+			platformContext := gjson.GetBytes(brokerServer2.LastRequestBody, "context.service_manager")
+			Expect(platformContext.Get("platform_id").String()).To(Equal(ctx.TestPlatform.ID))
+			Expect(platformContext.Get("platform_type").String()).To(Equal(ctx.TestPlatform.Type))
+		})
+
+		It("does not route requests to disabled brokers", func() {
+			ctx.SMWithOAuth.PATCH("/v1/service_brokers/" + brokerID2).
+				WithJSON(common.Object{"state": "disabled"}).
+				Expect().Status(http.StatusOK)
+
+			ctx.SMWithBasic.PUT(instanceURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				WithJSON(provisionRequest(brokerServer2)).
+				Expect().Status(http.StatusBadRequest)
+			Expect(brokerServer2.ServiceInstanceEndpointRequests).To(BeEmpty())
+		})
+
+		It("forwards requests without a body to the broker of the tracked service instance", func() {
+			ctx.SMWithBasic.PUT(instanceURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				WithJSON(provisionRequest(brokerServer2)).
+				Expect().Status(http.StatusCreated)
+
+			ctx.SMWithBasic.GET(instanceURL+"/last_operation").
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				Expect().Status(http.StatusOK)
+			Expect(brokerServer2.ServiceInstanceLastOpEndpointRequests).To(HaveLen(1))
+
+			ctx.SMWithBasic.PUT(instanceURL+"/service_bindings/binding-id").
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				WithJSON(provisionRequest(brokerServer2)).
+				Expect().Status(http.StatusCreated)
+			Expect(brokerServer2.BindingEndpointRequests).To(HaveLen(1))
+
+			ctx.SMWithBasic.DELETE(instanceURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				Expect().Status(http.StatusOK)
+			Expect(brokerServer1.ServiceInstanceEndpointRequests).To(BeEmpty())
+			Expect(brokerServer2.ServiceInstanceEndpointRequests).To(HaveLen(2))
+		})
+
+		It("forwards requests for untracked service instances by the catalog ids in the query", func() {
+			serviceID, planID := catalogIDs(brokerServer1)
+			ctx.SMWithBasic.DELETE(instanceURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				WithQuery("service_id", serviceID).
+				WithQuery("plan_id", planID).
+				Expect().Status(http.StatusOK)
+			Expect(brokerServer1.ServiceInstanceEndpointRequests).To(HaveLen(1))
+		})
+
+		It("rejects requests which cannot be routed", func() {
+			ctx.SMWithBasic.GET(instanceURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				Expect().Status(http.StatusNotFound)
+		})
+	})
+
+	Describe("visibility enforcement", func() {
+		var (
+			hiddenBrokerID     string
+			hiddenBrokerServer *common.BrokerServer
+		)
+
+		BeforeEach(func() {
+			hiddenBrokerID, _, hiddenBrokerServer = ctx.RegisterBroker()
+		})
+
+		AfterEach(func() {
+			ctx.CleanupBroker(hiddenBrokerID)
+		})
+
+		It("rejects provision requests of platforms for plans which are not visible to them", func() {
+			ctx.SMWithBasic.PUT(instanceURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				WithJSON(provisionRequest(hiddenBrokerServer)).
+				Expect().Status(http.StatusForbidden)
+
+			Expect(hiddenBrokerServer.ServiceInstanceEndpointRequests).To(BeEmpty())
+		})
+
+		It("forwards provision requests of platforms for plans which are visible to them", func() {
+			_, planID := catalogIDs(hiddenBrokerServer)
+			servicePlanID := ctx.SMWithOAuth.GET("/v1/service_plans").
+				WithQuery("fieldQuery", "catalog_id = "+planID).
+				Expect().Status(http.StatusOK).
+				JSON().Path("$.service_plans[0].id").String().Raw()
+			ctx.SMWithOAuth.POST("/v1/visibilities").
+				WithJSON(common.Object{"service_plan_id": servicePlanID, "platform_id": ctx.TestPlatform.ID}).
+				Expect().Status(http.StatusCreated)
+
+			ctx.SMWithBasic.PUT(instanceURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				WithJSON(provisionRequest(hiddenBrokerServer)).
+				Expect().Status(http.StatusCreated)
+
+			Expect(hiddenBrokerServer.ServiceInstanceEndpointRequests).To(HaveLen(1))
+		})
+
+		It("checks the plan of the broker to which the request is routed when disabled brokers offer the same plan", func() {
+			disabledBrokerID, _, _ := ctx.RegisterBrokerWithCatalog(hiddenBrokerServer.Catalog)
+			defer ctx.CleanupBroker(disabledBrokerID)
+			publishPlans(disabledBrokerID)
+			ctx.SMWithOAuth.PATCH("/v1/service_brokers/" + disabledBrokerID).
+				WithJSON(common.Object{"state": "disabled"}).
+				Expect().Status(http.StatusOK)
+
+			ctx.SMWithBasic.PUT(instanceURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				WithJSON(provisionRequest(hiddenBrokerServer)).
+				Expect().Status(http.StatusForbidden)
+
+			Expect(hiddenBrokerServer.ServiceInstanceEndpointRequests).To(BeEmpty())
+		})
+	})
+})
@@ -191,6 +191,70 @@ var _ = Describe("Service Manager Authentication", func() {
 			{"Invalid authorization schema", "DELETE", "/v1/visibilities/999", "Basic abc"},
 			{"Missing token in authorization header", "DELETE", "/v1/visibilities/999", "Bearer "},
 			{"Invalid token in authorization header", "DELETE", "/v1/visibilities/999", "Bearer abc"},
+
+			// CATALOG OVERRIDES
+			{"Missing authorization header", "GET", "/v1/catalog_overrides/999", ""},
+			{"Invalid authorization schema", "GET", "/v1/catalog_overrides/999", "Basic abc"},
+			{"Missing token in authorization header", "GET", "/v1/catalog_overrides/999", "Bearer "},
+			{"Invalid token in authorization header", "GET", "/v1/catalog_overrides/999", "Bearer abc"},
+
+			{"Missing authorization header", "GET", "/v1/catalog_overrides", ""},
+			{"Invalid authorization schema", "GET", "/v1/catalog_overrides", "Basic abc"},
+			{"Missing token in authorization header", "GET", "/v1/catalog_overrides", "Bearer "},
+			{"Invalid token in authorization header", "GET", "/v1/catalog_overrides", "Bearer abc"},
+
+			{"Missing authorization header", "POST", "/v1/catalog_overrides", ""},
+			{"Invalid authorization schema", "POST", "/v1/catalog_overrides", "Basic abc"},
+			{"Missing token in authorization header", "POST", "/v1/catalog_overrides", "Bearer "},
+			{"Invalid token in authorization header", "POST", "/v1/catalog_overrides", "Bearer abc"},
+
+			{"Missing authorization header", "PATCH", "/v1/catalog_overrides/999", ""},
+			{"Invalid authorization schema", "PATCH", "/v1/catalog_overrides/999", "Basic abc"},
+			{"Missing token in authorization header", "PATCH", "/v1/catalog_overrides/999", "Bearer "},
+			{"Invalid token in authorization header", "PATCH", "/v1/catalog_overrides/999", "Bearer abc"},
+
+			{"Missing authorization header", "DELETE", "/v1/catalog_overrides", ""},
+			{"Invalid authorization schema", "DELETE", "/v1/catalog_overrides", "Basic abc"},
+			{"Missing token in authorization header", "DELETE", "/v1/catalog_overrides", "Bearer "},
+			{"Invalid token in authorization header", "DELETE", "/v1/catalog_overrides", "Bearer abc"},
+
+			{"Missing authorization header", "DELETE", "/v1/catalog_overrides/999", ""},
+			{"Invalid authorization schema", "DELETE", "/v1/catalog_overrides/999", "Basic abc"},
+			{"Missing token in authorization header", "DELETE", "/v1/catalog_overrides/999", "Bearer "},
+			{"Invalid token in authorization header", "DELETE", "/v1/catalog_overrides/999", "Bearer abc"},
+
+			// SERVICE INSTANCES
+			{"Missing authorization header", "GET", "/v1/service_instances/999", ""},
+			{"Invalid basic credentials", "GET", "/v1/service_instances/999", "Basic abc"},
+			{"Missing token in authorization header", "GET", "/v1/service_instances/999", "Bearer "},
+			{"Invalid token in authorization header", "GET", "/v1/service_instances/999", "Bearer abc"},
+
+			{"Missing authorization header", "GET", "/v1/service_instances", ""},
+			{"Invalid basic credentials", "GET", "/v1/service_instances", "Basic abc"},
+			{"Missing token in authorization header", "GET", "/v1/service_instances", "Bearer "},
+			{"Invalid token in authorization header", "GET", "/v1/service_instances", "Bearer abc"},
+
+			// SERVICE BINDINGS
+			{"Missing authorization header", "GET", "/v1/service_bindings/999", ""},
+			{"Invalid basic credentials", "GET", "/v1/service_bindings/999", "Basic abc"},
+			{"Missing token in authorization header", "GET", "/v1/service_bindings/999", "Bearer "},
+			{"Invalid token in authorization header", "GET", "/v1/service_bindings/999", "Bearer abc"},
+
+			{"Missing authorization header", "GET", "/v1/service_bindings", ""},
+			{"Invalid basic credentials", "GET", "/v1/service_bindings", "Basic abc"},
+			{"Missing token in authorization header", "GET", "/v1/service_bindings", "Bearer "},
+			{"Invalid token in authorization header", "GET", "/v1/service_bindings", "Bearer abc"},
+
+			// OPERATIONS
+			{"Missing authorization header", "GET", "/v1/operations/999", ""},
+			{"Invalid basic credentials", "GET", "/v1/operations/999", "Basic abc"},
+			{"Missing token in authorization header", "GET", "/v1/operations/999", "Bearer "},
+			{"Invalid token in authorization header", "GET", "/v1/operations/999", "Bearer abc"},
+
+			{"Missing authorization header", "GET", "/v1/operations", ""},
+			{"Invalid basic credentials", "GET", "/v1/operations", "Basic abc"},
+			{"Missing token in authorization header", "GET", "/v1/operations", "Bearer "},
+			{"Invalid token in authorization header", "GET", "/v1/operations", "Bearer abc"},
 		}
 
 		for _, request := range authRequests {
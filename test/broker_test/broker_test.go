@@ -20,6 +20,7 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/Peripli/service-manager/pkg/types"
 
@@ -208,6 +209,66 @@ var _ = test.DescribeTestsFor(test.TestCase{
 					})
 				})
 
+				Context("when oauth2 credentials are incomplete", func() {
+					BeforeEach(func() {
+						postBrokerRequestWithNoLabels["credentials"] = common.Object{
+							"oauth2": common.Object{
+								"token_url": "http://localhost:12345/oauth/token",
+								"client_id": "client",
+							},
+						}
+					})
+
+					It("returns 400", func() {
+						ctx.SMWithOAuth.POST("/v1/service_brokers").WithJSON(postBrokerRequestWithNoLabels).
+							Expect().
+							Status(http.StatusBadRequest).
+							JSON().Object().
+							Keys().Contains("error", "description")
+
+						assertInvocationCount(brokerServer.CatalogEndpointRequests, 0)
+					})
+				})
+
+				Context("when tls credentials contain an invalid certificate", func() {
+					BeforeEach(func() {
+						postBrokerRequestWithNoLabels["credentials"] = common.Object{
+							"tls": common.Object{
+								"certificate": "invalid",
+								"key":         "invalid",
+							},
+						}
+					})
+
+					It("returns 400", func() {
+						ctx.SMWithOAuth.POST("/v1/service_brokers").WithJSON(postBrokerRequestWithNoLabels).
+							Expect().
+							Status(http.StatusBadRequest).
+							JSON().Object().
+							Keys().Contains("error", "description")
+
+						assertInvocationCount(brokerServer.CatalogEndpointRequests, 0)
+					})
+				})
+
+				Context("when tls trust settings contain invalid ca certificates", func() {
+					BeforeEach(func() {
+						postBrokerRequestWithNoLabels["tls"] = common.Object{
+							"ca_certificates": "invalid",
+						}
+					})
+
+					It("returns 400", func() {
+						ctx.SMWithOAuth.POST("/v1/service_brokers").WithJSON(postBrokerRequestWithNoLabels).
+							Expect().
+							Status(http.StatusBadRequest).
+							JSON().Object().
+							Keys().Contains("error", "description")
+
+						assertInvocationCount(brokerServer.CatalogEndpointRequests, 0)
+					})
+				})
+
 				Context("when obtaining the broker catalog fails because the broker is not reachable", func() {
 					BeforeEach(func() {
 						postBrokerRequestWithNoLabels["broker_url"] = "http://localhost:12345"
@@ -267,9 +328,21 @@ var _ = test.DescribeTestsFor(test.TestCase{
 						})
 
 						Context("that has an empty description", func() {
-							verifyPOSTWhenCatalogFieldIsMissing(func(r *httpexpect.Response) {
-								r.Status(http.StatusCreated).JSON().Object().Keys().NotContains("services", "credentials")
-							}, "services.0.description")
+							Context("when catalog validation is strict", func() {
+								verifyPOSTWhenCatalogFieldIsMissing(func(r *httpexpect.Response) {
+									r.Status(http.StatusBadRequest).JSON().Object().Keys().Contains("error", "description", "details")
+								}, "services.0.description")
+							})
+
+							Context("when catalog validation is lenient", func() {
+								BeforeEach(func() {
+									postBrokerRequestWithNoLabels["catalog_validation"] = "lenient"
+								})
+
+								verifyPOSTWhenCatalogFieldIsMissing(func(r *httpexpect.Response) {
+									r.Status(http.StatusCreated).JSON().Object().Keys().NotContains("services", "credentials")
+								}, "services.0.description")
+							})
 						})
 
 						Context("that has invalid tags", func() {
@@ -305,9 +378,21 @@ var _ = test.DescribeTestsFor(test.TestCase{
 						})
 
 						Context("that has an empty description", func() {
-							verifyPOSTWhenCatalogFieldIsMissing(func(r *httpexpect.Response) {
-								r.Status(http.StatusCreated).JSON().Object().Keys().NotContains("services", "credentials")
-							}, "services.0.plans.0.description")
+							Context("when catalog validation is strict", func() {
+								verifyPOSTWhenCatalogFieldIsMissing(func(r *httpexpect.Response) {
+									r.Status(http.StatusBadRequest).JSON().Object().Keys().Contains("error", "description", "details")
+								}, "services.0.plans.0.description")
+							})
+
+							Context("when catalog validation is lenient", func() {
+								BeforeEach(func() {
+									postBrokerRequestWithNoLabels["catalog_validation"] = "lenient"
+								})
+
+								verifyPOSTWhenCatalogFieldIsMissing(func(r *httpexpect.Response) {
+									r.Status(http.StatusCreated).JSON().Object().Keys().NotContains("services", "credentials")
+								}, "services.0.plans.0.description")
+							})
 						})
 
 						Context("that has invalid metadata", func() {
@@ -324,6 +409,113 @@ var _ = test.DescribeTestsFor(test.TestCase{
 					})
 				})
 
+				Context("when the broker catalog does not conform to the OSB specification", func() {
+					BeforeEach(func() {
+						catalog := common.NewEmptySBCatalog()
+						plan := common.GenerateTestPlan()
+						catalog.AddService(common.GenerateTestServiceWithPlans(plan))
+						catalog.AddService(common.GenerateTestServiceWithPlans(plan))
+						invalidSchemas, err := sjson.Set(string(catalog), "services.0.plans.0.schemas.service_instance.create.parameters.type", 5)
+						Expect(err).ToNot(HaveOccurred())
+						brokerServer.Catalog = common.SBCatalog(invalidSchemas)
+					})
+
+					Context("when catalog validation is strict", func() {
+						It("returns 400 with the list of violations", func() {
+							details := ctx.SMWithOAuth.POST("/v1/service_brokers").WithJSON(postBrokerRequestWithNoLabels).
+								Expect().
+								Status(http.StatusBadRequest).
+								JSON().Object().Value("details").Array()
+
+							details.Length().Equal(2)
+							details.Element(0).Object().ValueEqual("path", "services[0].plans[0].schemas.service_instance.create.parameters")
+							details.Element(1).Object().ValueEqual("path", "services[1].plans[0].id")
+
+							assertInvocationCount(brokerServer.CatalogEndpointRequests, 1)
+						})
+					})
+
+					Context("when catalog validation is lenient", func() {
+						BeforeEach(func() {
+							postBrokerRequestWithNoLabels["catalog_validation"] = "lenient"
+						})
+
+						It("returns 201", func() {
+							ctx.SMWithOAuth.POST("/v1/service_brokers").WithJSON(postBrokerRequestWithNoLabels).
+								Expect().
+								Status(http.StatusCreated).
+								JSON().Object().
+								ValueEqual("catalog_validation", "lenient")
+
+							assertInvocationCount(brokerServer.CatalogEndpointRequests, 1)
+						})
+					})
+				})
+
+				Context("when catalog validation is not supported", func() {
+					BeforeEach(func() {
+						postBrokerRequestWithNoLabels["catalog_validation"] = "unknown"
+					})
+
+					It("returns 400", func() {
+						ctx.SMWithOAuth.POST("/v1/service_brokers").WithJSON(postBrokerRequestWithNoLabels).
+							Expect().
+							Status(http.StatusBadRequest).
+							JSON().Object().
+							Keys().Contains("error", "description")
+
+						assertInvocationCount(brokerServer.CatalogEndpointRequests, 0)
+					})
+				})
+
+				Context("when parameters validation is not supported", func() {
+					BeforeEach(func() {
+						postBrokerRequestWithNoLabels["parameters_validation"] = "unknown"
+					})
+
+					It("returns 400", func() {
+						ctx.SMWithOAuth.POST("/v1/service_brokers").WithJSON(postBrokerRequestWithNoLabels).
+							Expect().
+							Status(http.StatusBadRequest).
+							JSON().Object().
+							Keys().Contains("error", "description")
+
+						assertInvocationCount(brokerServer.CatalogEndpointRequests, 0)
+					})
+				})
+
+				Context("when platform context is not supported", func() {
+					BeforeEach(func() {
+						postBrokerRequestWithNoLabels["platform_context"] = "unknown"
+					})
+
+					It("returns 400", func() {
+						ctx.SMWithOAuth.POST("/v1/service_brokers").WithJSON(postBrokerRequestWithNoLabels).
+							Expect().
+							Status(http.StatusBadRequest).
+							JSON().Object().
+							Keys().Contains("error", "description")
+
+						assertInvocationCount(brokerServer.CatalogEndpointRequests, 0)
+					})
+				})
+
+				Context("when broker state is not supported", func() {
+					BeforeEach(func() {
+						postBrokerRequestWithNoLabels["state"] = "stopped"
+					})
+
+					It("returns 400", func() {
+						ctx.SMWithOAuth.POST("/v1/service_brokers").WithJSON(postBrokerRequestWithNoLabels).
+							Expect().
+							Status(http.StatusBadRequest).
+							JSON().Object().
+							Keys().Contains("error", "description")
+
+						assertInvocationCount(brokerServer.CatalogEndpointRequests, 0)
+					})
+				})
+
 				Context("when fetching catalog fails", func() {
 					BeforeEach(func() {
 						brokerServer.CatalogHandler = func(w http.ResponseWriter, req *http.Request) {
@@ -373,6 +565,93 @@ var _ = test.DescribeTestsFor(test.TestCase{
 
 						assertPOSTReturns201()
 					})
+
+					Context("when the broker catalog contains optional OSB fields", func() {
+						var catalogServiceID, catalogPlanID string
+
+						BeforeEach(func() {
+							catalog := string(brokerServer.Catalog)
+							catalogServiceID = gjson.Get(catalog, "services.0.id").Str
+							catalogPlanID = gjson.Get(catalog, "services.0.plans.0.id").Str
+
+							var err error
+							for path, value := range map[string]interface{}{
+								"services.0.bindable":                         true,
+								"services.0.instances_retrievable":            true,
+								"services.0.bindings_retrievable":             false,
+								"services.0.plan_updateable":                  false,
+								"services.0.allow_context_updates":            true,
+								"services.0.dashboard_client":                 common.Object{"id": "client-id", "secret": "client-secret", "redirect_uri": "https://dashboard.example.com"},
+								"services.0.plans.0.plan_updateable":          true,
+								"services.0.plans.0.bindings_retrievable":     true,
+								"services.0.plans.0.maximum_polling_duration": 3600,
+								"services.0.plans.0.maintenance_info":         common.Object{"version": "1.0.0", "description": "initial version"},
+							} {
+								catalog, err = sjson.Set(catalog, path, value)
+								Expect(err).ToNot(HaveOccurred())
+							}
+							brokerServer.Catalog = common.SBCatalog(catalog)
+						})
+
+						It("stores the fields without loss", func() {
+							brokerID := ctx.SMWithOAuth.POST("/v1/service_brokers").WithJSON(postBrokerRequestWithNoLabels).
+								Expect().
+								Status(http.StatusCreated).
+								JSON().Object().Value("id").String().Raw()
+
+							serviceOfferings := ctx.SMWithOAuth.GET("/v1/service_offerings").
+								Expect().
+								Status(http.StatusOK).
+								JSON().Object().Value("service_offerings").Array().Iter()
+							var serviceOffering *httpexpect.Object
+							for _, so := range serviceOfferings {
+								if so.Object().Value("catalog_id").String().Raw() == catalogServiceID {
+									serviceOffering = so.Object()
+								}
+							}
+							Expect(serviceOffering).ToNot(BeNil())
+							serviceOffering.ValueEqual("instances_retrievable", true)
+							serviceOffering.ValueEqual("bindings_retrievable", false)
+							serviceOffering.ValueEqual("plan_updateable", false)
+							serviceOffering.ValueEqual("allow_context_updates", true)
+							serviceOffering.Value("dashboard_client").Object().
+								ValueEqual("id", "client-id").
+								ValueEqual("redirect_uri", "https://dashboard.example.com")
+
+							servicePlans := ctx.SMWithOAuth.GET("/v1/service_plans").
+								Expect().
+								Status(http.StatusOK).
+								JSON().Object().Value("service_plans").Array().Iter()
+							var servicePlan *httpexpect.Object
+							for _, sp := range servicePlans {
+								if sp.Object().Value("catalog_id").String().Raw() == catalogPlanID {
+									servicePlan = sp.Object()
+								}
+							}
+							Expect(servicePlan).ToNot(BeNil())
+							servicePlan.ValueEqual("plan_updateable", true)
+							servicePlan.ValueEqual("bindings_retrievable", true)
+							servicePlan.ValueEqual("maximum_polling_duration", 3600)
+							servicePlan.Value("maintenance_info").Object().ValueEqual("version", "1.0.0")
+							servicePlan.NotContainsKey("bindable")
+
+							catalog := ctx.SMWithBasic.GET("/v1/osb/"+brokerID+"/v2/catalog").
+								WithHeader("X-Broker-API-Version", "oidc_authn.13").
+								Expect().
+								Status(http.StatusOK).
+								Body().Raw()
+							var catalogPlan gjson.Result
+							for _, service := range gjson.Get(catalog, "services").Array() {
+								for _, plan := range service.Get("plans").Array() {
+									if plan.Get("id").String() == catalogPlanID {
+										catalogPlan = plan
+									}
+								}
+							}
+							Expect(catalogPlan.Get("plan_updateable").Exists()).To(BeTrue())
+							Expect(catalogPlan.Get("bindable").Exists()).To(BeFalse())
+						})
+					})
 				})
 
 				Context("when broker with name already exists", func() {
@@ -433,6 +712,379 @@ var _ = test.DescribeTestsFor(test.TestCase{
 				})
 			})
 
+			Describe("virtual brokers", func() {
+				var (
+					virtualBroker  common.Object
+					virtualCatalog string
+				)
+
+				BeforeEach(func() {
+					virtualCatalog = string(common.NewRandomSBCatalog())
+					virtualBroker = common.Object{
+						"name":                "virtual-broker",
+						"type":                "virtual",
+						"catalog":             common.JSONToMap(virtualCatalog),
+						"binding_credentials": common.Object{"uri": "https://example.com", "password": "secret"},
+					}
+				})
+
+				Context("when the catalog is missing", func() {
+					It("returns 400", func() {
+						delete(virtualBroker, "catalog")
+						ctx.SMWithOAuth.POST("/v1/service_brokers").WithJSON(virtualBroker).
+							Expect().
+							Status(http.StatusBadRequest).
+							JSON().Object().Value("description").String().Contains("missing catalog")
+					})
+				})
+
+				Context("when an external broker has binding credentials", func() {
+					It("returns 400", func() {
+						postBrokerRequestWithNoLabels["binding_credentials"] = common.Object{"password": "secret"}
+						ctx.SMWithOAuth.POST("/v1/service_brokers").WithJSON(postBrokerRequestWithNoLabels).
+							Expect().
+							Status(http.StatusBadRequest)
+					})
+				})
+
+				Context("when the catalog is provided", func() {
+					var brokerID string
+
+					BeforeEach(func() {
+						reply := ctx.SMWithOAuth.POST("/v1/service_brokers").WithJSON(virtualBroker).
+							Expect().
+							Status(http.StatusCreated).
+							JSON().Object()
+						reply.ValueEqual("type", "virtual")
+						reply.NotContainsKey("binding_credentials")
+						reply.NotContainsKey("catalog")
+						brokerID = reply.Value("id").String().Raw()
+					})
+
+					It("stores the catalog without calling any broker", func() {
+						ctx.SMWithOAuth.GET("/v1/service_offerings").
+							WithQuery("fieldQuery", "broker_id = "+brokerID).
+							Expect().
+							Status(http.StatusOK).
+							JSON().Path("$.service_offerings").Array().Length().Equal(1)
+						assertInvocationCount(brokerServer.CatalogEndpointRequests, 0)
+					})
+
+					Context("when OSB requests are sent to the broker", func() {
+						var (
+							instanceURL  string
+							bindingURL   string
+							provisionReq common.Object
+							otherPlanReq common.Object
+						)
+
+						osbRequest := func(method, url string) *httpexpect.Request {
+							return ctx.SMWithBasic.Request(method, url).WithHeader("X-Broker-API-Version", "2.14")
+						}
+
+						BeforeEach(func() {
+							instanceURL = "/v1/osb/" + brokerID + "/v2/service_instances/12345"
+							bindingURL = instanceURL + "/service_bindings/6789"
+							provisionReq = common.Object{
+								"service_id": gjson.Get(virtualCatalog, "services.0.id").Str,
+								"plan_id":    gjson.Get(virtualCatalog, "services.0.plans.0.id").Str,
+							}
+							otherPlanReq = common.Object{
+								"service_id": gjson.Get(virtualCatalog, "services.0.id").Str,
+								"plan_id":    gjson.Get(virtualCatalog, "services.0.plans.1.id").Str,
+							}
+						})
+
+						It("answers the OSB requests with the static credentials", func() {
+							osbRequest(http.MethodPut, instanceURL).WithJSON(provisionReq).
+								Expect().
+								Status(http.StatusCreated)
+
+							osbRequest(http.MethodPut, bindingURL).WithJSON(provisionReq).
+								Expect().
+								Status(http.StatusCreated).
+								JSON().Path("$.credentials.password").Equal("secret")
+
+							osbRequest(http.MethodGet, bindingURL).
+								Expect().
+								Status(http.StatusOK).
+								JSON().Path("$.credentials.password").Equal("secret")
+
+							osbRequest(http.MethodGet, instanceURL).
+								Expect().
+								Status(http.StatusOK).
+								JSON().Object().ValueEqual("plan_id", provisionReq["plan_id"])
+
+							osbRequest(http.MethodDelete, bindingURL).
+								WithQuery("service_id", provisionReq["service_id"]).
+								WithQuery("plan_id", provisionReq["plan_id"]).
+								Expect().
+								Status(http.StatusOK)
+
+							osbRequest(http.MethodDelete, instanceURL).
+								WithQuery("service_id", provisionReq["service_id"]).
+								WithQuery("plan_id", provisionReq["plan_id"]).
+								Expect().
+								Status(http.StatusOK)
+						})
+
+						It("rejects plans which are not in the catalog of the broker", func() {
+							osbRequest(http.MethodPut, instanceURL).
+								WithJSON(common.Object{"service_id": "service_id", "plan_id": "plan_id"}).
+								Expect().
+								Status(http.StatusBadRequest)
+						})
+
+						It("answers repeated provision requests according to the recorded instance", func() {
+							osbRequest(http.MethodPut, instanceURL).WithJSON(provisionReq).
+								Expect().
+								Status(http.StatusCreated)
+							osbRequest(http.MethodPut, instanceURL).WithJSON(provisionReq).
+								Expect().
+								Status(http.StatusOK)
+							osbRequest(http.MethodPut, instanceURL).WithJSON(otherPlanReq).
+								Expect().
+								Status(http.StatusConflict)
+						})
+
+						It("does not expose the resources to other platforms", func() {
+							osbRequest(http.MethodPut, instanceURL).WithJSON(provisionReq).
+								Expect().
+								Status(http.StatusCreated)
+							osbRequest(http.MethodPut, bindingURL).WithJSON(provisionReq).
+								Expect().
+								Status(http.StatusCreated)
+
+							platform := common.RegisterPlatformInSM(common.GenerateRandomPlatform(), ctx.SMWithOAuth)
+							defer ctx.SMWithOAuth.DELETE("/v1/platforms/" + platform.ID).Expect().Status(http.StatusOK)
+							otherPlatformRequest := func(method, url string) *httpexpect.Request {
+								return ctx.SM.Request(method, url).
+									WithBasicAuth(platform.Credentials.Basic.Username, platform.Credentials.Basic.Password).
+									WithHeader("X-Broker-API-Version", "2.14")
+							}
+
+							otherPlatformRequest(http.MethodGet, instanceURL).
+								Expect().
+								Status(http.StatusNotFound)
+							otherPlatformRequest(http.MethodGet, bindingURL).
+								Expect().
+								Status(http.StatusNotFound)
+							otherPlatformRequest(http.MethodPut, instanceURL).WithJSON(provisionReq).
+								Expect().
+								Status(http.StatusConflict)
+							otherPlatformRequest(http.MethodPut, bindingURL).WithJSON(provisionReq).
+								Expect().
+								Status(http.StatusConflict)
+
+							osbRequest(http.MethodGet, bindingURL).
+								Expect().
+								Status(http.StatusOK)
+						})
+
+						It("rejects the ids of service instances of other brokers", func() {
+							otherBrokerID, _, otherBrokerServer := ctx.RegisterBroker()
+							defer ctx.CleanupBroker(otherBrokerID)
+							otherCatalog := string(otherBrokerServer.Catalog)
+							osbRequest(http.MethodPut, "/v1/osb/"+otherBrokerID+"/v2/service_instances/12345").
+								WithJSON(common.Object{
+									"service_id": gjson.Get(otherCatalog, "services.0.id").Str,
+									"plan_id":    gjson.Get(otherCatalog, "services.0.plans.0.id").Str,
+								}).
+								Expect().
+								Status(http.StatusCreated)
+
+							osbRequest(http.MethodPut, instanceURL).WithJSON(provisionReq).
+								Expect().
+								Status(http.StatusConflict)
+						})
+
+						It("reports resources which do not exist", func() {
+							osbRequest(http.MethodGet, instanceURL).
+								Expect().
+								Status(http.StatusNotFound)
+							osbRequest(http.MethodGet, bindingURL).
+								Expect().
+								Status(http.StatusNotFound)
+							osbRequest(http.MethodPut, bindingURL).WithJSON(provisionReq).
+								Expect().
+								Status(http.StatusNotFound)
+							osbRequest(http.MethodDelete, bindingURL).
+								WithQuery("service_id", provisionReq["service_id"]).
+								WithQuery("plan_id", provisionReq["plan_id"]).
+								Expect().
+								Status(http.StatusGone)
+							osbRequest(http.MethodDelete, instanceURL).
+								WithQuery("service_id", provisionReq["service_id"]).
+								WithQuery("plan_id", provisionReq["plan_id"]).
+								Expect().
+								Status(http.StatusGone)
+						})
+					})
+
+					It("keeps the catalog when the broker is patched without a catalog", func() {
+						ctx.SMWithOAuth.PATCH("/v1/service_brokers/"+brokerID).
+							WithJSON(common.Object{"description": "updated"}).
+							Expect().
+							Status(http.StatusOK).
+							JSON().Object().ValueEqual("type", "virtual")
+
+						ctx.SMWithOAuth.GET("/v1/service_offerings").
+							WithQuery("fieldQuery", "broker_id = "+brokerID).
+							Expect().
+							Status(http.StatusOK).
+							JSON().Path("$.service_offerings").Array().Length().Equal(1)
+					})
+
+					It("resyncs the catalog provided in the patch request", func() {
+						ctx.SMWithOAuth.PATCH("/v1/service_brokers/" + brokerID).
+							WithJSON(common.Object{"catalog": common.JSONToMap(string(common.NewEmptySBCatalog()))}).
+							Expect().
+							Status(http.StatusOK)
+
+						ctx.SMWithOAuth.GET("/v1/service_offerings").
+							WithQuery("fieldQuery", "broker_id = "+brokerID).
+							Expect().
+							Status(http.StatusOK).
+							JSON().Path("$.service_offerings").Array().Empty()
+					})
+				})
+			})
+
+			Describe("PUT credentials", func() {
+				var brokerID string
+
+				BeforeEach(func() {
+					reply := ctx.SMWithOAuth.POST("/v1/service_brokers").WithJSON(postBrokerRequestWithNoLabels).
+						Expect().
+						Status(http.StatusCreated).
+						JSON().Object()
+
+					brokerID = reply.Value("id").String().Raw()
+					brokerServer.ResetCallHistory()
+				})
+
+				rotateCredentials := func(request common.Object) *httpexpect.Response {
+					return ctx.SMWithOAuth.PUT("/v1/service_brokers/" + brokerID + "/credentials").
+						WithJSON(request).
+						Expect()
+				}
+
+				deprovision := func() *httpexpect.Response {
+					return ctx.SMWithBasic.DELETE("/v1/osb/"+brokerID+"/v2/service_instances/12345").
+						WithHeader("X-Broker-API-Version", "2.13").
+						WithQuery("service_id", "service_id").
+						WithQuery("plan_id", "plan_id").
+						Expect()
+				}
+
+				newCredentials := func(password string) common.Object {
+					return common.Object{
+						"basic": common.Object{
+							"username": brokerServer.Username,
+							"password": password,
+						},
+					}
+				}
+
+				Context("when credentials are missing", func() {
+					It("returns 400", func() {
+						rotateCredentials(common.Object{}).
+							Status(http.StatusBadRequest).
+							JSON().Object().Keys().Contains("error", "description")
+					})
+				})
+
+				Context("when grace period is invalid", func() {
+					It("returns 400", func() {
+						rotateCredentials(common.Object{"credentials": newCredentials("new-password"), "grace_period": "one hour"}).
+							Status(http.StatusBadRequest).
+							JSON().Object().Keys().Contains("error", "description")
+					})
+				})
+
+				Context("when broker is missing", func() {
+					It("returns 404", func() {
+						ctx.SMWithOAuth.PUT("/v1/service_brokers/no_such_id/credentials").
+							WithJSON(common.Object{"credentials": newCredentials("new-password")}).
+							Expect().
+							Status(http.StatusNotFound)
+					})
+				})
+
+				Context("when the broker has already rotated its credentials", func() {
+					BeforeEach(func() {
+						brokerServer.Password = "new-password"
+					})
+
+					It("stores the new credentials without fetching the catalog", func() {
+						deprovision().Status(http.StatusUnauthorized)
+
+						rotateCredentials(common.Object{"credentials": newCredentials("new-password")}).
+							Status(http.StatusOK).
+							JSON().Object().Keys().NotContains("credentials")
+
+						deprovision().Status(http.StatusOK)
+						assertInvocationCount(brokerServer.CatalogEndpointRequests, 0)
+					})
+				})
+
+				Context("when verification is requested", func() {
+					It("returns 400 if the broker rejects the new credentials", func() {
+						rotateCredentials(common.Object{"credentials": newCredentials("new-password"), "verify": true}).
+							Status(http.StatusBadRequest).
+							JSON().Object().Keys().Contains("error", "description")
+
+						assertInvocationCount(brokerServer.CatalogEndpointRequests, 1)
+						deprovision().Status(http.StatusOK)
+					})
+
+					It("returns 200 if the broker accepts the new credentials", func() {
+						brokerServer.Password = "new-password"
+
+						rotateCredentials(common.Object{"credentials": newCredentials("new-password"), "verify": true}).
+							Status(http.StatusOK)
+
+						assertInvocationCount(brokerServer.CatalogEndpointRequests, 1)
+					})
+				})
+
+				Context("when grace period is requested", func() {
+					It("tries the previous credentials if the broker rejects the new ones", func() {
+						rotateCredentials(common.Object{"credentials": newCredentials("new-password"), "grace_period": "1h"}).
+							Status(http.StatusOK)
+
+						deprovision().Status(http.StatusOK)
+
+						brokerServer.Password = "new-password"
+						deprovision().Status(http.StatusOK)
+					})
+
+					It("does not try the previous credentials after another rotation without grace period", func() {
+						rotateCredentials(common.Object{"credentials": newCredentials("new-password"), "grace_period": "1h"}).
+							Status(http.StatusOK)
+						rotateCredentials(common.Object{"credentials": newCredentials("newer-password")}).
+							Status(http.StatusOK)
+
+						deprovision().Status(http.StatusUnauthorized)
+					})
+
+					It("does not try the previous credentials after the credentials are patched", func() {
+						previousPassword := brokerServer.Password
+						rotateCredentials(common.Object{"credentials": newCredentials("new-password"), "grace_period": "1h"}).
+							Status(http.StatusOK)
+
+						brokerServer.Password = "newer-password"
+						ctx.SMWithOAuth.PATCH("/v1/service_brokers/" + brokerID).
+							WithJSON(common.Object{"credentials": newCredentials("newer-password")}).
+							Expect().
+							Status(http.StatusOK)
+
+						brokerServer.Password = previousPassword
+						deprovision().Status(http.StatusUnauthorized)
+					})
+				})
+			})
+
 			Describe("PATCH", func() {
 				var brokerID string
 
@@ -809,6 +1461,116 @@ var _ = test.DescribeTestsFor(test.TestCase{
 					})
 				})
 
+				Context("when the broker catalog is not modified", func() {
+					offeringsUpdatedAt := func() []interface{} {
+						return ctx.SMWithOAuth.GET("/v1/service_offerings").
+							WithQuery("fieldQuery", "broker_id = "+brokerID).
+							Expect().
+							Status(http.StatusOK).
+							JSON().Path("$.service_offerings[*].updated_at").Array().Raw()
+					}
+
+					Context("when the broker supports conditional catalog requests", func() {
+						BeforeEach(func() {
+							brokerServer.CatalogHandler = func(w http.ResponseWriter, req *http.Request) {
+								w.Header().Set("ETag", `"v1"`)
+								if req.Header.Get("If-None-Match") == `"v1"` {
+									w.WriteHeader(http.StatusNotModified)
+									return
+								}
+								common.SetResponse(w, http.StatusOK, common.JSONToMap(string(brokerServer.Catalog)))
+							}
+							ctx.SMWithOAuth.PATCH("/v1/service_brokers/" + brokerID).
+								WithJSON(common.Object{}).
+								Expect().
+								Status(http.StatusOK)
+							brokerServer.ResetCallHistory()
+						})
+
+						It("skips the catalog resync", func() {
+							anotherService := common.JSONToMap(common.GenerateTestServiceWithPlans())
+							catalog, err := sjson.Set(string(brokerServer.Catalog), "services.-1", anotherService)
+							Expect(err).ShouldNot(HaveOccurred())
+							brokerServer.Catalog = common.SBCatalog(catalog)
+
+							ctx.SMWithOAuth.PATCH("/v1/service_brokers/"+brokerID).
+								WithJSON(common.Object{"description": "not modified"}).
+								Expect().
+								Status(http.StatusOK).
+								JSON().Object().
+								ValueEqual("description", "not modified")
+
+							assertInvocationCount(brokerServer.CatalogEndpointRequests, 1)
+							Expect(brokerServer.CatalogEndpointRequests[0].Header.Get("If-None-Match")).To(Equal(`"v1"`))
+
+							ctx.SMWithOAuth.GET("/v1/service_offerings").
+								Expect().
+								Status(http.StatusOK).
+								JSON().Path("$.service_offerings[*].catalog_id").Array().NotContains(anotherService["id"])
+						})
+
+						It("resyncs the catalog if the broker labels are modified", func() {
+							anotherService := common.JSONToMap(common.GenerateTestServiceWithPlans())
+							catalog, err := sjson.Set(string(brokerServer.Catalog), "services.-1", anotherService)
+							Expect(err).ShouldNot(HaveOccurred())
+							brokerServer.Catalog = common.SBCatalog(catalog)
+
+							ctx.SMWithOAuth.PATCH("/v1/service_brokers/" + brokerID).
+								WithJSON(common.Object{"labels": []query.LabelChange{{
+									Operation: query.AddLabelOperation,
+									Key:       "label_key",
+									Values:    []string{"label_value"},
+								}}}).
+								Expect().
+								Status(http.StatusOK)
+
+							assertInvocationCount(brokerServer.CatalogEndpointRequests, 1)
+							Expect(brokerServer.CatalogEndpointRequests[0].Header.Get("If-None-Match")).To(BeEmpty())
+
+							ctx.SMWithOAuth.GET("/v1/service_offerings").
+								Expect().
+								Status(http.StatusOK).
+								JSON().Path("$.service_offerings[*].catalog_id").Array().Contains(anotherService["id"])
+						})
+
+						It("validates the catalog again if the catalog validation of the broker is modified", func() {
+							catalog, err := sjson.Delete(string(brokerServer.Catalog), "services.0.description")
+							Expect(err).ShouldNot(HaveOccurred())
+							brokerServer.Catalog = common.SBCatalog(catalog)
+
+							ctx.SMWithOAuth.PATCH("/v1/service_brokers/" + brokerID).
+								WithJSON(common.Object{"catalog_validation": "lenient"}).
+								Expect().
+								Status(http.StatusOK)
+
+							ctx.SMWithOAuth.PATCH("/v1/service_brokers/"+brokerID).
+								WithJSON(common.Object{"catalog_validation": "strict"}).
+								Expect().
+								Status(http.StatusBadRequest).
+								JSON().Object().Keys().Contains("error", "description", "details")
+
+							assertInvocationCount(brokerServer.CatalogEndpointRequests, 2)
+							Expect(brokerServer.CatalogEndpointRequests[1].Header.Get("If-None-Match")).To(BeEmpty())
+						})
+					})
+
+					Context("when the broker does not support conditional catalog requests", func() {
+						It("skips the catalog resync if the catalog content is unchanged", func() {
+							updatedAt := offeringsUpdatedAt()
+
+							ctx.SMWithOAuth.PATCH("/v1/service_brokers/"+brokerID).
+								WithJSON(common.Object{"description": "not modified"}).
+								Expect().
+								Status(http.StatusOK).
+								JSON().Object().
+								ValueEqual("description", "not modified")
+
+							assertInvocationCount(brokerServer.CatalogEndpointRequests, 1)
+							Expect(offeringsUpdatedAt()).To(Equal(updatedAt))
+						})
+					})
+				})
+
 				Context("when the broker catalog is modified", func() {
 					Context("when a new service offering with a plan existing for another service offering is added", func() {
 						var anotherServiceID string
@@ -830,14 +1592,25 @@ var _ = test.DescribeTestsFor(test.TestCase{
 							brokerServer.Catalog = common.SBCatalog(catalog)
 						})
 
-						It("is returned from the Services API associated with the correct broker", func() {
+						It("is rejected when catalog validation is strict", func() {
+							ctx.SMWithOAuth.PATCH("/v1/service_brokers/" + brokerID).
+								WithJSON(common.Object{}).
+								Expect().
+								Status(http.StatusBadRequest).
+								JSON().Object().Value("details").Array().First().Object().
+								Value("message").String().Contains(existingPlanID)
+
+							assertInvocationCount(brokerServer.CatalogEndpointRequests, 1)
+						})
+
+						It("is returned from the Services API associated with the correct broker when catalog validation is lenient", func() {
 							ctx.SMWithOAuth.GET("/v1/service_offerings").
 								Expect().
 								Status(http.StatusOK).
 								JSON().
 								Path("$.service_offerings[*].catalog_id").Array().NotContains(anotherServiceID)
 							ctx.SMWithOAuth.PATCH("/v1/service_brokers/" + brokerID).
-								WithJSON(common.Object{}).
+								WithJSON(common.Object{"catalog_validation": "lenient"}).
 								Expect().
 								Status(http.StatusOK)
 							servicesJsonResp := ctx.SMWithOAuth.GET("/v1/service_offerings").
@@ -971,7 +1744,7 @@ var _ = test.DescribeTestsFor(test.TestCase{
 						var anotherServiceID string
 
 						BeforeEach(func() {
-							anotherService := common.JSONToMap(common.GenerateTestServiceWithPlans())
+							anotherService := common.JSONToMap(common.GenerateTestServiceWithPlans(common.GenerateTestPlan()))
 							anotherServiceID = anotherService["id"].(string)
 							Expect(anotherServiceID).ToNot(BeEmpty())
 
@@ -1101,9 +1874,21 @@ var _ = test.DescribeTestsFor(test.TestCase{
 						})
 
 						Context("when catalog service description is removed", func() {
-							verifyPATCHWhenCatalogFieldIsMissing(func(r *httpexpect.Response) {
-								r.Status(http.StatusOK)
-							}, "services.0.description")
+							Context("when catalog validation is strict", func() {
+								verifyPATCHWhenCatalogFieldIsMissing(func(r *httpexpect.Response) {
+									r.Status(http.StatusBadRequest).JSON().Object().Keys().Contains("error", "description", "details")
+								}, "services.0.description")
+							})
+
+							Context("when catalog validation is lenient", func() {
+								BeforeEach(func() {
+									postBrokerRequestWithNoLabels["catalog_validation"] = "lenient"
+								})
+
+								verifyPATCHWhenCatalogFieldIsMissing(func(r *httpexpect.Response) {
+									r.Status(http.StatusOK)
+								}, "services.0.description")
+							})
 						})
 
 						Context("when tags are invalid json", func() {
@@ -1247,9 +2032,21 @@ var _ = test.DescribeTestsFor(test.TestCase{
 						})
 
 						Context("when catalog plan description is removed", func() {
-							verifyPATCHWhenCatalogFieldIsMissing(func(r *httpexpect.Response) {
-								r.Status(http.StatusOK)
-							}, "services.0.plans.0.description")
+							Context("when catalog validation is strict", func() {
+								verifyPATCHWhenCatalogFieldIsMissing(func(r *httpexpect.Response) {
+									r.Status(http.StatusBadRequest).JSON().Object().Keys().Contains("error", "description", "details")
+								}, "services.0.plans.0.description")
+							})
+
+							Context("when catalog validation is lenient", func() {
+								BeforeEach(func() {
+									postBrokerRequestWithNoLabels["catalog_validation"] = "lenient"
+								})
+
+								verifyPATCHWhenCatalogFieldIsMissing(func(r *httpexpect.Response) {
+									r.Status(http.StatusOK)
+								}, "services.0.plans.0.description")
+							})
 						})
 
 						Context("when schemas is invalid json", func() {
@@ -1263,6 +2060,49 @@ var _ = test.DescribeTestsFor(test.TestCase{
 								r.Status(http.StatusBadRequest).JSON().Object().Keys().Contains("error", "description")
 							}, "services.0.plans.0.metadata", "{invalid")
 						})
+
+						Context("when maintenance info version is not a semantic version", func() {
+							verifyPATCHWhenCatalogFieldHasValue(func(r *httpexpect.Response) {
+								r.Status(http.StatusBadRequest).JSON().Object().Keys().Contains("error", "description", "details")
+							}, "services.0.plans.0.maintenance_info", common.Object{"version": "latest"})
+						})
+
+						Context("when maintenance info version is bumped", func() {
+							var planCatalogID string
+
+							BeforeEach(func() {
+								planCatalogID = gjson.Get(string(brokerServer.Catalog), "services.0.plans.0.id").Str
+								Expect(planCatalogID).ToNot(BeEmpty())
+							})
+
+							It("is returned by the Plans API when querying for plans with changed maintenance version", func() {
+								setMaintenanceVersion := func(version string) {
+									catalog, err := sjson.Set(string(brokerServer.Catalog), "services.0.plans.0.maintenance_info", common.Object{"version": version})
+									Expect(err).ToNot(HaveOccurred())
+									brokerServer.Catalog = common.SBCatalog(catalog)
+
+									ctx.SMWithOAuth.PATCH("/v1/service_brokers/" + brokerID).
+										WithJSON(common.Object{}).
+										Expect().
+										Status(http.StatusOK)
+								}
+
+								setMaintenanceVersion("1.0.0")
+								since := time.Now().UTC().Format(time.RFC3339Nano)
+
+								ctx.SMWithOAuth.GET("/v1/service_plans").WithQuery("fieldQuery", "maintenance_updated_at gt "+since).
+									Expect().
+									Status(http.StatusOK).
+									JSON().Path("$.service_plans[*].catalog_id").Array().NotContains(planCatalogID)
+
+								setMaintenanceVersion("1.1.0")
+
+								ctx.SMWithOAuth.GET("/v1/service_plans").WithQuery("fieldQuery", "maintenance_updated_at gt "+since).
+									Expect().
+									Status(http.StatusOK).
+									JSON().Path("$.service_plans[*].catalog_id").Array().Contains(planCatalogID)
+							})
+						})
 					})
 				})
 
@@ -0,0 +1,178 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package catalog_override_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Peripli/service-manager/test/common"
+	"github.com/tidwall/gjson"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestCatalogOverrides(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Catalog Overrides API Tests Suite")
+}
+
+var _ = Describe("Catalog Overrides API", func() {
+	var (
+		ctx *common.TestContext
+
+		brokerID            string
+		serviceOfferingID   string
+		hiddenPlanID        string
+		visiblePlanID       string
+		hiddenPlanCatalogID string
+		serviceCatalogID    string
+		brokerServer        *common.BrokerServer
+	)
+
+	BeforeSuite(func() {
+		ctx = common.DefaultTestContext()
+	})
+
+	AfterSuite(func() {
+		ctx.Cleanup()
+	})
+
+	BeforeEach(func() {
+		hiddenPlan := common.GeneratePaidTestPlan()
+		hiddenPlanCatalogID = gjson.Get(hiddenPlan, "id").Str
+		catalog := common.NewEmptySBCatalog()
+		service := common.GenerateTestServiceWithPlans(hiddenPlan, common.GenerateFreeTestPlan())
+		serviceCatalogID = gjson.Get(service, "id").Str
+		catalog.AddService(service)
+		brokerID, _, brokerServer = ctx.RegisterBrokerWithCatalog(catalog)
+
+		serviceOfferingID = ctx.SMWithOAuth.GET("/v1/service_offerings").
+			WithQuery("fieldQuery", "broker_id = "+brokerID).
+			Expect().Status(http.StatusOK).
+			JSON().Path("$.service_offerings[0].id").String().Raw()
+
+		plans := ctx.SMWithOAuth.GET("/v1/service_plans").
+			WithQuery("fieldQuery", "service_offering_id = "+serviceOfferingID).
+			Expect().Status(http.StatusOK).
+			JSON().Path("$.service_plans").Array()
+		for _, plan := range plans.Iter() {
+			if plan.Object().Value("catalog_id").String().Raw() == hiddenPlanCatalogID {
+				hiddenPlanID = plan.Object().Value("id").String().Raw()
+			} else {
+				visiblePlanID = plan.Object().Value("id").String().Raw()
+			}
+		}
+	})
+
+	AfterEach(func() {
+		ctx.CleanupBroker(brokerID)
+	})
+
+	Describe("POST", func() {
+		It("returns 400 when neither a service offering nor a service plan is targeted", func() {
+			ctx.SMWithOAuth.POST("/v1/catalog_overrides").
+				WithJSON(common.Object{"description": "desc"}).
+				Expect().Status(http.StatusBadRequest)
+		})
+
+		It("returns 400 when both a service offering and a service plan are targeted", func() {
+			ctx.SMWithOAuth.POST("/v1/catalog_overrides").
+				WithJSON(common.Object{"service_offering_id": serviceOfferingID, "service_plan_id": visiblePlanID}).
+				Expect().Status(http.StatusBadRequest)
+		})
+
+		It("returns 409 when the target is already overridden", func() {
+			ctx.SMWithOAuth.POST("/v1/catalog_overrides").
+				WithJSON(common.Object{"service_plan_id": visiblePlanID, "description": "first"}).
+				Expect().Status(http.StatusCreated)
+			ctx.SMWithOAuth.POST("/v1/catalog_overrides").
+				WithJSON(common.Object{"service_plan_id": visiblePlanID, "description": "second"}).
+				Expect().Status(http.StatusConflict)
+		})
+	})
+
+	Describe("merging", func() {
+		BeforeEach(func() {
+			ctx.SMWithOAuth.POST("/v1/catalog_overrides").
+				WithJSON(common.Object{
+					"service_offering_id": serviceOfferingID,
+					"description":         "friendly description",
+					"metadata":            common.Object{"displayName": "Friendly"},
+				}).
+				Expect().Status(http.StatusCreated)
+			ctx.SMWithOAuth.POST("/v1/catalog_overrides").
+				WithJSON(common.Object{"service_plan_id": hiddenPlanID, "hidden": true}).
+				Expect().Status(http.StatusCreated)
+		})
+
+		It("merges the overrides in the management API responses", func() {
+			so := ctx.SMWithOAuth.GET("/v1/service_offerings/" + serviceOfferingID).
+				Expect().Status(http.StatusOK).JSON().Object()
+			so.Value("description").Equal("friendly description")
+			so.Path("$.metadata.displayName").Equal("Friendly")
+
+			ctx.SMWithOAuth.GET("/v1/service_plans/" + hiddenPlanID).
+				Expect().Status(http.StatusOK)
+		})
+
+		It("merges the overrides in the OSB catalog and removes hidden plans", func() {
+			catalog := ctx.SMWithBasic.GET("/v1/osb/"+brokerID+"/v2/catalog").
+				WithHeader("X-Broker-API-Version", "2.13").
+				Expect().Status(http.StatusOK).JSON().Object()
+			service := catalog.Value("services").Array().First().Object()
+			service.Value("description").Equal("friendly description")
+			service.Value("plans").Array().Length().Equal(1)
+			service.Path("$.plans[*].id").Array().NotContains(hiddenPlanCatalogID)
+		})
+
+		It("rejects provision requests for hidden plans", func() {
+			ctx.SMWithBasic.PUT("/v1/osb/"+brokerID+"/v2/service_instances/12345").
+				WithHeader("X-Broker-API-Version", "2.13").
+				WithJSON(common.Object{"service_id": serviceCatalogID, "plan_id": hiddenPlanCatalogID}).
+				Expect().Status(http.StatusForbidden)
+			Expect(brokerServer.ServiceInstanceEndpointRequests).To(BeEmpty())
+		})
+
+		It("keeps the overrides after resync", func() {
+			ctx.SMWithOAuth.PATCH("/v1/service_brokers/" + brokerID).
+				WithJSON(common.Object{}).
+				Expect().Status(http.StatusOK)
+
+			ctx.SMWithOAuth.GET("/v1/service_offerings/" + serviceOfferingID).
+				Expect().Status(http.StatusOK).JSON().Object().
+				Value("description").Equal("friendly description")
+		})
+
+		It("lists and removes the overrides", func() {
+			overrides := ctx.SMWithOAuth.GET("/v1/catalog_overrides").
+				WithQuery("fieldQuery", "service_plan_id = "+hiddenPlanID).
+				Expect().Status(http.StatusOK).JSON().Path("$.catalog_overrides").Array()
+			overrides.Length().Equal(1)
+			overrideID := overrides.First().Object().Value("id").String().Raw()
+
+			ctx.SMWithOAuth.DELETE("/v1/catalog_overrides/" + overrideID).
+				Expect().Status(http.StatusOK)
+
+			ctx.SMWithBasic.GET("/v1/osb/"+brokerID+"/v2/catalog").
+				WithHeader("X-Broker-API-Version", "2.13").
+				Expect().Status(http.StatusOK).JSON().
+				Path("$.services[0].plans").Array().Length().Equal(2)
+		})
+	})
+})
@@ -94,7 +94,7 @@ var testService = `
     "name": "another-fake-service-%[1]s",
     "id": "%[1]s",
     "description": "test-description",
-    "requires": ["another-route_forwarding"],
+    "requires": ["route_forwarding"],
     "tags": ["another-no-sql", "another-relational"],
     "bindable": true,	
     "instances_retrievable": true,	
@@ -92,7 +92,7 @@ var _ = Describe("Service Manager Public Plans Filter", func() {
 			smb.RegisterFilters(&filters.PublicServicePlansFilter{
 				Repository: smb.Storage,
 				IsCatalogPlanPublicFunc: func(broker *types.Broker, catalogService *types.ServiceOffering, catalogPlan *types.ServicePlan) (b bool, e error) {
-					return catalogPlan.Free, nil
+					return catalogPlan.IsFree(), nil
 				},
 			})
 			return nil
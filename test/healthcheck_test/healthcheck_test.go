@@ -19,13 +19,17 @@ package healthcheck_test
 import (
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/Peripli/service-manager/api/healthcheck"
+	"github.com/Peripli/service-manager/pkg/env"
 	"github.com/Peripli/service-manager/test/common"
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
 )
 
 func TestHealth(t *testing.T) {
+	RegisterFailHandler(Fail)
 	RunSpecs(t, "Healthcheck Suite")
 }
 
@@ -34,7 +38,9 @@ var _ = Describe("Healthcheck API", func() {
 	var ctx *common.TestContext
 
 	BeforeSuite(func() {
-		ctx = common.DefaultTestContext()
+		ctx = common.NewTestContextBuilder().WithEnvPostExtensions(func(e env.Environment, servers map[string]common.FakeServer) {
+			e.Set("api.broker_health_interval", "100ms")
+		}).Build()
 	})
 
 	AfterSuite(func() {
@@ -50,4 +56,102 @@ var _ = Describe("Healthcheck API", func() {
 			})
 		})
 	})
+
+	Describe("Broker health", func() {
+		var brokerID string
+		var brokerServer *common.BrokerServer
+
+		brokerHealth := func() map[string]interface{} {
+			resp := ctx.SMWithOAuth.GET("/v1/service_brokers/" + brokerID + "/health").Expect()
+			if resp.Raw().StatusCode == http.StatusNotFound {
+				return nil
+			}
+			return resp.Status(http.StatusOK).JSON().Object().Raw()
+		}
+
+		BeforeEach(func() {
+			brokerID, _, brokerServer = ctx.RegisterBroker()
+		})
+
+		AfterEach(func() {
+			ctx.CleanupBroker(brokerID)
+		})
+
+		It("reports the latency of reachable brokers", func() {
+			Eventually(brokerHealth, 5*time.Second, 100*time.Millisecond).Should(HaveKeyWithValue("status", "UP"))
+
+			health := brokerHealth()
+			details := health["details"].(map[string]interface{})
+			Expect(details).To(HaveKey("latency"))
+			Expect(details).To(HaveKeyWithValue("consecutive_failures", BeNumerically("==", 0)))
+		})
+
+		It("reports the consecutive failures of unreachable brokers without affecting the overall status", func() {
+			Eventually(brokerHealth, 5*time.Second, 100*time.Millisecond).Should(HaveKeyWithValue("status", "UP"))
+
+			brokerServer.CatalogHandler = func(rw http.ResponseWriter, req *http.Request) {
+				common.SetResponse(rw, http.StatusInternalServerError, common.Object{})
+			}
+
+			Eventually(func() interface{} {
+				health := brokerHealth()
+				if health["status"] != "DOWN" {
+					return 0
+				}
+				return health["details"].(map[string]interface{})["consecutive_failures"]
+			}, 5*time.Second, 100*time.Millisecond).Should(BeNumerically(">=", 2))
+
+			ctx.SM.GET(healthcheck.URL).
+				Expect().
+				Status(http.StatusOK).JSON().Object().ContainsMap(map[string]interface{}{
+				"status": "UP",
+			})
+		})
+
+		It("publishes only the number of unreachable brokers on the health endpoint", func() {
+			Eventually(brokerHealth, 5*time.Second, 100*time.Millisecond).ShouldNot(BeNil())
+
+			details := ctx.SM.GET(healthcheck.URL).
+				Expect().
+				Status(http.StatusOK).JSON().Object().
+				Value("details").Object().
+				Value("brokers").Object().
+				Value("details").Object()
+			details.Keys().ContainsOnly("probed", "unreachable")
+		})
+
+		It("requires authentication for the health of individual brokers", func() {
+			ctx.SM.GET("/v1/service_brokers/" + brokerID + "/health").
+				Expect().
+				Status(http.StatusUnauthorized)
+		})
+
+		It("does not open the circuit breaker of brokers whose probes fail", func() {
+			Eventually(brokerHealth, 5*time.Second, 100*time.Millisecond).Should(HaveKeyWithValue("status", "UP"))
+			Expect(brokerHealth()["details"]).ToNot(HaveKey("circuit_breaker"))
+
+			ctx.SMWithOAuth.PATCH("/v1/service_brokers/" + brokerID).
+				WithJSON(common.Object{"resilience": common.Object{"circuit_breaker_threshold": 1, "circuit_breaker_timeout": 60}}).
+				Expect().Status(http.StatusOK)
+			Eventually(func() interface{} {
+				return brokerHealth()["details"]
+			}, 5*time.Second, 100*time.Millisecond).Should(HaveKeyWithValue("circuit_breaker", "closed"))
+
+			brokerServer.CatalogHandler = func(rw http.ResponseWriter, req *http.Request) {
+				common.SetResponse(rw, http.StatusInternalServerError, common.Object{})
+			}
+			Eventually(func() interface{} {
+				return brokerHealth()["details"].(map[string]interface{})["consecutive_failures"]
+			}, 5*time.Second, 100*time.Millisecond).Should(BeNumerically(">=", 2))
+			Expect(brokerHealth()["details"]).To(HaveKeyWithValue("circuit_breaker", "closed"))
+		})
+
+		It("stops reporting deleted brokers", func() {
+			Eventually(brokerHealth, 5*time.Second, 100*time.Millisecond).ShouldNot(BeNil())
+
+			ctx.SMWithOAuth.DELETE("/v1/service_brokers/" + brokerID).Expect().Status(http.StatusOK)
+
+			Eventually(brokerHealth, 5*time.Second, 100*time.Millisecond).Should(BeNil())
+		})
+	})
 })
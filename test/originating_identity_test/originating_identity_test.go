@@ -0,0 +1,182 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package originating_identity_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"testing"
+
+	"github.com/Peripli/service-manager/pkg/env"
+	"github.com/Peripli/service-manager/test/common"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestOriginatingIdentity(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Originating Identity Tests Suite")
+}
+
+var _ = Describe("Originating identity", func() {
+	const (
+		signingKey      = "signing-key"
+		verificationKey = "verification-key"
+
+		identityHeader  = "X-Broker-API-Originating-Identity"
+		signatureHeader = "X-Broker-API-Originating-Identity-Signature"
+
+		// cloudfoundry {"user_id":"123"}
+		platformIdentity = "cloudfoundry eyJ1c2VyX2lkIjoiMTIzIn0="
+	)
+
+	var (
+		ctx *common.TestContext
+
+		brokerID     string
+		brokerServer *common.BrokerServer
+		instanceURL  string
+	)
+
+	sign := func(key, identity string) string {
+		mac := hmac.New(sha256.New, []byte(key))
+		mac.Write([]byte(identity))
+		return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	}
+
+	provisionRequest := common.Object{
+		"service_id": "dummyId",
+		"plan_id":    "dummyplanId",
+	}
+
+	BeforeSuite(func() {
+		ctx = common.NewTestContextBuilder().
+			WithEnvPostExtensions(func(e env.Environment, servers map[string]common.FakeServer) {
+				e.Set("api.originating_identity_signing_key", signingKey)
+				e.Set("api.originating_identity_verification_key", verificationKey)
+			}).Build()
+	})
+
+	AfterSuite(func() {
+		ctx.Cleanup()
+	})
+
+	BeforeEach(func() {
+		brokerID, _, brokerServer = ctx.RegisterBroker()
+		instanceURL = "/v1/osb/" + brokerID + "/v2/service_instances/12345"
+	})
+
+	AfterEach(func() {
+		ctx.CleanupBroker(brokerID)
+	})
+
+	Context("when the request is sent by a platform", func() {
+		It("forwards the verified identity signed by Service Manager", func() {
+			ctx.SMWithBasic.PUT(instanceURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				WithHeader(identityHeader, platformIdentity).
+				WithHeader(signatureHeader, sign(verificationKey, platformIdentity)).
+				WithJSON(provisionRequest).
+				Expect().Status(http.StatusCreated)
+
+			Expect(brokerServer.LastRequest.Header.Get(identityHeader)).To(Equal(platformIdentity))
+			Expect(brokerServer.LastRequest.Header.Get(signatureHeader)).To(Equal(sign(signingKey, platformIdentity)))
+		})
+
+		It("rejects identities without signature", func() {
+			ctx.SMWithBasic.PUT(instanceURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				WithHeader(identityHeader, platformIdentity).
+				WithJSON(provisionRequest).
+				Expect().Status(http.StatusBadRequest).
+				JSON().Object().Keys().Contains("error", "description")
+			Expect(brokerServer.ServiceInstanceEndpointRequests).To(BeEmpty())
+		})
+
+		It("rejects identities with invalid signature", func() {
+			ctx.SMWithBasic.PUT(instanceURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				WithHeader(identityHeader, platformIdentity).
+				WithHeader(signatureHeader, sign("other-key", platformIdentity)).
+				WithJSON(provisionRequest).
+				Expect().Status(http.StatusBadRequest)
+		})
+
+		It("rejects malformed identities", func() {
+			for _, identity := range []string{"cloudfoundry", "cloudfoundry not-base64", "cloudfoundry " + base64.StdEncoding.EncodeToString([]byte("[]"))} {
+				ctx.SMWithBasic.PUT(instanceURL).
+					WithHeader("X-Broker-API-Version", "oidc_authn.13").
+					WithHeader(identityHeader, identity).
+					WithHeader(signatureHeader, sign(verificationKey, identity)).
+					WithJSON(provisionRequest).
+					Expect().Status(http.StatusBadRequest)
+			}
+		})
+
+		It("forwards requests without identity", func() {
+			ctx.SMWithBasic.PUT(instanceURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				WithJSON(provisionRequest).
+				Expect().Status(http.StatusCreated)
+
+			Expect(brokerServer.LastRequest.Header.Get(identityHeader)).To(BeEmpty())
+			Expect(brokerServer.LastRequest.Header.Get(signatureHeader)).To(BeEmpty())
+		})
+	})
+
+	It("rejects identities of the service-manager platform", func() {
+		identity := "service-manager " + base64.StdEncoding.EncodeToString([]byte(`{"user_id":"admin"}`))
+		ctx.SMWithBasic.PUT(instanceURL).
+			WithHeader("X-Broker-API-Version", "oidc_authn.13").
+			WithHeader(identityHeader, identity).
+			WithHeader(signatureHeader, sign(verificationKey, identity)).
+			WithJSON(provisionRequest).
+			Expect().Status(http.StatusBadRequest)
+		Expect(brokerServer.ServiceInstanceEndpointRequests).To(BeEmpty())
+	})
+
+	Context("when no verification key is configured", func() {
+		var unverifiedCtx *common.TestContext
+
+		BeforeEach(func() {
+			unverifiedCtx = common.NewTestContextBuilder().
+				WithEnvPostExtensions(func(e env.Environment, servers map[string]common.FakeServer) {
+					e.Set("api.originating_identity_signing_key", signingKey)
+				}).Build()
+		})
+
+		AfterEach(func() {
+			unverifiedCtx.Cleanup()
+		})
+
+		It("forwards the identities of platforms without signature", func() {
+			unverifiedBrokerID, _, unverifiedBrokerServer := unverifiedCtx.RegisterBroker()
+			unverifiedCtx.SMWithBasic.PUT("/v1/osb/"+unverifiedBrokerID+"/v2/service_instances/12345").
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				WithHeader(identityHeader, platformIdentity).
+				WithHeader(signatureHeader, sign(signingKey, platformIdentity)).
+				WithJSON(provisionRequest).
+				Expect().Status(http.StatusCreated)
+
+			Expect(unverifiedBrokerServer.LastRequest.Header.Get(identityHeader)).To(Equal(platformIdentity))
+			Expect(unverifiedBrokerServer.LastRequest.Header.Get(signatureHeader)).To(BeEmpty())
+		})
+	})
+})
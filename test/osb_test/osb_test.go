@@ -18,10 +18,14 @@ package osb_test
 import (
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 
 	"github.com/gofrs/uuid"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
 
+	"github.com/Peripli/service-manager/pkg/env"
 	"github.com/Peripli/service-manager/test/common"
 	"github.com/gavv/httpexpect"
 
@@ -148,7 +152,9 @@ var _ = Describe("Service Manager OSB API", func() {
 	)
 
 	BeforeSuite(func() {
-		ctx = common.DefaultTestContext()
+		ctx = common.NewTestContextBuilder().WithEnvPostExtensions(func(e env.Environment, servers map[string]common.FakeServer) {
+			e.Set("api.orphan_mitigation_backoff", "100ms")
+		}).Build()
 		validBrokerID, _, validBrokerServer = ctx.RegisterBroker()
 		smUrlToWorkingBroker = validBrokerServer.URL() + "/v1/osb/" + validBrokerID
 
@@ -554,6 +560,311 @@ var _ = Describe("Service Manager OSB API", func() {
 		})
 	})
 
+	Describe("Broker state", func() {
+		var (
+			brokerID       string
+			brokerServer   *common.BrokerServer
+			smURLToBroker  string
+			setBrokerState func(state string)
+		)
+
+		BeforeEach(func() {
+			brokerID, _, brokerServer = ctx.RegisterBroker()
+			smURLToBroker = brokerServer.URL() + "/v1/osb/" + brokerID
+			setBrokerState = func(state string) {
+				ctx.SMWithOAuth.PATCH("/v1/service_brokers/"+brokerID).
+					WithJSON(common.Object{"state": state}).
+					Expect().
+					Status(http.StatusOK).
+					JSON().Object().ValueEqual("state", state)
+			}
+		})
+
+		AfterEach(func() {
+			ctx.CleanupBroker(brokerID)
+		})
+
+		Context("when broker is disabled", func() {
+			BeforeEach(func() {
+				brokerServer.ResetCallHistory()
+				brokerServer.CatalogHandler = failingHandler
+				setBrokerState("disabled")
+			})
+
+			It("does not fetch the broker catalog", func() {
+				Expect(len(brokerServer.CatalogEndpointRequests)).To(Equal(0))
+			})
+
+			It("hides the broker catalog", func() {
+				ctx.SMWithBasic.GET(smURLToBroker+"/v2/catalog").WithHeader("X-Broker-API-Version", "oidc_authn.13").
+					Expect().
+					Status(http.StatusOK).
+					JSON().Object().Value("services").Array().Empty()
+			})
+
+			It("rejects provision requests", func() {
+				ctx.SMWithBasic.PUT(smURLToBroker+"/v2/service_instances/12345").WithHeader("X-Broker-API-Version", "oidc_authn.13").
+					WithJSON(getDummyService()).
+					Expect().
+					Status(http.StatusServiceUnavailable).
+					JSON().Object().Keys().Contains("error", "description")
+
+				Expect(len(brokerServer.ServiceInstanceEndpointRequests)).To(Equal(0))
+			})
+
+			It("rejects deprovision requests", func() {
+				ctx.SMWithBasic.DELETE(smURLToBroker+"/v2/service_instances/12345").WithHeader("X-Broker-API-Version", "oidc_authn.13").
+					WithQueryObject(getDummyService()).
+					Expect().
+					Status(http.StatusServiceUnavailable)
+			})
+		})
+
+		Context("when broker is in maintenance", func() {
+			BeforeEach(func() {
+				setBrokerState("maintenance")
+			})
+
+			It("returns the broker catalog", func() {
+				ctx.SMWithBasic.GET(smURLToBroker+"/v2/catalog").WithHeader("X-Broker-API-Version", "oidc_authn.13").
+					Expect().
+					Status(http.StatusOK).
+					JSON().Object().Value("services").Array().NotEmpty()
+			})
+
+			It("rejects provision requests", func() {
+				ctx.SMWithBasic.PUT(smURLToBroker+"/v2/service_instances/12345").WithHeader("X-Broker-API-Version", "oidc_authn.13").
+					WithJSON(getDummyService()).
+					Expect().
+					Status(http.StatusServiceUnavailable).
+					JSON().Object().Keys().Contains("error", "description")
+			})
+
+			It("rejects bind requests", func() {
+				ctx.SMWithBasic.PUT(smURLToBroker+"/v2/service_instances/12345/service_bindings/kjlk").WithHeader("X-Broker-API-Version", "oidc_authn.13").
+					WithJSON(getDummyService()).
+					Expect().
+					Status(http.StatusServiceUnavailable)
+			})
+
+			It("allows deprovision requests", func() {
+				assertWorkingBrokerResponse(
+					ctx.SMWithBasic.DELETE(smURLToBroker+"/v2/service_instances/12345").WithHeader("X-Broker-API-Version", "oidc_authn.13").
+						WithQueryObject(getDummyService()).Expect(), http.StatusOK)
+			})
+
+			It("allows unbind requests", func() {
+				assertWorkingBrokerResponse(
+					ctx.SMWithBasic.DELETE(smURLToBroker+"/v2/service_instances/12345/service_bindings/kjlk").WithHeader("X-Broker-API-Version", "oidc_authn.13").
+						WithQueryObject(getDummyService()).Expect(), http.StatusOK)
+			})
+		})
+	})
+
+	Describe("Broker resilience", func() {
+		var (
+			brokerID      string
+			brokerServer  *common.BrokerServer
+			smURLToBroker string
+		)
+
+		setResilience := func(resilience common.Object) {
+			ctx.SMWithOAuth.PATCH("/v1/service_brokers/" + brokerID).
+				WithJSON(common.Object{"resilience": resilience}).
+				Expect().
+				Status(http.StatusOK).
+				JSON().Object().Value("resilience").Object().ContainsMap(resilience)
+		}
+
+		failingTimes := func(failures int, status int) http.HandlerFunc {
+			return func(rw http.ResponseWriter, req *http.Request) {
+				if failures > 0 {
+					failures--
+					common.SetResponse(rw, http.StatusServiceUnavailable, common.Object{})
+					return
+				}
+				common.SetResponse(rw, status, common.Object{})
+			}
+		}
+
+		BeforeEach(func() {
+			brokerID, _, brokerServer = ctx.RegisterBroker()
+			smURLToBroker = brokerServer.URL() + "/v1/osb/" + brokerID
+		})
+
+		AfterEach(func() {
+			ctx.CleanupBroker(brokerID)
+		})
+
+		It("rejects negative settings", func() {
+			ctx.SMWithOAuth.PATCH("/v1/service_brokers/" + brokerID).
+				WithJSON(common.Object{"resilience": common.Object{"retries": -1}}).
+				Expect().
+				Status(http.StatusBadRequest)
+		})
+
+		It("rejects circuit breakers without timeout", func() {
+			ctx.SMWithOAuth.PATCH("/v1/service_brokers/" + brokerID).
+				WithJSON(common.Object{"resilience": common.Object{"circuit_breaker_threshold": 1}}).
+				Expect().
+				Status(http.StatusBadRequest)
+		})
+
+		Context("when retries are configured", func() {
+			BeforeEach(func() {
+				setResilience(common.Object{"retries": 2})
+				brokerServer.ResetCallHistory()
+			})
+
+			It("retries failed get requests", func() {
+				brokerServer.ServiceInstanceLastOpHandler = failingTimes(2, http.StatusOK)
+
+				ctx.SMWithBasic.GET(smURLToBroker+"/v2/service_instances/12345/last_operation").WithHeader("X-Broker-API-Version", "oidc_authn.13").
+					Expect().
+					Status(http.StatusOK)
+				Expect(brokerServer.ServiceInstanceLastOpEndpointRequests).To(HaveLen(3))
+			})
+
+			It("does not retry provision requests", func() {
+				brokerServer.ServiceInstanceHandler = failingTimes(1, http.StatusCreated)
+
+				ctx.SMWithBasic.PUT(smURLToBroker+"/v2/service_instances/12345").WithHeader("X-Broker-API-Version", "oidc_authn.13").
+					WithJSON(getDummyService()).
+					Expect().
+					Status(http.StatusServiceUnavailable)
+				Expect(brokerServer.ServiceInstanceEndpointRequests).To(HaveLen(1))
+			})
+		})
+
+		Context("when a circuit breaker is configured", func() {
+			BeforeEach(func() {
+				setResilience(common.Object{"circuit_breaker_threshold": 2, "circuit_breaker_timeout": 60})
+				brokerServer.ResetCallHistory()
+				brokerServer.ServiceInstanceLastOpHandler = func(rw http.ResponseWriter, req *http.Request) {
+					common.SetResponse(rw, http.StatusInternalServerError, common.Object{})
+				}
+			})
+
+			It("fails fast after the threshold of consecutive failures is reached", func() {
+				lastOperationURL := smURLToBroker + "/v2/service_instances/12345/last_operation"
+				for i := 0; i < 2; i++ {
+					ctx.SMWithBasic.GET(lastOperationURL).WithHeader("X-Broker-API-Version", "oidc_authn.13").
+						Expect().
+						Status(http.StatusInternalServerError)
+				}
+
+				resp := ctx.SMWithBasic.GET(lastOperationURL).WithHeader("X-Broker-API-Version", "oidc_authn.13").
+					Expect().
+					Status(http.StatusServiceUnavailable)
+				resp.JSON().Object().Keys().Contains("error", "description")
+				retryAfter, err := strconv.Atoi(resp.Header("Retry-After").Raw())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(retryAfter).To(BeNumerically(">", 0))
+				Expect(retryAfter).To(BeNumerically("<=", 60))
+				Expect(brokerServer.ServiceInstanceLastOpEndpointRequests).To(HaveLen(2))
+			})
+
+			It("is reset by successful requests", func() {
+				lastOperationURL := smURLToBroker + "/v2/service_instances/12345/last_operation"
+				ctx.SMWithBasic.GET(lastOperationURL).WithHeader("X-Broker-API-Version", "oidc_authn.13").
+					Expect().
+					Status(http.StatusInternalServerError)
+
+				brokerServer.ServiceInstanceLastOpHandler = failingTimes(0, http.StatusOK)
+				ctx.SMWithBasic.GET(lastOperationURL).WithHeader("X-Broker-API-Version", "oidc_authn.13").
+					Expect().
+					Status(http.StatusOK)
+
+				brokerServer.ServiceInstanceLastOpHandler = failingTimes(0, http.StatusInternalServerError)
+				ctx.SMWithBasic.GET(lastOperationURL).WithHeader("X-Broker-API-Version", "oidc_authn.13").
+					Expect().
+					Status(http.StatusInternalServerError)
+				ctx.SMWithBasic.GET(lastOperationURL).WithHeader("X-Broker-API-Version", "oidc_authn.13").
+					Expect().
+					Status(http.StatusInternalServerError)
+				Expect(brokerServer.ServiceInstanceLastOpEndpointRequests).To(HaveLen(4))
+			})
+		})
+	})
+
+	Describe("Platform context", func() {
+		var (
+			brokerID      string
+			brokerServer  *common.BrokerServer
+			smURLToBroker string
+		)
+
+		provisionRequest := common.Object{
+			"service_id": "dummyId",
+			"plan_id":    "dummyplanId",
+			"context":    common.Object{"platform": "cloudfoundry"},
+		}
+
+		BeforeEach(func() {
+			brokerID, _, brokerServer = ctx.RegisterBroker()
+			smURLToBroker = "/v1/osb/" + brokerID
+		})
+
+		AfterEach(func() {
+			ctx.CleanupBroker(brokerID)
+		})
+
+		It("is not added to the requests of brokers which did not enable it", func() {
+			ctx.SMWithBasic.PUT(smURLToBroker+"/v2/service_instances/12345").WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				WithJSON(provisionRequest).
+				Expect().
+				Status(http.StatusCreated)
+
+			Expect(gjson.GetBytes(brokerServer.LastRequestBody, "context").Raw).To(MatchJSON(`{"platform":"cloudfoundry"}`))
+		})
+
+		Context("when the broker enabled it", func() {
+			var expectedContext string
+
+			BeforeEach(func() {
+				ctx.SMWithOAuth.PATCH("/v1/service_brokers/"+brokerID).
+					WithJSON(common.Object{"platform_context": "enabled"}).
+					Expect().
+					Status(http.StatusOK).
+					JSON().Object().ValueEqual("platform_context", "enabled")
+				expectedContext = `{
+					"platform": "cloudfoundry",
+					"service_manager": {
+						"platform_id": "` + ctx.TestPlatform.ID + `",
+						"platform_type": "` + ctx.TestPlatform.Type + `"
+					}
+				}`
+			})
+
+			It("is added to provision requests", func() {
+				ctx.SMWithBasic.PUT(smURLToBroker+"/v2/service_instances/12345").WithHeader("X-Broker-API-Version", "oidc_authn.13").
+					WithJSON(provisionRequest).
+					Expect().
+					Status(http.StatusCreated)
+
+				Expect(gjson.GetBytes(brokerServer.LastRequestBody, "context").Raw).To(MatchJSON(expectedContext))
+			})
+
+			It("is added to update requests", func() {
+				ctx.SMWithBasic.PATCH(smURLToBroker+"/v2/service_instances/12345").WithHeader("X-Broker-API-Version", "oidc_authn.13").
+					WithJSON(provisionRequest).
+					Expect().
+					Status(http.StatusOK)
+
+				Expect(gjson.GetBytes(brokerServer.LastRequestBody, "context").Raw).To(MatchJSON(expectedContext))
+			})
+
+			It("is added to bind requests", func() {
+				ctx.SMWithBasic.PUT(smURLToBroker+"/v2/service_instances/12345/service_bindings/kjlk").WithHeader("X-Broker-API-Version", "oidc_authn.13").
+					WithJSON(provisionRequest).
+					Expect().
+					Status(http.StatusCreated)
+
+				Expect(gjson.GetBytes(brokerServer.LastRequestBody, "context").Raw).To(MatchJSON(expectedContext))
+			})
+		})
+	})
+
 	Describe("Prefixed broker path", func() {
 		Context("when call to working broker", func() {
 
@@ -596,6 +907,561 @@ var _ = Describe("Service Manager OSB API", func() {
 		})
 	})
 
+	Describe("API version negotiation", func() {
+		var (
+			brokerID     string
+			brokerServer *common.BrokerServer
+			osbURL       string
+		)
+
+		BeforeEach(func() {
+			brokerID, _, brokerServer = ctx.RegisterBroker()
+			brokerServer.CatalogHandler = func(rw http.ResponseWriter, req *http.Request) {
+				if req.Header.Get("X-Broker-API-Version") != "2.11" {
+					common.SetResponse(rw, http.StatusPreconditionFailed, common.Object{})
+					return
+				}
+				rw.Header().Set("Content-Type", "application/json")
+				rw.WriteHeader(http.StatusOK)
+				rw.Write([]byte(brokerServer.Catalog))
+			}
+			ctx.SMWithOAuth.PATCH("/v1/service_brokers/"+brokerID).
+				WithJSON(common.Object{}).
+				Expect().
+				Status(http.StatusOK).
+				JSON().Object().
+				ValueEqual("api_version", "2.11")
+			osbURL = "/v1/osb/" + brokerID
+		})
+
+		AfterEach(func() {
+			ctx.CleanupBroker(brokerID)
+		})
+
+		It("downgrades requests to the API version of the broker", func() {
+			ctx.SMWithBasic.PUT(osbURL+"/v2/service_instances/12345").
+				WithHeader("X-Broker-API-Version", "2.14").
+				WithHeader("X-Broker-API-Originating-Identity", "cloudfoundry eyJ1c2VyX2lkIjoiMTIzIn0=").
+				WithJSON(common.Object{
+					"service_id": "dummyId",
+					"plan_id":    "dummyplanId",
+					"context":    common.Object{"platform": "cloudfoundry"},
+				}).
+				Expect().Status(http.StatusCreated)
+
+			Expect(brokerServer.LastRequest.Header.Get("X-Broker-API-Version")).To(Equal("2.11"))
+			Expect(brokerServer.LastRequest.Header.Get("X-Broker-API-Originating-Identity")).To(BeEmpty())
+			Expect(common.JSONToMap(string(brokerServer.LastRequestBody))).ToNot(HaveKey("context"))
+		})
+
+		It("does not change requests with the API version of the broker", func() {
+			ctx.SMWithBasic.PUT(osbURL+"/v2/service_instances/12345").
+				WithHeader("X-Broker-API-Version", "2.11").
+				WithJSON(getDummyService()).
+				Expect().Status(http.StatusCreated)
+
+			Expect(brokerServer.LastRequest.Header.Get("X-Broker-API-Version")).To(Equal("2.11"))
+		})
+
+		It("rejects fetching service instances", func() {
+			ctx.SMWithBasic.GET(osbURL+"/v2/service_instances/12345").
+				WithHeader("X-Broker-API-Version", "2.14").
+				Expect().Status(http.StatusPreconditionFailed).
+				JSON().Object().Value("description").String().Contains("2.11")
+		})
+
+		It("rejects requests with maintenance info", func() {
+			ctx.SMWithBasic.PATCH(osbURL+"/v2/service_instances/12345").
+				WithHeader("X-Broker-API-Version", "2.15").
+				WithJSON(common.Object{
+					"service_id":       "dummyId",
+					"maintenance_info": common.Object{"version": "2.0.0"},
+				}).
+				Expect().Status(http.StatusPreconditionFailed)
+		})
+	})
+
+	Describe("Response streaming", func() {
+		var (
+			brokerID     string
+			brokerServer *common.BrokerServer
+		)
+
+		BeforeEach(func() {
+			brokerID, _, brokerServer = ctx.RegisterBroker()
+		})
+
+		AfterEach(func() {
+			ctx.CleanupBroker(brokerID)
+		})
+
+		It("forwards the response of fetch requests together with its length", func() {
+			body := `{"service_id":"` + strings.Repeat("x", 100000) + `"}`
+			brokerServer.ServiceInstanceHandler = func(rw http.ResponseWriter, req *http.Request) {
+				rw.Header().Set("Content-Type", "application/json")
+				rw.Header().Set("Content-Length", strconv.Itoa(len(body)))
+				rw.WriteHeader(http.StatusOK)
+				rw.Write([]byte(body))
+			}
+
+			resp := ctx.SMWithBasic.GET("/v1/osb/"+brokerID+"/v2/service_instances/12345").
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				Expect().Status(http.StatusOK)
+			resp.Header("Content-Length").Equal(strconv.Itoa(len(body)))
+			resp.Body().Equal(body)
+		})
+	})
+
+	Describe("Parameters validation", func() {
+		const schemas = `{
+			"service_instance": {
+				"create": {"parameters": {"type": "object", "required": ["size"], "properties": {"size": {"type": "integer", "maximum": 10}}}},
+				"update": {"parameters": {"type": "object", "properties": {"size": {"type": "integer", "minimum": 5}}}}
+			},
+			"service_binding": {
+				"create": {"parameters": {"type": "object", "additionalProperties": false}}
+			}
+		}`
+
+		var (
+			brokerID     string
+			brokerServer *common.BrokerServer
+			instanceURL  string
+			serviceID    string
+			planID       string
+		)
+
+		request := func(parameters common.Object) common.Object {
+			return common.Object{
+				"service_id": serviceID,
+				"plan_id":    planID,
+				"parameters": parameters,
+			}
+		}
+
+		BeforeEach(func() {
+			plan, err := sjson.SetRaw(common.GeneratePaidTestPlan(), "schemas", schemas)
+			Expect(err).ToNot(HaveOccurred())
+			plan, err = sjson.Set(plan, "bindable", true)
+			Expect(err).ToNot(HaveOccurred())
+			catalog := common.NewEmptySBCatalog()
+			catalog.AddService(common.GenerateTestServiceWithPlans(plan))
+
+			brokerID, _, brokerServer = ctx.RegisterBrokerWithCatalog(catalog)
+			instanceURL = "/v1/osb/" + brokerID + "/v2/service_instances/12345"
+			serviceID = gjson.Get(string(catalog), "services.0.id").Str
+			planID = gjson.Get(string(catalog), "services.0.plans.0.id").Str
+		})
+
+		AfterEach(func() {
+			ctx.CleanupBroker(brokerID)
+		})
+
+		It("rejects provision requests with invalid parameters", func() {
+			ctx.SMWithBasic.PUT(instanceURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				WithJSON(request(common.Object{"size": 20})).
+				Expect().Status(http.StatusBadRequest).
+				JSON().Object().Value("description").String().Contains("size")
+			Expect(brokerServer.ServiceInstanceEndpointRequests).To(BeEmpty())
+		})
+
+		It("forwards provision requests with valid parameters", func() {
+			ctx.SMWithBasic.PUT(instanceURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				WithJSON(request(common.Object{"size": 5})).
+				Expect().Status(http.StatusCreated)
+		})
+
+		It("validates updates against the update schema", func() {
+			ctx.SMWithBasic.PATCH(instanceURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				WithJSON(request(common.Object{"size": 1})).
+				Expect().Status(http.StatusBadRequest)
+			ctx.SMWithBasic.PATCH(instanceURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				WithJSON(request(common.Object{"size": 20})).
+				Expect().Status(http.StatusOK)
+		})
+
+		It("validates bind requests against the binding schema", func() {
+			ctx.SMWithBasic.PUT(instanceURL+"/service_bindings/binding-id").
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				WithJSON(request(common.Object{"unknown": "value"})).
+				Expect().Status(http.StatusBadRequest)
+			Expect(brokerServer.BindingEndpointRequests).To(BeEmpty())
+		})
+
+		It("does not validate the requests for brokers which disabled the validation", func() {
+			ctx.SMWithOAuth.PATCH("/v1/service_brokers/"+brokerID).
+				WithJSON(common.Object{"parameters_validation": "disabled"}).
+				Expect().Status(http.StatusOK).
+				JSON().Object().ValueEqual("parameters_validation", "disabled")
+
+			ctx.SMWithBasic.PUT(instanceURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				WithJSON(request(common.Object{"size": 20})).
+				Expect().Status(http.StatusCreated)
+		})
+	})
+
+	Describe("Fetching resources which are not retrievable", func() {
+		var (
+			brokerID     string
+			brokerServer *common.BrokerServer
+			instanceURL  string
+			bindingURL   string
+			serviceID    string
+			planID       string
+			service      string
+		)
+
+		BeforeEach(func() {
+			var err error
+			service, err = sjson.Set(common.GenerateTestServiceWithPlans(common.GeneratePaidTestPlan()), "instances_retrievable", false)
+			Expect(err).ToNot(HaveOccurred())
+			service, err = sjson.Set(service, "bindings_retrievable", false)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		JustBeforeEach(func() {
+			catalog := common.NewEmptySBCatalog()
+			catalog.AddService(service)
+
+			brokerID, _, brokerServer = ctx.RegisterBrokerWithCatalog(catalog)
+			instanceURL = "/v1/osb/" + brokerID + "/v2/service_instances/12345"
+			bindingURL = instanceURL + "/service_bindings/binding-id"
+			serviceID = gjson.Get(string(catalog), "services.0.id").Str
+			planID = gjson.Get(string(catalog), "services.0.plans.0.id").Str
+
+			brokerServer.ServiceInstanceHandler = func(rw http.ResponseWriter, req *http.Request) {
+				common.SetResponse(rw, http.StatusCreated, common.Object{"dashboard_url": "https://dashboard.example.com"})
+			}
+			ctx.SMWithBasic.PUT(instanceURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				WithJSON(common.Object{
+					"service_id": serviceID,
+					"plan_id":    planID,
+					"parameters": common.Object{"size": 5},
+				}).
+				Expect().Status(http.StatusCreated)
+			ctx.SMWithBasic.PUT(bindingURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				WithJSON(common.Object{
+					"service_id": serviceID,
+					"plan_id":    planID,
+					"parameters": common.Object{"role": "reader"},
+				}).
+				Expect().Status(http.StatusCreated)
+			brokerServer.ResetCallHistory()
+		})
+
+		AfterEach(func() {
+			ctx.CleanupBroker(brokerID)
+		})
+
+		It("returns the service instance recorded by Service Manager", func() {
+			ctx.SMWithBasic.GET(instanceURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.14").
+				Expect().Status(http.StatusOK).
+				JSON().Object().Equal(common.Object{
+				"service_id":    serviceID,
+				"plan_id":       planID,
+				"dashboard_url": "https://dashboard.example.com",
+				"parameters":    common.Object{"size": 5},
+			})
+			Expect(brokerServer.ServiceInstanceEndpointRequests).To(BeEmpty())
+		})
+
+		It("returns the merged parameters of updated service instances", func() {
+			brokerServer.ServiceInstanceHandler = func(rw http.ResponseWriter, req *http.Request) {
+				common.SetResponse(rw, http.StatusOK, common.Object{})
+			}
+			ctx.SMWithBasic.PATCH(instanceURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				WithJSON(common.Object{
+					"service_id": serviceID,
+					"parameters": common.Object{"zone": "eu"},
+				}).
+				Expect().Status(http.StatusOK)
+
+			ctx.SMWithBasic.GET(instanceURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.14").
+				Expect().Status(http.StatusOK).
+				JSON().Object().Value("parameters").Equal(common.Object{"size": 5, "zone": "eu"})
+		})
+
+		It("returns the service binding recorded by Service Manager", func() {
+			ctx.SMWithBasic.GET(bindingURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.14").
+				Expect().Status(http.StatusOK).
+				JSON().Object().Equal(common.Object{
+				"credentials": common.Object{
+					"instance_id": "12345",
+					"binding_id":  "binding-id",
+				},
+				"parameters": common.Object{"role": "reader"},
+			})
+			Expect(brokerServer.BindingEndpointRequests).To(BeEmpty())
+		})
+
+		It("does not expose the credentials of service bindings in the Service Manager API", func() {
+			ctx.SMWithOAuth.GET("/v1/service_bindings/binding-id").
+				Expect().Status(http.StatusOK).
+				JSON().Object().NotContainsKey("credentials")
+		})
+
+		It("does not return the resources created by other platforms", func() {
+			platform := common.RegisterPlatformInSM(common.GenerateRandomPlatform(), ctx.SMWithOAuth)
+			defer ctx.SMWithOAuth.DELETE("/v1/platforms/" + platform.ID).Expect().Status(http.StatusOK)
+			otherPlatform := ctx.SM.Builder(func(req *httpexpect.Request) {
+				req.WithBasicAuth(platform.Credentials.Basic.Username, platform.Credentials.Basic.Password)
+			})
+
+			otherPlatform.GET(instanceURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.14").
+				Expect().Status(http.StatusNotFound)
+			otherPlatform.GET(bindingURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.14").
+				Expect().Status(http.StatusNotFound)
+			Expect(brokerServer.ServiceInstanceEndpointRequests).To(BeEmpty())
+			Expect(brokerServer.BindingEndpointRequests).To(BeEmpty())
+		})
+
+		It("forwards requests for resources which are not tracked to the broker", func() {
+			ctx.SMWithBasic.GET("/v1/osb/"+brokerID+"/v2/service_instances/unknown").
+				WithHeader("X-Broker-API-Version", "oidc_authn.14").
+				Expect()
+			Expect(brokerServer.ServiceInstanceEndpointRequests).To(HaveLen(1))
+		})
+
+		Context("when only the plan is not bindings retrievable", func() {
+			BeforeEach(func() {
+				var err error
+				service, err = sjson.Set(service, "bindings_retrievable", true)
+				Expect(err).ToNot(HaveOccurred())
+				service, err = sjson.Set(service, "plans.0.bindings_retrievable", false)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("returns the service binding recorded by Service Manager", func() {
+				ctx.SMWithBasic.GET(bindingURL).
+					WithHeader("X-Broker-API-Version", "oidc_authn.14").
+					Expect().Status(http.StatusOK).
+					JSON().Object().Value("parameters").Equal(common.Object{"role": "reader"})
+				Expect(brokerServer.BindingEndpointRequests).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("Orphan mitigation", func() {
+		var (
+			brokerID     string
+			brokerServer *common.BrokerServer
+			instanceURL  string
+			provisionReq common.Object
+		)
+
+		deleteRequests := func(requests []*http.Request) []*http.Request {
+			result := make([]*http.Request, 0)
+			for _, request := range requests {
+				if request.Method == http.MethodDelete {
+					result = append(result, request)
+				}
+			}
+			return result
+		}
+
+		BeforeEach(func() {
+			brokerID, _, brokerServer = ctx.RegisterBroker()
+			instanceURL = "/v1/osb/" + brokerID + "/v2/service_instances/12345"
+			provisionReq = common.Object{
+				"service_id": "dummyId",
+				"plan_id":    "dummyPlanId",
+			}
+		})
+
+		AfterEach(func() {
+			ctx.CleanupBroker(brokerID)
+		})
+
+		Context("when provision fails with a server error", func() {
+			It("deprovisions the service instance", func() {
+				brokerServer.ServiceInstanceHandler = func(rw http.ResponseWriter, req *http.Request) {
+					if req.Method == http.MethodPut {
+						common.SetResponse(rw, http.StatusInternalServerError, common.Object{})
+						return
+					}
+					common.SetResponse(rw, http.StatusOK, common.Object{})
+				}
+				ctx.SMWithBasic.PUT(instanceURL).
+					WithHeader("X-Broker-API-Version", "oidc_authn.13").
+					WithJSON(provisionReq).
+					Expect().Status(http.StatusInternalServerError)
+
+				Eventually(func() []*http.Request {
+					return deleteRequests(brokerServer.ServiceInstanceEndpointRequests)
+				}, "5s", "50ms").Should(HaveLen(1))
+				query := deleteRequests(brokerServer.ServiceInstanceEndpointRequests)[0].URL.Query()
+				Expect(query.Get("service_id")).To(Equal("dummyId"))
+				Expect(query.Get("plan_id")).To(Equal("dummyPlanId"))
+				Expect(query.Get("accepts_incomplete")).To(Equal("true"))
+			})
+		})
+
+		Context("when provision returns 201 Created with a malformed body", func() {
+			It("deprovisions the service instance", func() {
+				brokerServer.ServiceInstanceHandler = func(rw http.ResponseWriter, req *http.Request) {
+					if req.Method == http.MethodPut {
+						rw.WriteHeader(http.StatusCreated)
+						rw.Write([]byte("{malformed"))
+						return
+					}
+					common.SetResponse(rw, http.StatusOK, common.Object{})
+				}
+				ctx.SMWithBasic.PUT(instanceURL).
+					WithHeader("X-Broker-API-Version", "oidc_authn.13").
+					WithJSON(provisionReq).
+					Expect().Status(http.StatusCreated)
+
+				Eventually(func() []*http.Request {
+					return deleteRequests(brokerServer.ServiceInstanceEndpointRequests)
+				}, "5s", "50ms").Should(HaveLen(1))
+			})
+		})
+
+		Context("when bind fails with a server error", func() {
+			It("unbinds the service binding", func() {
+				brokerServer.BindingHandler = func(rw http.ResponseWriter, req *http.Request) {
+					if req.Method == http.MethodPut {
+						common.SetResponse(rw, http.StatusServiceUnavailable, common.Object{})
+						return
+					}
+					common.SetResponse(rw, http.StatusOK, common.Object{})
+				}
+				ctx.SMWithBasic.PUT(instanceURL+"/service_bindings/54321").
+					WithHeader("X-Broker-API-Version", "oidc_authn.13").
+					WithJSON(provisionReq).
+					Expect().Status(http.StatusServiceUnavailable)
+
+				Eventually(func() []*http.Request {
+					return deleteRequests(brokerServer.BindingEndpointRequests)
+				}, "5s", "50ms").Should(HaveLen(1))
+			})
+		})
+
+		Context("when provision is rejected by the broker", func() {
+			It("does not deprovision the service instance", func() {
+				brokerServer.ServiceInstanceHandler = func(rw http.ResponseWriter, req *http.Request) {
+					common.SetResponse(rw, http.StatusBadRequest, common.Object{})
+				}
+				ctx.SMWithBasic.PUT(instanceURL).
+					WithHeader("X-Broker-API-Version", "oidc_authn.13").
+					WithJSON(provisionReq).
+					Expect().Status(http.StatusBadRequest)
+
+				Consistently(func() []*http.Request {
+					return deleteRequests(brokerServer.ServiceInstanceEndpointRequests)
+				}, "500ms", "50ms").Should(BeEmpty())
+			})
+		})
+
+		Context("when the deprovision fails", func() {
+			It("retries the deprovision", func() {
+				deprovisionAttempts := 0
+				brokerServer.ServiceInstanceHandler = func(rw http.ResponseWriter, req *http.Request) {
+					if req.Method == http.MethodDelete {
+						deprovisionAttempts++
+						if deprovisionAttempts > 1 {
+							common.SetResponse(rw, http.StatusOK, common.Object{})
+							return
+						}
+					}
+					common.SetResponse(rw, http.StatusInternalServerError, common.Object{})
+				}
+				ctx.SMWithBasic.PUT(instanceURL).
+					WithHeader("X-Broker-API-Version", "oidc_authn.13").
+					WithJSON(provisionReq).
+					Expect().Status(http.StatusInternalServerError)
+
+				Eventually(func() []*http.Request {
+					return deleteRequests(brokerServer.ServiceInstanceEndpointRequests)
+				}, "5s", "50ms").Should(HaveLen(2))
+				Consistently(func() []*http.Request {
+					return deleteRequests(brokerServer.ServiceInstanceEndpointRequests)
+				}, "500ms", "50ms").Should(HaveLen(2))
+			})
+		})
+
+		Context("when the deprovision is accepted asynchronously", func() {
+			It("polls the last operation until the deprovision finishes", func() {
+				brokerServer.ServiceInstanceHandler = func(rw http.ResponseWriter, req *http.Request) {
+					if req.Method == http.MethodDelete {
+						common.SetResponse(rw, http.StatusAccepted, common.Object{"operation": "deprovision-1"})
+						return
+					}
+					common.SetResponse(rw, http.StatusInternalServerError, common.Object{})
+				}
+				lastOperationRequests := 0
+				brokerServer.ServiceInstanceLastOpHandler = func(rw http.ResponseWriter, req *http.Request) {
+					lastOperationRequests++
+					if lastOperationRequests < 2 {
+						common.SetResponse(rw, http.StatusOK, common.Object{"state": "in progress"})
+						return
+					}
+					common.SetResponse(rw, http.StatusOK, common.Object{"state": "succeeded"})
+				}
+				ctx.SMWithBasic.PUT(instanceURL).
+					WithHeader("X-Broker-API-Version", "oidc_authn.13").
+					WithJSON(provisionReq).
+					Expect().Status(http.StatusInternalServerError)
+
+				Eventually(func() []*http.Request {
+					return brokerServer.ServiceInstanceLastOpEndpointRequests
+				}, "5s", "50ms").Should(HaveLen(2))
+				Consistently(func() []*http.Request {
+					return brokerServer.ServiceInstanceLastOpEndpointRequests
+				}, "500ms", "50ms").Should(HaveLen(2))
+
+				Expect(deleteRequests(brokerServer.ServiceInstanceEndpointRequests)).To(HaveLen(1))
+				query := brokerServer.ServiceInstanceLastOpEndpointRequests[0].URL.Query()
+				Expect(query.Get("operation")).To(Equal("deprovision-1"))
+				Expect(query.Get("service_id")).To(Equal("dummyId"))
+				Expect(query.Get("plan_id")).To(Equal("dummyPlanId"))
+			})
+
+			It("deprovisions the service instance again if the deprovision fails", func() {
+				deprovisionAttempts := 0
+				brokerServer.ServiceInstanceHandler = func(rw http.ResponseWriter, req *http.Request) {
+					if req.Method == http.MethodDelete {
+						deprovisionAttempts++
+						if deprovisionAttempts > 1 {
+							common.SetResponse(rw, http.StatusOK, common.Object{})
+							return
+						}
+						common.SetResponse(rw, http.StatusAccepted, common.Object{})
+						return
+					}
+					common.SetResponse(rw, http.StatusInternalServerError, common.Object{})
+				}
+				brokerServer.ServiceInstanceLastOpHandler = func(rw http.ResponseWriter, req *http.Request) {
+					common.SetResponse(rw, http.StatusOK, common.Object{"state": "failed"})
+				}
+				ctx.SMWithBasic.PUT(instanceURL).
+					WithHeader("X-Broker-API-Version", "oidc_authn.13").
+					WithJSON(provisionReq).
+					Expect().Status(http.StatusInternalServerError)
+
+				Eventually(func() []*http.Request {
+					return deleteRequests(brokerServer.ServiceInstanceEndpointRequests)
+				}, "5s", "50ms").Should(HaveLen(2))
+				Consistently(func() []*http.Request {
+					return deleteRequests(brokerServer.ServiceInstanceEndpointRequests)
+				}, "500ms", "50ms").Should(HaveLen(2))
+				Expect(brokerServer.ServiceInstanceLastOpEndpointRequests).To(HaveLen(1))
+			})
+		})
+	})
 })
 
 type prefixedBrokerHandler struct {
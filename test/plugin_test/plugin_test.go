@@ -8,6 +8,7 @@ import (
 	"strconv"
 	"testing"
 
+	"github.com/Peripli/service-manager/pkg/catalog"
 	"github.com/Peripli/service-manager/pkg/env"
 
 	"github.com/Peripli/service-manager/pkg/sm"
@@ -212,8 +213,87 @@ var _ = Describe("Service Manager Plugins", func() {
 
 	})
 
+	Describe("Catalog transformer", func() {
+		var transformer *TestCatalogTransformer
+
+		BeforeEach(func() {
+			transformer = &TestCatalogTransformer{}
+
+			ctx = common.NewTestContextBuilder().WithSMExtensions(func(ctx context.Context, smb *sm.ServiceManagerBuilder, e env.Environment) error {
+				smb.CatalogTransformers().Register(transformer)
+				return nil
+			}).Build()
+		})
+
+		It("stores the transformed catalog", func() {
+			transformer.transform = func(ctx context.Context, req *catalog.Request, next catalog.Handler) error {
+				for _, service := range req.Catalog.Services {
+					service.Description = "transformed " + service.Description
+					service.Plans = service.Plans[:1]
+				}
+				return next.Handle(ctx, req)
+			}
+
+			brokerID, _, _ := ctx.RegisterBroker()
+			serviceOffering := ctx.SMWithOAuth.GET("/v1/service_offerings").
+				WithQuery("fieldQuery", "broker_id = "+brokerID).
+				Expect().Status(http.StatusOK).
+				JSON().Path("$.service_offerings[0]").Object()
+			serviceOffering.Value("description").String().HasPrefix("transformed ")
+
+			ctx.SMWithOAuth.GET("/v1/service_plans").
+				WithQuery("fieldQuery", "service_offering_id = "+serviceOffering.Value("id").String().Raw()).
+				Expect().Status(http.StatusOK).
+				JSON().Path("$.service_plans").Array().Length().Equal(1)
+		})
+
+		It("rejects the broker registration when the transformer returns an error", func() {
+			transformer.transform = func(ctx context.Context, req *catalog.Request, next catalog.Handler) error {
+				return &util.HTTPError{
+					ErrorType:   "CatalogRejected",
+					Description: "catalog rejected",
+					StatusCode:  http.StatusBadRequest,
+				}
+			}
+
+			brokerServer := common.NewBrokerServer()
+			defer brokerServer.Close()
+			ctx.SMWithOAuth.POST("/v1/service_brokers").
+				WithJSON(object{
+					"name":       "rejected-broker",
+					"broker_url": brokerServer.URL(),
+					"credentials": object{
+						"basic": object{
+							"username": brokerServer.Username,
+							"password": brokerServer.Password,
+						},
+					},
+				}).
+				Expect().Status(http.StatusBadRequest).
+				JSON().Object().Value("error").Equal("CatalogRejected")
+
+			ctx.SMWithOAuth.GET("/v1/service_brokers").
+				WithQuery("fieldQuery", "name = rejected-broker").
+				Expect().Status(http.StatusOK).
+				JSON().Path("$.service_brokers").Array().Empty()
+		})
+	})
+
 })
 
+type TestCatalogTransformer struct {
+	transform func(ctx context.Context, req *catalog.Request, next catalog.Handler) error
+}
+
+func (t *TestCatalogTransformer) Name() string { return "TestCatalogTransformer" }
+
+func (t *TestCatalogTransformer) TransformCatalog(ctx context.Context, req *catalog.Request, next catalog.Handler) error {
+	if t.transform == nil {
+		return next.Handle(ctx, req)
+	}
+	return t.transform(ctx, req, next)
+}
+
 type TestPlugin map[string]web.Middleware
 
 func (p TestPlugin) Name() string { return "TestPlugin" }
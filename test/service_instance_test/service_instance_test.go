@@ -0,0 +1,402 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package service_instance_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Peripli/service-manager/pkg/env"
+	"github.com/Peripli/service-manager/test/common"
+	"github.com/gavv/httpexpect"
+	"github.com/tidwall/gjson"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestServiceInstances(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Service Instances and Bindings API Tests Suite")
+}
+
+var _ = Describe("Service Instances and Bindings API", func() {
+	const (
+		instanceID = "instance-1"
+		bindingID  = "binding-1"
+	)
+
+	var (
+		ctx *common.TestContext
+
+		brokerID     string
+		brokerServer *common.BrokerServer
+		provisionReq common.Object
+
+		instancePath string
+		bindingPath  string
+	)
+
+	BeforeSuite(func() {
+		ctx = common.DefaultTestContext()
+	})
+
+	AfterSuite(func() {
+		ctx.Cleanup()
+	})
+
+	BeforeEach(func() {
+		brokerID, _, brokerServer = ctx.RegisterBroker()
+		catalog := string(brokerServer.Catalog)
+		provisionReq = common.Object{
+			"service_id": gjson.Get(catalog, "services.0.id").Str,
+			"plan_id":    gjson.Get(catalog, "services.0.plans.0.id").Str,
+			"context": common.Object{
+				"platform": "kubernetes",
+			},
+		}
+		instancePath = "/v1/osb/" + brokerID + "/v2/service_instances/" + instanceID
+		bindingPath = instancePath + "/service_bindings/" + bindingID
+	})
+
+	AfterEach(func() {
+		ctx.CleanupBroker(brokerID)
+	})
+
+	provision := func(expectedStatus int) {
+		ctx.SMWithBasic.PUT(instancePath).
+			WithHeader("X-Broker-API-Version", "2.14").
+			WithQuery("accepts_incomplete", true).
+			WithJSON(provisionReq).
+			Expect().Status(expectedStatus)
+	}
+
+	Context("when a service instance is provisioned synchronously", func() {
+		BeforeEach(func() {
+			provision(http.StatusCreated)
+		})
+
+		It("is listed as ready", func() {
+			instance := ctx.SMWithOAuth.GET("/v1/service_instances/" + instanceID).
+				Expect().Status(http.StatusOK).JSON().Object()
+			instance.Value("broker_id").Equal(brokerID)
+			instance.Value("ready").Equal(true)
+			instance.Value("platform_id").String().NotEmpty()
+			instance.Value("service_plan_id").String().NotEmpty()
+			instance.Value("context").Object().Value("platform").Equal("kubernetes")
+			instance.NotContainsKey("pending_operation")
+
+			ctx.SMWithOAuth.GET("/v1/service_instances").
+				Expect().Status(http.StatusOK).
+				JSON().Path("$.service_instances[*].id").Array().Contains(instanceID)
+		})
+
+		It("is visible only to the platform which provisioned it", func() {
+			ctx.SMWithBasic.GET("/v1/service_instances/" + instanceID).
+				Expect().Status(http.StatusOK)
+			ctx.SMWithBasic.GET("/v1/service_instances").
+				Expect().Status(http.StatusOK).
+				JSON().Path("$.service_instances[*].id").Array().Contains(instanceID)
+
+			platform := common.RegisterPlatformInSM(common.GenerateRandomPlatform(), ctx.SMWithOAuth)
+			defer ctx.SMWithOAuth.DELETE("/v1/platforms/" + platform.ID).Expect().Status(http.StatusOK)
+			otherPlatform := ctx.SM.Builder(func(req *httpexpect.Request) {
+				req.WithBasicAuth(platform.Credentials.Basic.Username, platform.Credentials.Basic.Password)
+			})
+
+			otherPlatform.GET("/v1/service_instances/" + instanceID).
+				Expect().Status(http.StatusNotFound)
+			otherPlatform.GET("/v1/service_instances").
+				Expect().Status(http.StatusOK).
+				JSON().Path("$.service_instances[*].id").Array().NotContains(instanceID)
+		})
+
+		It("is not taken over by other platforms provisioning the same id", func() {
+			platform := common.RegisterPlatformInSM(common.GenerateRandomPlatform(), ctx.SMWithOAuth)
+			defer ctx.SMWithOAuth.DELETE("/v1/platforms/" + platform.ID).Expect().Status(http.StatusOK)
+			otherPlatform := ctx.SM.Builder(func(req *httpexpect.Request) {
+				req.WithBasicAuth(platform.Credentials.Basic.Username, platform.Credentials.Basic.Password)
+			})
+
+			otherPlatform.PUT(instancePath).
+				WithHeader("X-Broker-API-Version", "2.14").
+				WithJSON(provisionReq).
+				Expect().Status(http.StatusCreated)
+
+			ctx.SMWithBasic.GET("/v1/service_instances/" + instanceID).
+				Expect().Status(http.StatusOK)
+			otherPlatform.GET("/v1/service_instances/" + instanceID).
+				Expect().Status(http.StatusNotFound)
+		})
+
+		It("is removed when the service instance is deprovisioned", func() {
+			ctx.SMWithBasic.DELETE(instancePath).
+				WithHeader("X-Broker-API-Version", "2.14").
+				Expect().Status(http.StatusOK)
+
+			ctx.SMWithOAuth.GET("/v1/service_instances/" + instanceID).
+				Expect().Status(http.StatusNotFound)
+		})
+
+		It("is not changed when the broker rejects the deprovision", func() {
+			brokerServer.ServiceInstanceHandler = func(rw http.ResponseWriter, req *http.Request) {
+				common.SetResponse(rw, http.StatusInternalServerError, common.Object{})
+			}
+			ctx.SMWithBasic.DELETE(instancePath).
+				WithHeader("X-Broker-API-Version", "2.14").
+				Expect().Status(http.StatusInternalServerError)
+
+			ctx.SMWithOAuth.GET("/v1/service_instances/" + instanceID).
+				Expect().Status(http.StatusOK).
+				JSON().Object().Value("ready").Equal(true)
+		})
+
+		Context("and a service binding is created", func() {
+			BeforeEach(func() {
+				ctx.SMWithBasic.PUT(bindingPath).
+					WithHeader("X-Broker-API-Version", "2.14").
+					WithJSON(provisionReq).
+					Expect().Status(http.StatusCreated)
+			})
+
+			It("is listed as ready", func() {
+				binding := ctx.SMWithOAuth.GET("/v1/service_bindings/" + bindingID).
+					Expect().Status(http.StatusOK).JSON().Object()
+				binding.Value("service_instance_id").Equal(instanceID)
+				binding.Value("broker_id").Equal(brokerID)
+				binding.Value("ready").Equal(true)
+
+				ctx.SMWithOAuth.GET("/v1/service_bindings").
+					Expect().Status(http.StatusOK).
+					JSON().Path("$.service_bindings[*].id").Array().Contains(bindingID)
+			})
+
+			It("is visible only to the platform which created it", func() {
+				ctx.SMWithBasic.GET("/v1/service_bindings/" + bindingID).
+					Expect().Status(http.StatusOK)
+
+				platform := common.RegisterPlatformInSM(common.GenerateRandomPlatform(), ctx.SMWithOAuth)
+				defer ctx.SMWithOAuth.DELETE("/v1/platforms/" + platform.ID).Expect().Status(http.StatusOK)
+				otherPlatform := ctx.SM.Builder(func(req *httpexpect.Request) {
+					req.WithBasicAuth(platform.Credentials.Basic.Username, platform.Credentials.Basic.Password)
+				})
+
+				otherPlatform.GET("/v1/service_bindings/" + bindingID).
+					Expect().Status(http.StatusNotFound)
+				otherPlatform.GET("/v1/service_bindings").
+					Expect().Status(http.StatusOK).
+					JSON().Path("$.service_bindings[*].id").Array().NotContains(bindingID)
+			})
+
+			It("is not taken over by other platforms creating the same id", func() {
+				platform := common.RegisterPlatformInSM(common.GenerateRandomPlatform(), ctx.SMWithOAuth)
+				defer ctx.SMWithOAuth.DELETE("/v1/platforms/" + platform.ID).Expect().Status(http.StatusOK)
+				otherPlatform := ctx.SM.Builder(func(req *httpexpect.Request) {
+					req.WithBasicAuth(platform.Credentials.Basic.Username, platform.Credentials.Basic.Password)
+				})
+
+				otherPlatform.PUT(bindingPath).
+					WithHeader("X-Broker-API-Version", "2.14").
+					WithJSON(provisionReq).
+					Expect().Status(http.StatusCreated)
+
+				ctx.SMWithBasic.GET("/v1/service_bindings/" + bindingID).
+					Expect().Status(http.StatusOK)
+				otherPlatform.GET("/v1/service_bindings/" + bindingID).
+					Expect().Status(http.StatusNotFound)
+			})
+
+			It("is removed when the service binding is deleted", func() {
+				ctx.SMWithBasic.DELETE(bindingPath).
+					WithHeader("X-Broker-API-Version", "2.14").
+					Expect().Status(http.StatusOK)
+
+				ctx.SMWithOAuth.GET("/v1/service_bindings/" + bindingID).
+					Expect().Status(http.StatusNotFound)
+			})
+
+			It("is removed together with its service instance", func() {
+				ctx.SMWithBasic.DELETE(instancePath).
+					WithHeader("X-Broker-API-Version", "2.14").
+					Expect().Status(http.StatusOK)
+
+				ctx.SMWithOAuth.GET("/v1/service_bindings/" + bindingID).
+					Expect().Status(http.StatusNotFound)
+			})
+		})
+	})
+
+	Context("when a service instance is provisioned asynchronously", func() {
+		BeforeEach(func() {
+			brokerServer.ServiceInstanceHandler = func(rw http.ResponseWriter, req *http.Request) {
+				common.SetResponse(rw, http.StatusAccepted, common.Object{"operation": "provision-token"})
+			}
+			provision(http.StatusAccepted)
+		})
+
+		It("is listed with a pending create operation", func() {
+			instance := ctx.SMWithOAuth.GET("/v1/service_instances/" + instanceID).
+				Expect().Status(http.StatusOK).JSON().Object()
+			instance.Value("ready").Equal(false)
+			instance.Value("pending_operation").Equal("create")
+		})
+
+		It("records the operation accepted by the broker", func() {
+			operation := listOperations(ctx, brokerID).Element(0).Object()
+			operation.Value("id").String().NotEmpty()
+			operation.Value("type").Equal("create")
+			operation.Value("state").Equal("in progress")
+			operation.Value("resource_type").Equal("service_instance")
+			operation.Value("resource_id").Equal(instanceID)
+			operation.Value("operation").Equal("provision-token")
+			operation.Value("deadline").String().NotEmpty()
+
+			ctx.SMWithOAuth.GET("/v1/operations/" + operation.Value("id").String().Raw()).
+				Expect().Status(http.StatusOK).
+				JSON().Object().Value("resource_id").Equal(instanceID)
+		})
+
+		It("becomes ready when the last operation succeeds", func() {
+			ctx.SMWithBasic.GET(instancePath+"/last_operation").
+				WithHeader("X-Broker-API-Version", "2.14").
+				WithQuery("operation", "provision-token").
+				Expect().Status(http.StatusOK)
+
+			instance := ctx.SMWithOAuth.GET("/v1/service_instances/" + instanceID).
+				Expect().Status(http.StatusOK).JSON().Object()
+			instance.Value("ready").Equal(true)
+			instance.NotContainsKey("pending_operation")
+
+			listOperations(ctx, brokerID).Element(0).Object().Value("state").Equal("succeeded")
+		})
+
+		It("is removed when the last operation fails", func() {
+			brokerServer.ServiceInstanceLastOpHandler = func(rw http.ResponseWriter, req *http.Request) {
+				common.SetResponse(rw, http.StatusOK, common.Object{"state": "failed", "description": "out of capacity"})
+			}
+			ctx.SMWithBasic.GET(instancePath+"/last_operation").
+				WithHeader("X-Broker-API-Version", "2.14").
+				Expect().Status(http.StatusOK)
+
+			ctx.SMWithOAuth.GET("/v1/service_instances/" + instanceID).
+				Expect().Status(http.StatusNotFound)
+
+			operation := listOperations(ctx, brokerID).Element(0).Object()
+			operation.Value("state").Equal("failed")
+			operation.Value("description").Equal("out of capacity")
+		})
+
+		It("keeps the operation in progress while the broker reports it in progress", func() {
+			brokerServer.ServiceInstanceLastOpHandler = func(rw http.ResponseWriter, req *http.Request) {
+				common.SetResponse(rw, http.StatusOK, common.Object{"state": "in progress"})
+			}
+			ctx.SMWithBasic.GET(instancePath+"/last_operation").
+				WithHeader("X-Broker-API-Version", "2.14").
+				Expect().Status(http.StatusOK)
+
+			listOperations(ctx, brokerID).Element(0).Object().Value("state").Equal("in progress")
+		})
+	})
+
+	Context("when a service binding is created for an untracked service instance", func() {
+		It("is not tracked", func() {
+			ctx.SMWithBasic.PUT(bindingPath).
+				WithHeader("X-Broker-API-Version", "2.14").
+				WithJSON(provisionReq).
+				Expect().Status(http.StatusCreated)
+
+			ctx.SMWithOAuth.GET("/v1/service_bindings/" + bindingID).
+				Expect().Status(http.StatusNotFound)
+		})
+	})
+})
+
+var _ = Describe("Operation polling", func() {
+	const instanceID = "polled-instance"
+
+	var (
+		ctx *common.TestContext
+
+		brokerID     string
+		brokerServer *common.BrokerServer
+	)
+
+	BeforeEach(func() {
+		ctx = common.NewTestContextBuilder().WithEnvPostExtensions(func(e env.Environment, servers map[string]common.FakeServer) {
+			e.Set("api.operation_polling_interval", "100ms")
+			e.Set("api.maximum_polling_duration", "1s")
+		}).Build()
+
+		brokerID, _, brokerServer = ctx.RegisterBroker()
+		brokerServer.ServiceInstanceHandler = func(rw http.ResponseWriter, req *http.Request) {
+			common.SetResponse(rw, http.StatusAccepted, common.Object{"operation": "provision-token"})
+		}
+	})
+
+	AfterEach(func() {
+		ctx.Cleanup()
+	})
+
+	provision := func() {
+		catalog := string(brokerServer.Catalog)
+		ctx.SMWithBasic.PUT("/v1/osb/"+brokerID+"/v2/service_instances/"+instanceID).
+			WithHeader("X-Broker-API-Version", "2.14").
+			WithQuery("accepts_incomplete", true).
+			WithJSON(common.Object{
+				"service_id": gjson.Get(catalog, "services.0.id").Str,
+				"plan_id":    gjson.Get(catalog, "services.0.plans.0.id").Str,
+			}).
+			Expect().Status(http.StatusAccepted)
+	}
+
+	It("resolves operations which are not polled by the platform", func() {
+		provision()
+
+		Eventually(func() string {
+			return listOperations(ctx, brokerID).Element(0).Object().Value("state").String().Raw()
+		}, "5s", "100ms").Should(Equal("succeeded"))
+
+		lastOperationRequest := brokerServer.ServiceInstanceLastOpEndpointRequests[0]
+		Expect(lastOperationRequest.URL.Query().Get("operation")).To(Equal("provision-token"))
+		Expect(lastOperationRequest.URL.Query().Get("plan_id")).ToNot(BeEmpty())
+
+		ctx.SMWithOAuth.GET("/v1/service_instances/" + instanceID).
+			Expect().Status(http.StatusOK).
+			JSON().Object().Value("ready").Equal(true)
+	})
+
+	It("fails operations which do not finish within the maximum polling duration", func() {
+		brokerServer.ServiceInstanceLastOpHandler = func(rw http.ResponseWriter, req *http.Request) {
+			common.SetResponse(rw, http.StatusOK, common.Object{"state": "in progress"})
+		}
+		provision()
+
+		Eventually(func() string {
+			return listOperations(ctx, brokerID).Element(0).Object().Value("state").String().Raw()
+		}, "5s", "100ms").Should(Equal("failed"))
+
+		ctx.SMWithOAuth.GET("/v1/service_instances/" + instanceID).
+			Expect().Status(http.StatusNotFound)
+	})
+})
+
+func listOperations(ctx *common.TestContext, brokerID string) *httpexpect.Array {
+	return ctx.SMWithOAuth.GET("/v1/operations").
+		WithQuery("fieldQuery", "broker_id = "+brokerID).
+		Expect().Status(http.StatusOK).
+		JSON().Path("$.operations").Array()
+}
@@ -45,7 +45,7 @@ var _ = test.DescribeTestsFor(test.TestCase{
 })
 
 func blueprint(ctx *common.TestContext) common.Object {
-	cService := common.GenerateTestServiceWithPlans()
+	cService := common.GenerateTestServiceWithPlans(common.GenerateTestPlan())
 	catalog := common.NewEmptySBCatalog()
 	catalog.AddService(cService)
 	id, _, _ := ctx.RegisterBrokerWithCatalog(catalog)
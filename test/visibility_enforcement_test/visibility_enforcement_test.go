@@ -0,0 +1,253 @@
+/*
+ *    Copyright 2018 The Service Manager Authors
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package visibility_enforcement_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gavv/httpexpect"
+	"github.com/tidwall/gjson"
+
+	"github.com/Peripli/service-manager/pkg/env"
+	"github.com/Peripli/service-manager/test/common"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestVisibilityEnforcement(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Visibility Enforcement Tests Suite")
+}
+
+var _ = Describe("Visibility enforcement", func() {
+	var (
+		ctx *common.TestContext
+
+		otherPlatformID string
+
+		brokerID      string
+		brokerServer  *common.BrokerServer
+		instanceURL   string
+		serviceID     string
+		planID        string
+		otherPlanID   string
+		servicePlanID string
+		provisionReq  common.Object
+	)
+
+	createLabeledVisibility := func(servicePlanID, platformID string, labels common.Object) {
+		visibility := common.Object{"service_plan_id": servicePlanID}
+		if platformID != "" {
+			visibility["platform_id"] = platformID
+		}
+		if labels != nil {
+			visibility["labels"] = labels
+		}
+		ctx.SMWithOAuth.POST("/v1/visibilities").WithJSON(visibility).
+			Expect().Status(http.StatusCreated)
+	}
+
+	createVisibility := func(platformID string) {
+		createLabeledVisibility(servicePlanID, platformID, nil)
+	}
+
+	smPlanID := func(catalogID string) string {
+		return ctx.SMWithOAuth.GET("/v1/service_plans").
+			WithQuery("fieldQuery", "catalog_id = "+catalogID).
+			Expect().Status(http.StatusOK).
+			JSON().Path("$.service_plans[0].id").String().Raw()
+	}
+
+	BeforeSuite(func() {
+		ctx = common.NewTestContextBuilder().WithEnvPostExtensions(func(e env.Environment, servers map[string]common.FakeServer) {
+			e.Set("api.enforce_visibilities", true)
+		}).Build()
+		otherPlatformID = ctx.RegisterPlatform().ID
+	})
+
+	AfterSuite(func() {
+		ctx.Cleanup()
+	})
+
+	BeforeEach(func() {
+		brokerID, _, brokerServer = ctx.RegisterBroker()
+		instanceURL = "/v1/osb/" + brokerID + "/v2/service_instances/12345"
+
+		catalog := string(brokerServer.Catalog)
+		serviceID = gjson.Get(catalog, "services.0.id").Str
+		planID = gjson.Get(catalog, "services.0.plans.0.id").Str
+		otherPlanID = gjson.Get(catalog, "services.0.plans.1.id").Str
+		provisionReq = common.Object{
+			"service_id": serviceID,
+			"plan_id":    planID,
+		}
+
+		servicePlanID = smPlanID(planID)
+	})
+
+	AfterEach(func() {
+		ctx.CleanupBroker(brokerID)
+	})
+
+	Describe("catalog", func() {
+		var catalogURL string
+
+		BeforeEach(func() {
+			catalogURL = "/v1/osb/" + brokerID + "/v2/catalog"
+		})
+
+		catalogPlanIDs := func(req *httpexpect.Request) []interface{} {
+			return req.WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				Expect().Status(http.StatusOK).
+				JSON().Path("$.services[*].plans[*].id").Array().Raw()
+		}
+
+		It("contains no plans for platforms without visibilities", func() {
+			ctx.SMWithBasic.GET(catalogURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				Expect().Status(http.StatusOK).
+				JSON().Object().Value("services").Array().Empty()
+		})
+
+		It("contains the plans visible to the platform", func() {
+			createVisibility(ctx.TestPlatform.ID)
+			Expect(catalogPlanIDs(ctx.SMWithBasic.GET(catalogURL))).To(ConsistOf(planID))
+		})
+
+		It("contains the public plans", func() {
+			createVisibility("")
+			Expect(catalogPlanIDs(ctx.SMWithBasic.GET(catalogURL))).To(ConsistOf(planID))
+		})
+
+		It("does not contain the plans visible to other platforms only", func() {
+			createVisibility(otherPlatformID)
+			ctx.SMWithBasic.GET(catalogURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				Expect().Status(http.StatusOK).
+				JSON().Object().Value("services").Array().Empty()
+		})
+
+		Context("when label criteria are specified", func() {
+			BeforeEach(func() {
+				createLabeledVisibility(servicePlanID, ctx.TestPlatform.ID, common.Object{
+					"cluster_id": common.Array{"cluster1"},
+				})
+				createLabeledVisibility(smPlanID(otherPlanID), "", nil)
+			})
+
+			It("contains the plans with matching visibilities and the public plans", func() {
+				plans := catalogPlanIDs(ctx.SMWithBasic.GET(catalogURL).WithQuery("labelQuery", "cluster_id = cluster1"))
+				Expect(plans).To(ConsistOf(planID, otherPlanID))
+			})
+
+			It("does not contain the plans without matching visibilities", func() {
+				plans := catalogPlanIDs(ctx.SMWithBasic.GET(catalogURL).WithQuery("labelQuery", "cluster_id = cluster2"))
+				Expect(plans).To(ConsistOf(otherPlanID))
+			})
+		})
+	})
+
+	Describe("provision", func() {
+		Context("when there is no visibility for the plan", func() {
+			It("rejects the requests of platforms", func() {
+				ctx.SMWithBasic.PUT(instanceURL).
+					WithHeader("X-Broker-API-Version", "oidc_authn.13").
+					WithJSON(provisionReq).
+					Expect().Status(http.StatusForbidden).
+					JSON().Object().Keys().Contains("error", "description")
+				Expect(brokerServer.ServiceInstanceEndpointRequests).To(BeEmpty())
+			})
+		})
+
+		Context("when the plan is visible to another platform only", func() {
+			It("rejects the requests of the platform", func() {
+				createVisibility(otherPlatformID)
+				ctx.SMWithBasic.PUT(instanceURL).
+					WithHeader("X-Broker-API-Version", "oidc_authn.13").
+					WithJSON(provisionReq).
+					Expect().Status(http.StatusForbidden)
+			})
+		})
+
+		Context("when the plan is visible to the platform", func() {
+			It("forwards the requests to the broker", func() {
+				createVisibility(ctx.TestPlatform.ID)
+				ctx.SMWithBasic.PUT(instanceURL).
+					WithHeader("X-Broker-API-Version", "oidc_authn.13").
+					WithJSON(provisionReq).
+					Expect().Status(http.StatusCreated)
+			})
+		})
+
+		Context("when the plan has a public visibility", func() {
+			It("forwards the requests to the broker", func() {
+				createVisibility("")
+				ctx.SMWithBasic.PUT(instanceURL).
+					WithHeader("X-Broker-API-Version", "oidc_authn.13").
+					WithJSON(provisionReq).
+					Expect().Status(http.StatusCreated)
+			})
+		})
+
+		Context("when the plan is not offered by the broker", func() {
+			It("rejects the requests of platforms", func() {
+				ctx.SMWithBasic.PUT(instanceURL).
+					WithHeader("X-Broker-API-Version", "oidc_authn.13").
+					WithJSON(common.Object{
+						"service_id": serviceID,
+						"plan_id":    "unknown-plan",
+					}).
+					Expect().Status(http.StatusForbidden)
+			})
+		})
+	})
+
+	Describe("update", func() {
+		BeforeEach(func() {
+			createVisibility(ctx.TestPlatform.ID)
+		})
+
+		It("rejects plan changes to plans not visible to the platform", func() {
+			ctx.SMWithBasic.PATCH(instanceURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				WithJSON(common.Object{
+					"service_id": serviceID,
+					"plan_id":    otherPlanID,
+				}).
+				Expect().Status(http.StatusForbidden)
+		})
+
+		It("forwards plan changes to visible plans to the broker", func() {
+			ctx.SMWithBasic.PATCH(instanceURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				WithJSON(provisionReq).
+				Expect().Status(http.StatusOK)
+		})
+
+		It("forwards updates which do not change the plan to the broker", func() {
+			ctx.SMWithBasic.PATCH(instanceURL).
+				WithHeader("X-Broker-API-Version", "oidc_authn.13").
+				WithJSON(common.Object{
+					"service_id": serviceID,
+					"parameters": common.Object{"key": "value"},
+				}).
+				Expect().Status(http.StatusOK)
+		})
+	})
+})